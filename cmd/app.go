@@ -0,0 +1,245 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"edubot/internal/config"
+	"edubot/internal/handlers"
+	"edubot/internal/models"
+	"edubot/internal/policy"
+	"edubot/internal/realtime"
+	"edubot/internal/repository"
+	"edubot/internal/services"
+	"edubot/pkg/database"
+	"edubot/pkg/email"
+	"edubot/pkg/ratelimit"
+	"edubot/pkg/storage"
+	"edubot/pkg/telegram"
+
+	"github.com/google/uuid"
+)
+
+// app — собранные зависимости сервера: сервисы для бота и фоновых задач, обработчики для маршрутов
+type app struct {
+	cfg            *config.Config
+	db             *database.Database
+	telegramBot    *telegram.Bot
+	realtimeBroker realtime.Broker
+
+	userRepo            repository.UserRepository
+	telegramLinkRepo    repository.TelegramMessageLinkRepository
+	authService         *services.AuthService
+	notificationService services.NotificationService
+	assignmentService   services.AssignmentService
+	chatService         services.ChatService
+	mediaService        services.MediaService
+	groupService        services.GroupService
+	parentService       services.ParentService
+	inviteService       services.InviteService
+
+	authIPLimiter        *ratelimit.Limiter
+	trialIPLimiter       *ratelimit.Limiter
+	authHandler          *handlers.AuthHandler
+	sessionHandler       *handlers.SessionHandler
+	accessTokenHandler   *handlers.AccessTokenHandler
+	assignmentHandler    *handlers.AssignmentHandler
+	studentHandler       *handlers.StudentHandler
+	chatHandler          *handlers.ChatHandler
+	realtimeHandler      *handlers.RealtimeHandler
+	teacherInboxHandler  *handlers.TeacherInboxHandler
+	assistantHandler     *handlers.AssistantHandler
+	groupHandler         *handlers.GroupHandler
+	parentHandler        *handlers.ParentHandler
+	adminHandler         *handlers.AdminHandler
+	auditHandler         *handlers.AuditHandler
+	privacyHandler       *handlers.PrivacyHandler
+	inviteHandler        *handlers.InviteHandler
+	mediaHandler         *handlers.MediaHandler
+	homepageMediaHandler *handlers.HomepageMediaHandler
+	notificationHandler  *handlers.NotificationHandler
+	emailHandler         *handlers.EmailHandler
+}
+
+// newApp создает репозитории, сервисы и обработчики. telegramBot и emailSender могут быть nil,
+// если бот или SMTP не настроены на этом окружении.
+func newApp(cfg *config.Config, db *database.Database, fileStorage *storage.Storage, telegramBot *telegram.Bot, emailSender email.Sender) (*app, error) {
+	// Создаем репозитории
+	userRepo := repository.NewUserRepository(db.DB)
+	trialRepo := repository.NewTrialRequestRepository(db.DB)
+	assignmentRepo := repository.NewAssignmentRepository(db.DB)
+	assignmentTargetRepo := repository.NewAssignmentTargetRepository(db.DB)
+	feedbackRepo := repository.NewFeedbackRepository(db.DB)
+	submissionRepo := repository.NewSubmissionRepository(db.DB)
+	draftRepo := repository.NewDraftRepository(db.DB)
+	chatRepo := repository.NewChatRepository(db.DB)
+	notificationRepo := repository.NewNotificationRepository(db.DB)
+	notificationPreferenceRepo := repository.NewNotificationPreferenceRepository(db.DB)
+	groupRepo := repository.NewGroupRepository(db.DB)
+	mediaRepo := repository.NewMediaRepository(db.DB)
+	homepageMediaRepo := repository.NewHomepageMediaRepository(db.DB)
+	sessionRepo := repository.NewSessionRepository(db.DB)
+	accessTokenRepo := repository.NewAccessTokenRepository(db.DB)
+	teacherStudentRepo := repository.NewTeacherStudentRepository(db.DB)
+	parentRepo := repository.NewParentRepository(db.DB)
+	roleChangeRepo := repository.NewRoleChangeRepository(db.DB)
+	auditLogRepo := repository.NewAuditLogRepository(db.DB)
+	userDataRepo := repository.NewUserDataRepository(db.DB)
+	telegramLinkRepo := repository.NewTelegramMessageLinkRepository(db.DB)
+	inviteRepo := repository.NewInviteRepository(db.DB)
+
+	// Восстанавливаем связи преподаватель–ученик для данных, созданных до их появления
+	var defaultTeacherID *uuid.UUID
+	if teacher, err := userRepo.GetByTelegramID(cfg.TeacherTelegramID); err == nil && teacher.Role == models.RoleTeacher {
+		defaultTeacherID = &teacher.ID
+	}
+	if linked, err := teacherStudentRepo.Backfill(defaultTeacherID); err != nil {
+		log.Printf("Failed to backfill teacher-student links: %v", err)
+	} else if linked > 0 {
+		log.Printf("Backfilled %d teacher-student links", linked)
+	}
+
+	// Политика доступа: владелец-преподаватель и роли участников групп
+	accessPolicy := policy.New(groupRepo)
+
+	// Создаем сервисы
+	auditService := services.NewAuditService(auditLogRepo)
+	authService := services.NewAuthService(
+		userRepo,
+		trialRepo,
+		sessionRepo,
+		accessTokenRepo,
+		teacherStudentRepo,
+		roleChangeRepo,
+		auditService,
+		telegramBot,
+		cfg.JWTSecret,
+		cfg.JWTExpiration,
+		cfg.RefreshTokenTTL,
+		cfg.TrialDuplicateWindow,
+		cfg.TeacherTelegramID,
+		cfg.TeacherTelegramIDs,
+		cfg.AdminTelegramIDs,
+		cfg.TeacherPassword,
+		services.TelegramAuthConfig{
+			BotToken: cfg.TelegramBotToken,
+			MaxAge:   cfg.TelegramAuthMaxAge,
+			DevMode:  cfg.DevMode,
+		},
+	)
+	if cfg.DevMode {
+		log.Printf("DEV_MODE is enabled: Telegram auth signatures are NOT verified")
+	}
+	if err := authService.BootstrapAdmins(); err != nil {
+		log.Printf("Failed to bootstrap admins: %v", err)
+	}
+	// Realtime: события чата и уведомлений для подключенных клиентов
+	var realtimeBroker realtime.Broker = realtime.NewMemoryBroker()
+	if cfg.RealtimeBroker == "postgres" {
+		dsn := os.Getenv("DATABASE_URL")
+		if dsn == "" {
+			return nil, errors.New("REALTIME_BROKER=postgres requires DATABASE_URL")
+		}
+		realtimeBroker = realtime.NewPostgresBroker(db.DB, dsn)
+	}
+	realtimeHub, err := realtime.NewHub(realtimeBroker)
+	if err != nil {
+		realtimeBroker.Close()
+		return nil, fmt.Errorf("failed to start realtime hub: %w", err)
+	}
+
+	emailService := services.NewEmailService(emailSender, userRepo, cfg.BaseURL, cfg.EmailVerificationTTL)
+	notificationService := services.NewNotificationService(notificationRepo, notificationPreferenceRepo, assignmentTargetRepo, assignmentRepo, userRepo, parentRepo, teacherStudentRepo, telegramBot, emailService, services.RetryPolicy{
+		MaxAttempts: cfg.NotificationMaxAttempts,
+		BaseDelay:   cfg.NotificationRetryBaseDelay,
+		MaxDelay:    cfg.NotificationRetryMaxDelay,
+	}, cfg.DeadlineReminderOffsets, realtimeHub, telegramLinkRepo)
+	mediaService := services.NewMediaService(mediaRepo, userRepo, telegramBot, assignmentRepo, teacherStudentRepo, auditService)
+	assignmentService := services.NewAssignmentService(assignmentRepo, assignmentTargetRepo, groupRepo, userRepo, teacherStudentRepo, notificationService)
+	assignmentServiceOld := services.NewLegacyAssignmentService(assignmentRepo, userRepo, mediaService, telegramBot, accessPolicy, auditService)
+	submissionService := services.NewSubmissionService(submissionRepo, assignmentTargetRepo, draftRepo, userRepo, notificationService)
+	gradingService := services.NewGradingService(feedbackRepo, assignmentTargetRepo, submissionRepo, userRepo, groupRepo, notificationService, accessPolicy, auditService)
+	chatService := services.NewChatService(chatRepo, userRepo, groupRepo, teacherStudentRepo, mediaRepo, notificationService, accessPolicy, realtimeHub)
+	groupService := services.NewGroupService(groupRepo, userRepo, assignmentService, accessPolicy)
+	parentService := services.NewParentService(parentRepo, teacherStudentRepo, assignmentTargetRepo, userRepo, roleChangeRepo)
+	inviteService := services.NewInviteService(inviteRepo, userRepo, teacherStudentRepo, groupRepo, roleChangeRepo, telegramBot)
+	privacyService := services.NewPrivacyService(userDataRepo, userRepo, teacherStudentRepo, fileStorage, telegramBot, auditService)
+	// Используем базовый путь загрузок из конфигурации и подпапку homepage
+	homepageUploadPath := fmt.Sprintf("%s/%s", cfg.UploadPath, "homepage")
+	homepageMediaService := services.NewHomepageMediaService(homepageMediaRepo, cfg.BaseURL, homepageUploadPath)
+
+	// Создаем обработчики
+	// Ограничение частоты публичных запросов (счетчики в памяти процесса)
+	rateLimitStore := ratelimit.NewMemoryStore()
+	authIPLimiter := ratelimit.NewLimiter(rateLimitStore, "auth_ip", cfg.RateLimitAuthIP)
+	authTelegramLimiter := ratelimit.NewLimiter(rateLimitStore, "auth_tg", cfg.RateLimitAuthTelegramID)
+	authFailureLimiter := ratelimit.NewLimiter(rateLimitStore, "auth_fail_ip", cfg.RateLimitAuthFailuresIP)
+	trialIPLimiter := ratelimit.NewLimiter(rateLimitStore, "trial_ip", cfg.RateLimitTrialIP)
+
+	authHandler := handlers.NewAuthHandler(authService, authTelegramLimiter, authFailureLimiter)
+	sessionHandler := handlers.NewSessionHandler(authService)
+	accessTokenHandler := handlers.NewAccessTokenHandler(authService)
+	assignmentHandler := handlers.NewAssignmentHandler(assignmentServiceOld)
+	studentHandler := handlers.NewStudentHandler(assignmentService, submissionService, gradingService, chatService, notificationService)
+	chatHandler := handlers.NewChatHandler(chatService)
+	realtimeHandler := handlers.NewRealtimeHandler(realtimeHub, authService)
+	teacherInboxHandler := handlers.NewTeacherInboxHandler(gradingService, assignmentService, submissionService, chatService, notificationService, accessPolicy)
+	assistantHandler := handlers.NewAssistantHandler(groupService, gradingService)
+	groupHandler := handlers.NewGroupHandler(groupService)
+	parentHandler := handlers.NewParentHandler(parentService)
+	adminHandler := handlers.NewAdminHandler(authService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	privacyHandler := handlers.NewPrivacyHandler(privacyService)
+	inviteHandler := handlers.NewInviteHandler(inviteService)
+	mediaHandler := handlers.NewMediaHandler(mediaService)
+	homepageMediaHandler := handlers.NewHomepageMediaHandler(homepageMediaService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	emailHandler := handlers.NewEmailHandler(emailService)
+
+	return &app{
+		cfg:            cfg,
+		db:             db,
+		telegramBot:    telegramBot,
+		realtimeBroker: realtimeBroker,
+
+		userRepo:            userRepo,
+		telegramLinkRepo:    telegramLinkRepo,
+		authService:         authService,
+		notificationService: notificationService,
+		assignmentService:   assignmentService,
+		chatService:         chatService,
+		mediaService:        mediaService,
+		groupService:        groupService,
+		parentService:       parentService,
+		inviteService:       inviteService,
+
+		authIPLimiter:        authIPLimiter,
+		trialIPLimiter:       trialIPLimiter,
+		authHandler:          authHandler,
+		sessionHandler:       sessionHandler,
+		accessTokenHandler:   accessTokenHandler,
+		assignmentHandler:    assignmentHandler,
+		studentHandler:       studentHandler,
+		chatHandler:          chatHandler,
+		realtimeHandler:      realtimeHandler,
+		teacherInboxHandler:  teacherInboxHandler,
+		assistantHandler:     assistantHandler,
+		groupHandler:         groupHandler,
+		parentHandler:        parentHandler,
+		adminHandler:         adminHandler,
+		auditHandler:         auditHandler,
+		privacyHandler:       privacyHandler,
+		inviteHandler:        inviteHandler,
+		mediaHandler:         mediaHandler,
+		homepageMediaHandler: homepageMediaHandler,
+		notificationHandler:  notificationHandler,
+		emailHandler:         emailHandler,
+	}, nil
+}
+
+// close освобождает ресурсы, созданные newApp
+func (a *app) close() {
+	a.realtimeBroker.Close()
+}
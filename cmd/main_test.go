@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"edubot/internal/config"
+	"edubot/internal/models"
+	"edubot/internal/repository"
+	"edubot/internal/services"
+	"edubot/pkg/database"
+	"edubot/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const testTeacherTelegramID = 111
+
+// newTestApp собирает приложение на временной SQLite без бота и SMTP; env переопределяет настройки
+func newTestApp(t *testing.T, env map[string]string) *app {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	t.Setenv("DATABASE_URL", "")
+	t.Setenv("DEV_MODE", "true")
+	t.Setenv("TELEGRAM_BOT_TOKEN", "")
+	t.Setenv("TEACHER_TELEGRAM_ID", "111")
+	t.Setenv("TEACHER_TELEGRAM_IDS", "111")
+	t.Setenv("ADMIN_TELEGRAM_IDS", "")
+	t.Setenv("DB_PATH", filepath.Join(dir, "edubot.db"))
+	t.Setenv("UPLOAD_PATH", filepath.Join(dir, "uploads"))
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	db, err := database.NewDatabase(cfg.DBPath)
+	if err != nil {
+		t.Fatalf("database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	fileStorage, err := storage.NewStorage(cfg.UploadPath, cfg.MaxFileSize, cfg.MaxUserStorage)
+	if err != nil {
+		t.Fatalf("storage: %v", err)
+	}
+	a, err := newApp(cfg, db, fileStorage, nil, nil)
+	if err != nil {
+		t.Fatalf("newApp: %v", err)
+	}
+	t.Cleanup(a.close)
+	return a
+}
+
+// tokenFor входит через Telegram (в DEV_MODE без подписи) и выставляет пользователю роль
+func tokenFor(t *testing.T, a *app, telegramID int64, role models.UserRole) string {
+	t.Helper()
+	result, err := a.authService.AuthenticateWithTelegram(&services.TelegramAuthData{ID: telegramID, FirstName: string(role)}, services.ClientInfo{})
+	if err != nil {
+		t.Fatalf("authenticate %d: %v", telegramID, err)
+	}
+	if result.User.Role != role {
+		result.User.Role = role
+		if err := a.userRepo.Update(result.User); err != nil {
+			t.Fatalf("set role %s: %v", role, err)
+		}
+	}
+	return result.AccessToken
+}
+
+func serve(router *gin.Engine, method, path, token string) int {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestTeacherRoutesRequireTeacher(t *testing.T) {
+	a := newTestApp(t, nil)
+	router := a.router("../web")
+
+	tokens := map[string]string{
+		"guest":   tokenFor(t, a, 1001, models.RoleGuest),
+		"student": tokenFor(t, a, 1002, models.RoleStudent),
+		"parent":  tokenFor(t, a, 1003, models.RoleParent),
+	}
+
+	checked := 0
+	for _, route := range router.Routes() {
+		if !strings.HasPrefix(route.Path, "/api/teacher/") {
+			continue
+		}
+		checked++
+		// Параметры пути подставляем как есть: до обработчика запрос доходить не должен
+		path := strings.NewReplacer(":", "", "*", "").Replace(route.Path)
+		if code := serve(router, route.Method, path, ""); code != http.StatusUnauthorized {
+			t.Errorf("%s %s without token: got %d, want 401", route.Method, route.Path, code)
+		}
+		for role, token := range tokens {
+			if code := serve(router, route.Method, path, token); code != http.StatusForbidden {
+				t.Errorf("%s %s as %s: got %d, want 403", route.Method, route.Path, role, code)
+			}
+		}
+	}
+	if checked == 0 {
+		t.Fatal("no /api/teacher/ routes registered")
+	}
+
+	teacherToken := tokenFor(t, a, testTeacherTelegramID, models.RoleTeacher)
+	if code := serve(router, http.MethodGet, "/api/teacher/students", teacherToken); code != http.StatusOK {
+		t.Errorf("GET /api/teacher/students as teacher: got %d, want 200", code)
+	}
+}
+
+// signedInitData подписывает init_data Mini App так же, как Telegram
+func signedInitData(botToken string, telegramID int64) string {
+	values := url.Values{}
+	values.Set("user", fmt.Sprintf(`{"id":%d,"first_name":"Anna"}`, telegramID))
+	values.Set("auth_date", strconv.FormatInt(time.Now().Unix(), 10))
+	dataCheck := "auth_date=" + values.Get("auth_date") + "\nuser=" + values.Get("user")
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(botToken))
+	mac := hmac.New(sha256.New, secret.Sum(nil))
+	mac.Write([]byte(dataCheck))
+	values.Set("hash", hex.EncodeToString(mac.Sum(nil)))
+	return values.Encode()
+}
+
+func postTelegramAuth(router *gin.Engine, remoteAddr string, body any) int {
+	return postTelegramAuthVia(router, remoteAddr, "", body)
+}
+
+// postTelegramAuthVia отправляет вход с заголовком X-Forwarded-For, если он задан
+func postTelegramAuthVia(router *gin.Engine, remoteAddr, forwardedFor string, body any) int {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/public/auth/telegram", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestTelegramAuthRateLimits(t *testing.T) {
+	const botToken = "123456789:AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsaw"
+	a := newTestApp(t, map[string]string{
+		"DEV_MODE":                    "false",
+		"TELEGRAM_BOT_TOKEN":          botToken,
+		"RATE_LIMIT_AUTH_IP":          "0",
+		"RATE_LIMIT_AUTH_TELEGRAM_ID": "2/1m",
+		"RATE_LIMIT_AUTH_FAILURES_IP": "3/1m",
+	})
+	router := a.router("../web")
+	const attacker, victim = "192.0.2.1:1000", "198.51.100.7:2000"
+
+	// Поддельные запросы от имени 42 считаются неудачами по IP, а не попытками входа 42.
+	// Без доверенных прокси X-Forwarded-For не меняет IP, по которому считается лимит
+	forged := map[string]any{"id": 42, "first_name": "Anna", "auth_date": time.Now().Unix(), "hash": "00"}
+	for i := 0; i < 3; i++ {
+		if code := postTelegramAuthVia(router, attacker, fmt.Sprintf("203.0.113.%d", i+1), forged); code != http.StatusUnauthorized {
+			t.Fatalf("forged attempt %d: got %d, want 401", i+1, code)
+		}
+	}
+	if code := postTelegramAuthVia(router, attacker, "203.0.113.99", forged); code != http.StatusTooManyRequests {
+		t.Errorf("attempt after failures with forged X-Forwarded-For: got %d, want 429", code)
+	}
+	// С исчерпанным лимитом неудач IP не может войти даже с верной подписью
+	if code := postTelegramAuth(router, attacker, map[string]any{"init_data": signedInitData(botToken, 7)}); code != http.StatusTooManyRequests {
+		t.Errorf("valid login from blocked IP: got %d, want 429", code)
+	}
+
+	// Mini App присылает id только внутри init_data: лимит считается по проверенному ID
+	for i := 0; i < 2; i++ {
+		if code := postTelegramAuth(router, victim, map[string]any{"init_data": signedInitData(botToken, 42)}); code != http.StatusOK {
+			t.Fatalf("login %d of 42: got %d, want 200", i+1, code)
+		}
+	}
+	if code := postTelegramAuth(router, victim, map[string]any{"init_data": signedInitData(botToken, 42)}); code != http.StatusTooManyRequests {
+		t.Errorf("third login of 42: got %d, want 429", code)
+	}
+	if code := postTelegramAuth(router, victim, map[string]any{"init_data": signedInitData(botToken, 43)}); code != http.StatusOK {
+		t.Errorf("login of 43: got %d, want 200", code)
+	}
+}
+
+func TestRevokeStudentSessionsRequiresOwnStudent(t *testing.T) {
+	a := newTestApp(t, nil)
+	router := a.router("../web")
+	links := repository.NewTeacherStudentRepository(a.db.DB)
+
+	teacherToken := tokenFor(t, a, testTeacherTelegramID, models.RoleTeacher)
+	otherTeacherToken := tokenFor(t, a, 112, models.RoleTeacher)
+	ownToken := tokenFor(t, a, 1002, models.RoleStudent)
+	tokenFor(t, a, 1003, models.RoleStudent)
+	userIDs := map[int64]uuid.UUID{}
+	for _, telegramID := range []int64{testTeacherTelegramID, 112, 1002, 1003} {
+		user, err := a.userRepo.GetByTelegramID(telegramID)
+		if err != nil {
+			t.Fatalf("get user %d: %v", telegramID, err)
+		}
+		userIDs[telegramID] = user.ID
+	}
+	if err := links.Link(userIDs[testTeacherTelegramID], userIDs[1002], ""); err != nil {
+		t.Fatalf("link own student: %v", err)
+	}
+	if err := links.Link(userIDs[112], userIDs[1003], ""); err != nil {
+		t.Fatalf("link other student: %v", err)
+	}
+
+	// Чужой ученик и другой преподаватель для преподавателя не существуют
+	for _, telegramID := range []int64{1003, 112} {
+		path := "/api/teacher/students/" + userIDs[telegramID].String() + "/sessions"
+		if code := serve(router, http.MethodDelete, path, teacherToken); code != http.StatusNotFound {
+			t.Errorf("revoke sessions of %d: got %d, want 404", telegramID, code)
+		}
+	}
+	if code := serve(router, http.MethodGet, "/api/sessions", otherTeacherToken); code != http.StatusOK {
+		t.Errorf("other teacher logged out: got %d, want 200", code)
+	}
+
+	path := "/api/teacher/students/" + userIDs[1002].String() + "/sessions"
+	if code := serve(router, http.MethodDelete, path, teacherToken); code != http.StatusOK {
+		t.Errorf("revoke sessions of own student: got %d, want 200", code)
+	}
+	if code := serve(router, http.MethodGet, "/api/sessions", ownToken); code != http.StatusUnauthorized {
+		t.Errorf("student token after revoke: got %d, want 401", code)
+	}
+}
+
+func TestTrustedProxiesForwardClientIP(t *testing.T) {
+	a := newTestApp(t, map[string]string{
+		"DEV_MODE":                    "false",
+		"TELEGRAM_BOT_TOKEN":          "123456789:AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsaw",
+		"RATE_LIMIT_AUTH_IP":          "0",
+		"RATE_LIMIT_AUTH_FAILURES_IP": "1/1m",
+		"TRUSTED_PROXIES":             "10.0.0.0/8",
+	})
+	router := a.router("../web")
+	const proxy = "10.0.0.1:3000"
+
+	// За доверенным прокси лимит считается по X-Forwarded-For: клиенты не мешают друг другу
+	forged := map[string]any{"id": 42, "first_name": "Anna", "auth_date": time.Now().Unix(), "hash": "00"}
+	for _, client := range []string{"192.0.2.1", "192.0.2.2"} {
+		if code := postTelegramAuthVia(router, proxy, client, forged); code != http.StatusUnauthorized {
+			t.Errorf("first failure of %s: got %d, want 401", client, code)
+		}
+	}
+	if code := postTelegramAuthVia(router, proxy, "192.0.2.1", forged); code != http.StatusTooManyRequests {
+		t.Errorf("second failure of 192.0.2.1: got %d, want 429", code)
+	}
+
+	// Недоверенный отправитель не может выдать себя за другого клиента
+	if code := postTelegramAuthVia(router, "198.51.100.7:2000", "192.0.2.3", forged); code != http.StatusUnauthorized {
+		t.Errorf("first failure from untrusted sender: got %d, want 401", code)
+	}
+	if code := postTelegramAuthVia(router, "198.51.100.7:2000", "192.0.2.4", forged); code != http.StatusTooManyRequests {
+		t.Errorf("untrusted sender with new X-Forwarded-For: got %d, want 429", code)
+	}
+}
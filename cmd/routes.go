@@ -0,0 +1,431 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"edubot/internal/handlers"
+	"edubot/internal/models"
+
+	"github.com/gin-gonic/gin"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// router регистрирует все маршруты сервера; webDir — каталог со статикой и шаблонами
+func (a *app) router(webDir string) *gin.Engine {
+	// Настраиваем Gin
+	if gin.Mode() == gin.ReleaseMode {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	router := gin.Default()
+	// Без доверенных прокси IP клиента берется из соединения, а X-Forwarded-For игнорируется:
+	// иначе лимиты по IP обходятся подменой заголовка
+	if err := router.SetTrustedProxies(a.cfg.TrustedProxies); err != nil {
+		log.Printf("Invalid trusted proxies, ignoring forwarded headers: %v", err)
+		router.SetTrustedProxies(nil)
+	}
+
+	// Middleware
+	router.Use(handlers.CORSMiddleware())
+
+	// Статика и шаблоны нужны для Mini App даже при отключенном сайте
+	router.Static("/static", filepath.Join(webDir, "static"))
+	router.LoadHTMLGlob(filepath.Join(webDir, "templates", "*"))
+
+	// Публичные маршруты для медиафайлов главной страницы
+	router.GET("/media/homepage/:filename", a.homepageMediaHandler.ServeMedia)
+	router.GET("/api/public/homepage-media/:type", a.homepageMediaHandler.GetActiveMedia)
+
+	// Специальный endpoint для Telegram WebApp
+	router.GET("/telegram-check", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"status":       "telegram_ready",
+			"webapp_url":   "https://edubot-0g05.onrender.com/app",
+			"bot_username": "EduBot_by_Pugachev_bot",
+		})
+	})
+
+	// Тестовый маршрут для проверки работы сервера
+	router.GET("/health", func(c *gin.Context) {
+		health := gin.H{
+			"status":   "ok",
+			"message":  "EduBot server is running",
+			"base_url": a.cfg.BaseURL,
+			"port":     a.cfg.Port,
+		}
+		if a.telegramBot != nil {
+			health["telegram_queue"] = a.telegramBot.QueueStats()
+		}
+		health["webhook_rejected"] = handlers.WebhookRejectedCount()
+		c.JSON(http.StatusOK, health)
+	})
+
+	// Выключаем сайт по флагу DISABLE_SITE, но пускаем Mini App из Telegram
+	disableSite := os.Getenv("DISABLE_SITE") == "true"
+	router.GET("/", func(c *gin.Context) {
+		if disableSite && !isTelegramWebApp(c.Request) {
+			c.HTML(http.StatusOK, "site-disabled.html", nil)
+			return
+		}
+		c.HTML(http.StatusOK, "index.html", gin.H{"title": "EduBot - Образовательная платформа"})
+	})
+
+	// Специальный путь для Mini App, всегда отдаёт index.html (настрой в боте open_web_app на /app)
+	router.GET("/app", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "index.html", gin.H{"title": "EduBot - Mini App"})
+	})
+
+	// Панели управления доступны всегда (для Mini App)
+	router.GET("/teacher-dashboard", handlers.AuthMiddleware(a.authService), handlers.RequireHTMLRoles(models.RoleTeacher), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "teacher-dashboard.html", gin.H{"title": "Панель управления - EduBot"})
+	})
+	router.GET("/student-dashboard", handlers.AuthMiddleware(a.authService), handlers.RequireHTMLRoles(models.RoleStudent), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "student-dashboard.html", gin.H{"title": "Мои задания - EduBot"})
+	})
+	router.GET("/student-progress", handlers.AuthMiddleware(a.authService), handlers.RequireHTMLRoles(models.RoleStudent), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "student-progress.html", gin.H{"title": "Мой прогресс - EduBot"})
+	})
+	router.GET("/student-chat", handlers.AuthMiddleware(a.authService), handlers.RequireHTMLRoles(models.RoleStudent), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "student-chat.html", gin.H{"title": "Чат с преподавателем - EduBot"})
+	})
+
+	// Дополнительные страницы преподавателя доступны всегда (для Mini App)
+	router.GET("/teacher/assignments/create", handlers.AuthMiddleware(a.authService), handlers.RequireHTMLRoles(models.RoleTeacher), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "teacher-assignments.html", gin.H{"title": "Создание задания - EduBot"})
+	})
+	router.GET("/teacher-submissions", handlers.AuthMiddleware(a.authService), handlers.RequireHTMLRoles(models.RoleTeacher), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "teacher-submissions.html", gin.H{"title": "Проверка заданий - EduBot"})
+	})
+	router.GET("/teacher/content/create", handlers.AuthMiddleware(a.authService), handlers.RequireHTMLRoles(models.RoleTeacher), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "teacher-content.html", gin.H{"title": "Добавление материалов - EduBot"})
+	})
+	router.GET("/teacher/students", handlers.AuthMiddleware(a.authService), handlers.RequireHTMLRoles(models.RoleTeacher), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "teacher-students.html", gin.H{"title": "Ученики - EduBot"})
+	})
+	router.GET("/teacher/students/:id/progress", handlers.AuthMiddleware(a.authService), handlers.RequireHTMLRoles(models.RoleTeacher), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "teacher-student-progress.html", gin.H{"title": "Прогресс ученика - EduBot"})
+	})
+	router.GET("/teacher-groups", handlers.AuthMiddleware(a.authService), handlers.RequireHTMLRoles(models.RoleTeacher), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "teacher-groups.html", gin.H{"title": "Группы - EduBot"})
+	})
+	router.GET("/homepage-media", handlers.AuthMiddleware(a.authService), handlers.RequireHTMLRoles(models.RoleTeacher), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "homepage-media.html", gin.H{"title": "Управление медиафайлами - EduBot"})
+	})
+	router.GET("/teacher-trial-requests", handlers.AuthMiddleware(a.authService), handlers.RequireHTMLRoles(models.RoleTeacher), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "teacher-trial-requests.html", gin.H{"title": "Заявки на пробные уроки - EduBot"})
+	})
+
+	// HTML-страницы доступны только когда сайт включен
+	if !disableSite {
+		// Здесь могут быть дополнительные страницы, доступные только при включенном сайте
+	}
+
+	// helper: определяем запросы из Telegram Mini App по User-Agent/параметрам
+	// (упрощенно: User-Agent содержит "Telegram" или есть tgWebAppData в query)
+
+	// API маршруты
+	api := router.Group("/api")
+
+	// Публичные маршруты (доступны гостям)
+	// Публичные маршруты: временно отключены авторизация и регистрация
+	public := api.Group("/public")
+	{
+		// Публичные медиафайлы (приветственные ролики и т.д.)
+		public.GET("/media", a.mediaHandler.GetPublicMedia)
+		// Авторизация через Telegram WebApp/Desktop
+		public.POST("/auth/telegram", handlers.RateLimitByIP(a.authIPLimiter), a.authHandler.TelegramAuth)
+		// Обновление access-токена по refresh-токену
+		public.POST("/auth/refresh", a.sessionHandler.Refresh)
+		// Заявка на пробное занятие
+		public.POST("/trial-request", handlers.RateLimitByIP(a.trialIPLimiter), a.authHandler.SubmitTrialRequest)
+		// Подтверждение email по ссылке из письма
+		public.GET("/email/verify", a.emailHandler.Verify)
+	}
+
+	// Совместимость: /api/media/public (чтобы не перехватывалось /media/:id)
+	api.GET("/media/public", a.mediaHandler.GetPublicMedia)
+	_ = public
+
+	// Защищенные маршруты (требуют авторизации)
+	protected := api.Group("/")
+	protected.Use(handlers.AuthMiddleware(a.authService))
+	{
+		// Профиль пользователя
+		protected.GET("/profile", a.authHandler.GetProfile)
+		protected.POST("/profile/email", a.emailHandler.RequestVerification)
+
+		// Сессии входа
+		protected.POST("/auth/logout", a.sessionHandler.Logout)
+		protected.GET("/sessions", a.sessionHandler.ListSessions)
+		protected.DELETE("/sessions", a.sessionHandler.RevokeOtherSessions)
+		protected.DELETE("/sessions/:id", a.sessionHandler.RevokeSession)
+
+		// Настройки уведомлений
+		protected.GET("/notifications/preferences", a.notificationHandler.GetPreferences)
+		protected.PUT("/notifications/preferences", a.notificationHandler.UpdatePreferences)
+		protected.POST("/invites/redeem", a.inviteHandler.Redeem)
+
+		// Задания для учеников (student only)
+		protected.GET("/assignments", handlers.StudentOnlyMiddleware(), a.assignmentHandler.GetStudentAssignments)
+		protected.GET("/assignments/:id", handlers.StudentOnlyMiddleware(), a.assignmentHandler.GetAssignment)
+		protected.POST("/assignments/:id/complete", handlers.StudentOnlyMiddleware(), a.assignmentHandler.MarkAssignmentCompleted)
+		protected.GET("/assignments/upcoming", handlers.StudentOnlyMiddleware(), a.assignmentHandler.GetUpcomingDeadlines)
+
+		// Комментарии к заданиям
+		protected.GET("/assignments/:id/comments", a.assignmentHandler.GetComments)
+		protected.POST("/assignments/:id/comments", a.assignmentHandler.AddComment)
+
+		// Медиафайлы заданий
+		protected.POST("/assignments/:id/media", a.assignmentHandler.AddAssignmentMedia)
+		protected.GET("/assignments/:id/media", a.assignmentHandler.GetAssignmentMedia)
+
+		// Сдача заданий
+		protected.POST("/assignments/:id/submit", handlers.StudentOnlyMiddleware(), a.assignmentHandler.SubmitAssignment)
+
+		// Медиафайлы submissions
+		protected.GET("/submissions/:id/media", handlers.RequireRoles(models.RoleStudent, models.RoleTeacher), a.assignmentHandler.GetSubmissionMedia)
+
+		// Фидбэк учителя
+		protected.POST("/submissions/:id/feedback", handlers.TeacherOnlyMiddleware(), a.assignmentHandler.AddFeedbackMedia)
+		protected.GET("/submissions/:id/feedback", handlers.RequireRoles(models.RoleStudent, models.RoleTeacher), a.assignmentHandler.GetFeedbackMedia)
+
+		// Submissions для учителя
+		protected.GET("/teacher/submissions", handlers.TeacherOnlyMiddleware(), a.assignmentHandler.GetTeacherSubmissions)
+		protected.POST("/teacher/submissions/:id/feedback", handlers.TeacherOnlyMiddleware(), a.assignmentHandler.SubmitTeacherFeedback)
+
+		// Контент
+		protected.GET("/content/:id", a.assignmentHandler.GetContent)
+		protected.GET("/content/subject/:subject/grade/:grade", a.assignmentHandler.GetContentBySubject)
+
+		// Прогресс ученика
+		protected.GET("/progress", a.assignmentHandler.GetStudentProgress)
+
+		// Медиафайлы
+		protected.POST("/media", a.mediaHandler.CreateMedia)
+		protected.POST("/media/upload", a.mediaHandler.UploadMedia)
+		protected.GET("/media/:id", a.mediaHandler.GetMedia)
+		protected.GET("/media/:id/stream", a.mediaHandler.StreamMedia)
+		protected.GET("/media/:id/thumbnail", a.mediaHandler.GetThumbnail)
+		protected.GET("/media", a.mediaHandler.GetUserMedia)
+		protected.GET("/media/entity/:entity_type/:entity_id", a.mediaHandler.GetEntityMedia)
+		protected.PUT("/media/:id", a.mediaHandler.UpdateMedia)
+		protected.DELETE("/media/:id", a.mediaHandler.DeleteMedia)
+		protected.GET("/media/:id/views", a.mediaHandler.GetMediaViews)
+		protected.POST("/media/:id/access", a.mediaHandler.GrantAccess)
+		protected.DELETE("/media/:id/access/:user_id", a.mediaHandler.RevokeAccess)
+	}
+
+	// Student API routes
+	student := api.Group("/student")
+	student.Use(handlers.AuthMiddleware(a.authService))
+	student.Use(handlers.RequireRoles(models.RoleStudent))
+	{
+		// Assignments
+		student.GET("/assignments", a.studentHandler.GetAssignments)
+		student.GET("/assignments/:id", a.studentHandler.GetAssignment)
+		student.POST("/assignments/:id/submit", a.studentHandler.SubmitAssignment)
+		student.POST("/assignments/:id/draft", a.studentHandler.SaveDraft)
+		student.GET("/assignments/:id/draft", a.studentHandler.GetDraft)
+
+		// Progress
+		student.GET("/progress", a.studentHandler.GetProgress)
+
+		// Преподаватели ученика
+		student.GET("/teachers", a.authHandler.GetTeachers)
+
+		// Notifications
+		student.GET("/notifications", a.studentHandler.GetNotifications)
+		student.POST("/notifications/:id/read", a.studentHandler.MarkNotificationAsRead)
+	}
+
+	// Chat API routes
+	chat := api.Group("/chat")
+	chat.Use(handlers.AuthMiddleware(a.authService))
+	{
+		// Threads
+		chat.GET("/threads", a.chatHandler.GetThreads)
+		chat.GET("/threads/:id", a.chatHandler.GetThread)
+		chat.POST("/threads/student-teacher", a.chatHandler.GetOrCreateStudentTeacherThread)
+		chat.POST("/threads/group", a.chatHandler.GetOrCreateGroupThread)
+
+		// Messages
+		chat.GET("/threads/:id/messages", a.chatHandler.GetMessages)
+		chat.POST("/threads/:id/messages", a.chatHandler.SendMessage)
+		chat.PUT("/messages/:id", a.chatHandler.UpdateMessage)
+		chat.DELETE("/messages/:id", a.chatHandler.DeleteMessage)
+		chat.POST("/threads/:id/read", a.chatHandler.MarkAsRead)
+		chat.POST("/threads/:id/typing", a.chatHandler.Typing)
+		chat.GET("/threads/:id/pinned", a.chatHandler.GetPinned)
+		chat.POST("/messages/:id/reactions", a.chatHandler.AddReaction)
+		chat.DELETE("/messages/:id/reactions", a.chatHandler.RemoveReaction)
+		chat.POST("/messages/:id/pin", a.chatHandler.PinMessage)
+		chat.DELETE("/messages/:id/pin", a.chatHandler.UnpinMessage)
+	}
+
+	// Поток событий чата и уведомлений (SSE; авторизация по заголовку или cookie jwt)
+	api.GET("/realtime/events", handlers.AuthMiddleware(a.authService), a.realtimeHandler.Events)
+
+	// Маршруты ассистентов групп: права проверяются политикой доступа по роли в группе
+	assistant := api.Group("/assistant")
+	assistant.Use(handlers.AuthMiddleware(a.authService))
+	{
+		assistant.GET("/groups", a.assistantHandler.GetGroups)
+		assistant.GET("/inbox", a.assistantHandler.GetInbox)
+		assistant.GET("/inbox/:id", a.teacherInboxHandler.GetAssignmentForGrading)
+		assistant.POST("/inbox/:id/grade", a.teacherInboxHandler.GradeAssignment)
+	}
+
+	// Маршруты родителей: привязка по коду доступна гостю, остальное — только родителю
+	parent := api.Group("/parent")
+	parent.Use(handlers.AuthMiddleware(a.authService))
+	{
+		parent.POST("/link", handlers.RequireRoles(models.RoleGuest, models.RoleParent), a.parentHandler.AcceptInvite)
+		parent.GET("/children", handlers.RequireRoles(models.RoleParent), a.parentHandler.GetChildren)
+		parent.GET("/children/:id/assignments", handlers.RequireRoles(models.RoleParent), a.parentHandler.GetChildAssignments)
+		parent.GET("/children/:id/progress", handlers.RequireRoles(models.RoleParent), a.parentHandler.GetChildProgress)
+	}
+
+	// Маршруты администратора: управление преподавателями и журнал изменений
+	admin := api.Group("/admin")
+	admin.Use(handlers.AuthMiddleware(a.authService))
+	admin.Use(handlers.RequireRoles(models.RoleAdmin))
+	{
+		admin.GET("/teachers", a.adminHandler.ListTeachers)
+		admin.POST("/teachers/invite", a.adminHandler.InviteTeacher)
+		admin.POST("/teachers/:id/promote", a.adminHandler.PromoteTeacher)
+		admin.POST("/teachers/:id/deactivate", a.adminHandler.DeactivateTeacher)
+		admin.GET("/role-changes", a.adminHandler.ListRoleChanges)
+		admin.GET("/audit-log", a.auditHandler.List)
+
+		// Выгрузка и удаление данных пользователя
+		admin.GET("/users/:id/export", a.privacyHandler.Export)
+		admin.POST("/users/:id/erase", a.privacyHandler.Erase)
+	}
+
+	// Маршруты только для преподавателей (защищенные)
+	teacher := api.Group("/teacher")
+	teacher.Use(handlers.AuthMiddleware(a.authService))
+	teacher.Use(handlers.TeacherOnlyMiddleware())
+	{
+		// Коды приглашения учеников (/invite-code оставлен для совместимости)
+		teacher.POST("/invite-codes", a.inviteHandler.CreateInvite)
+		teacher.GET("/invite-codes", a.inviteHandler.ListInvites)
+		teacher.DELETE("/invite-codes/:id", a.inviteHandler.RevokeInvite)
+		teacher.POST("/invite-code", a.inviteHandler.CreateInvite)
+
+		// Персональные токены для скриптов
+		teacher.POST("/tokens", a.accessTokenHandler.Create)
+		teacher.GET("/tokens", a.accessTokenHandler.List)
+		teacher.DELETE("/tokens/:id", a.accessTokenHandler.Revoke)
+
+		// Панель управления
+		teacher.GET("/stats", a.authHandler.GetStats)
+		teacher.GET("/audit-log", a.auditHandler.List)
+
+		// Управление учениками
+		teacher.GET("/students", a.authHandler.GetStudents)
+		teacher.POST("/students", a.inviteHandler.CreateStudent)
+		teacher.POST("/students/assign", a.inviteHandler.InviteStudent)
+		teacher.DELETE("/students/:id", a.authHandler.UnlinkStudent)
+		teacher.DELETE("/students/:id/sessions", a.sessionHandler.RevokeStudentSessions)
+		teacher.GET("/students/:id/export", a.privacyHandler.Export)
+		teacher.POST("/students/:id/parent-invite", a.parentHandler.CreateInvite)
+
+		// Управление заявками на пробные уроки
+		teacher.GET("/trial-requests", a.authHandler.GetTrialRequests)
+		teacher.POST("/trial-requests/:id/approve", a.authHandler.ApproveTrialRequest)
+		teacher.POST("/trial-requests/:id/reject", a.authHandler.RejectTrialRequest)
+		teacher.POST("/trial-requests/:id/hide", a.authHandler.HideTrialRequest)
+
+		// Поиск пользователей
+		teacher.POST("/users/search", a.authHandler.SearchUsers)
+
+		// Группы
+		teacher.POST("/groups", a.groupHandler.CreateGroup)
+		teacher.GET("/groups", a.groupHandler.ListGroups)
+		teacher.POST("/groups/:id/members", a.groupHandler.AddMember)
+		teacher.DELETE("/groups/:id/members/:user_id", a.groupHandler.RemoveMember)
+		teacher.POST("/groups/:id/assignments", a.groupHandler.AssignHomework)
+
+		// Управление заданиями (legacy - используем TeacherInboxHandler)
+		teacher.PUT("/assignments/:id", a.assignmentHandler.UpdateAssignment)
+		teacher.DELETE("/assignments/:id", a.assignmentHandler.DeleteAssignment)
+
+		// Управление контентом
+		teacher.POST("/content", a.assignmentHandler.CreateContent)
+		teacher.GET("/content", a.assignmentHandler.GetTeacherContent)
+		teacher.PUT("/content/:id", a.assignmentHandler.UpdateContent)
+		teacher.DELETE("/content/:id", a.assignmentHandler.DeleteContent)
+
+		// Управление медиафайлами главной страницы
+		teacher.POST("/homepage-media/:type", a.homepageMediaHandler.UploadMedia)
+		teacher.GET("/homepage-media", a.homepageMediaHandler.ListMedia)
+		teacher.GET("/homepage-media/:id", a.homepageMediaHandler.GetMedia)
+		teacher.PUT("/homepage-media/:type/active", a.homepageMediaHandler.SetActiveMedia)
+		teacher.DELETE("/homepage-media/:id", a.homepageMediaHandler.DeleteMedia)
+
+		// Teacher Inbox API
+		teacher.GET("/inbox", a.teacherInboxHandler.GetInbox)
+		teacher.GET("/inbox/:id", a.teacherInboxHandler.GetAssignmentForGrading)
+		teacher.POST("/inbox/:id/grade", a.teacherInboxHandler.GradeAssignment)
+		teacher.GET("/assignments", a.teacherInboxHandler.GetAssignments)
+		teacher.POST("/assignments", a.teacherInboxHandler.CreateAssignment)
+		teacher.GET("/statistics", a.teacherInboxHandler.GetStatistics)
+		teacher.GET("/notifications", a.teacherInboxHandler.GetNotifications)
+		teacher.POST("/notifications/:id/read", a.teacherInboxHandler.MarkNotificationAsRead)
+		teacher.GET("/notifications/failed", a.notificationHandler.ListUndelivered)
+		teacher.POST("/notifications/:id/requeue", a.notificationHandler.Requeue)
+	}
+
+	// Выбор роли после Telegram-авторизации (без пароля)
+	// Отключено: выбор роли/учительская авторизация
+	// api.POST("/auth/select-role", handlers.AuthMiddleware(a.authService), a.authHandler.SelectRole)
+
+	// Webhook для Telegram
+	router.GET("/webhook", func(c *gin.Context) {
+		// Telegram проверяет доступность webhook
+		c.JSON(http.StatusOK, gin.H{"status": "webhook_ready"})
+	})
+
+	// Telegram подписывает запросы secret_token, заданным в SetWebhook
+	webhookSecret := ""
+	if a.telegramBot != nil {
+		webhookSecret = a.telegramBot.WebhookSecret()
+	}
+	router.POST("/webhook", handlers.TelegramWebhookMiddleware(webhookSecret), func(c *gin.Context) {
+		var update tgbotapi.Update
+		if err := c.ShouldBindJSON(&update); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Обрабатываем обновление от Telegram
+		if a.telegramBot != nil {
+			a.telegramBot.ProcessUpdate(update)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	return router
+}
+
+// isTelegramWebApp пытается определить, что запрос пришел из Telegram Mini App
+func isTelegramWebApp(r *http.Request) bool {
+	ua := r.Header.Get("User-Agent")
+	if strings.Contains(strings.ToLower(ua), "telegram") {
+		return true
+	}
+	// Также считаем Mini App, если прилетели параметры WebApp
+	if r.URL != nil {
+		q := r.URL.Query()
+		if q.Get("tgWebAppData") != "" || q.Get("tgWebAppStartParam") != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -1,12 +1,15 @@
 package config
 
 import (
+	"net"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+
+	"edubot/pkg/ratelimit"
 )
 
 // Config содержит все настройки приложения
@@ -22,8 +25,19 @@ type Config struct {
 	// Telegram
 	TelegramBotToken   string
 	TelegramWebhookURL string
+	TelegramMode       string // webhook или polling
 	TeacherTelegramID  int64
 	TeacherTelegramIDs []int64
+	AdminTelegramIDs   []int64 // Администраторы; как и список преподавателей, применяется только при первом входе
+
+	// Секрет для заголовка X-Telegram-Bot-Api-Secret-Token (пустой — выводится из токена)
+	TelegramWebhookSecret string
+
+	// Очередь исходящих сообщений бота
+	TelegramGlobalRate  float64
+	TelegramPerChatRate float64
+	TelegramSendWorkers int
+	TelegramQueueSize   int
 
 	// File Storage
 	UploadPath     string
@@ -33,7 +47,49 @@ type Config struct {
 	// Security
 	JWTSecret       string
 	TeacherPassword string
-	JWTExpiration   time.Duration
+	JWTExpiration   time.Duration // Время жизни access-токена
+	RefreshTokenTTL time.Duration // Время жизни сессии без обновления
+
+	// Проверка подписи Telegram (Mini App initData и Login Widget)
+	TelegramAuthMaxAge time.Duration
+	DevMode            bool // Пропускать проверку подписи; только для локальной разработки
+
+	// Защита публичных эндпоинтов от спама
+	RateLimitAuthIP         ratelimit.Rule // Вход через Telegram с одного IP
+	RateLimitAuthTelegramID ratelimit.Rule // Вход через Telegram с одним Telegram ID
+	RateLimitAuthFailuresIP ratelimit.Rule // Неудачные проверки подписи Telegram с одного IP
+	RateLimitTrialIP        ratelimit.Rule // Заявки на пробный урок с одного IP
+	TrialDuplicateWindow    time.Duration  // Повторная заявка с тем же контактом в этом окне отклоняется
+
+	// Прокси, которым доверяем X-Forwarded-For и X-Real-IP (IP или CIDR); пусто — IP берется из соединения
+	TrustedProxies []string
+
+	// Realtime: "memory" — один экземпляр, "postgres" — LISTEN/NOTIFY через DATABASE_URL
+	RealtimeBroker string
+
+	// Scheduler
+	SchedulerEnabled             bool
+	JobLockTTL                   time.Duration
+	SendNotificationsSchedule    string
+	MarkOverdueSchedule          string
+	OverdueNotificationsSchedule string
+	CleanupNotificationsSchedule string
+	DeadlineRemindersSchedule    string
+	CleanupSessionsSchedule      string
+
+	// Notifications
+	DeadlineReminderOffsets    []time.Duration
+	NotificationMaxAttempts    int
+	NotificationRetryBaseDelay time.Duration
+	NotificationRetryMaxDelay  time.Duration
+
+	// Email (SMTP)
+	SMTPHost             string
+	SMTPPort             int
+	SMTPUsername         string
+	SMTPPassword         string
+	SMTPFrom             string
+	EmailVerificationTTL time.Duration
 }
 
 // Load загружает конфигурацию из переменных окружения
@@ -48,10 +104,27 @@ func Load() (*Config, error) {
 		DBPath:             getEnv("DB_PATH", "./data/edubot.db"),
 		TelegramBotToken:   getEnv("TELEGRAM_BOT_TOKEN", ""),
 		TelegramWebhookURL: getEnv("TELEGRAM_WEBHOOK_URL", ""),
+		TelegramMode:       getEnv("TELEGRAM_MODE", "webhook"),
 		UploadPath:         getEnv("UPLOAD_PATH", "./data/uploads"),
 		JWTSecret:          getEnv("JWT_SECRET", "edubot_secret_key_2024"),
 		TeacherPassword:    getEnv("TEACHER_PASSWORD", ""),
-		JWTExpiration:      24 * time.Hour,
+
+		TelegramWebhookSecret: getEnv("TELEGRAM_WEBHOOK_SECRET", ""),
+
+		DevMode: getEnv("DEV_MODE", "false") == "true",
+
+		SchedulerEnabled:             getEnv("SCHEDULER_ENABLED", "true") == "true",
+		SendNotificationsSchedule:    getEnv("JOB_SEND_NOTIFICATIONS_SCHEDULE", "@every 1m"),
+		MarkOverdueSchedule:          getEnv("JOB_MARK_OVERDUE_SCHEDULE", "*/5 * * * *"),
+		OverdueNotificationsSchedule: getEnv("JOB_OVERDUE_NOTIFICATIONS_SCHEDULE", "*/15 * * * *"),
+		CleanupNotificationsSchedule: getEnv("JOB_CLEANUP_NOTIFICATIONS_SCHEDULE", "0 3 * * *"),
+		DeadlineRemindersSchedule:    getEnv("JOB_DEADLINE_REMINDERS_SCHEDULE", "*/5 * * * *"),
+		CleanupSessionsSchedule:      getEnv("JOB_CLEANUP_SESSIONS_SCHEDULE", "30 3 * * *"),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", "EduBot <noreply@edubot.local>"),
 	}
 
 	// Парсим числовые значения
@@ -67,30 +140,164 @@ func Load() (*Config, error) {
 		config.MaxUserStorage = 500 * 1024 * 1024 // 500MB по умолчанию
 	}
 
+	if lockTTL, err := time.ParseDuration(getEnv("JOB_LOCK_TTL", "10m")); err == nil {
+		config.JobLockTTL = lockTTL
+	} else {
+		config.JobLockTTL = 10 * time.Minute
+	}
+
+	if globalRate, err := strconv.ParseFloat(getEnv("TELEGRAM_GLOBAL_RATE", "30"), 64); err == nil && globalRate > 0 {
+		config.TelegramGlobalRate = globalRate
+	} else {
+		config.TelegramGlobalRate = 30
+	}
+
+	if perChatRate, err := strconv.ParseFloat(getEnv("TELEGRAM_PER_CHAT_RATE", "1"), 64); err == nil && perChatRate > 0 {
+		config.TelegramPerChatRate = perChatRate
+	} else {
+		config.TelegramPerChatRate = 1
+	}
+
+	if workers, err := strconv.Atoi(getEnv("TELEGRAM_SEND_WORKERS", "4")); err == nil && workers > 0 {
+		config.TelegramSendWorkers = workers
+	} else {
+		config.TelegramSendWorkers = 4
+	}
+
+	if queueSize, err := strconv.Atoi(getEnv("TELEGRAM_QUEUE_SIZE", "1000")); err == nil && queueSize > 0 {
+		config.TelegramQueueSize = queueSize
+	} else {
+		config.TelegramQueueSize = 1000
+	}
+
+	if jwtExpiration, err := time.ParseDuration(getEnv("JWT_EXPIRATION", "15m")); err == nil && jwtExpiration > 0 {
+		config.JWTExpiration = jwtExpiration
+	} else {
+		config.JWTExpiration = 15 * time.Minute
+	}
+
+	if refreshTTL, err := time.ParseDuration(getEnv("REFRESH_TOKEN_TTL", "720h")); err == nil && refreshTTL > 0 {
+		config.RefreshTokenTTL = refreshTTL
+	} else {
+		config.RefreshTokenTTL = 30 * 24 * time.Hour
+	}
+
+	if authMaxAge, err := time.ParseDuration(getEnv("TELEGRAM_AUTH_MAX_AGE", "24h")); err == nil {
+		config.TelegramAuthMaxAge = authMaxAge
+	} else {
+		config.TelegramAuthMaxAge = 24 * time.Hour
+	}
+
+	config.RateLimitAuthIP = parseRateLimit(getEnv("RATE_LIMIT_AUTH_IP", "30/1m"), ratelimit.Rule{Limit: 30, Window: time.Minute})
+	config.RateLimitAuthTelegramID = parseRateLimit(getEnv("RATE_LIMIT_AUTH_TELEGRAM_ID", "10/1m"), ratelimit.Rule{Limit: 10, Window: time.Minute})
+	config.RateLimitAuthFailuresIP = parseRateLimit(getEnv("RATE_LIMIT_AUTH_FAILURES_IP", "10/15m"), ratelimit.Rule{Limit: 10, Window: 15 * time.Minute})
+	config.RateLimitTrialIP = parseRateLimit(getEnv("RATE_LIMIT_TRIAL_IP", "5/1h"), ratelimit.Rule{Limit: 5, Window: time.Hour})
+
+	if duplicateWindow, err := time.ParseDuration(getEnv("TRIAL_DUPLICATE_WINDOW", "24h")); err == nil && duplicateWindow >= 0 {
+		config.TrialDuplicateWindow = duplicateWindow
+	} else {
+		config.TrialDuplicateWindow = 24 * time.Hour
+	}
+
+	config.TrustedProxies = parseTrustedProxies(getEnv("TRUSTED_PROXIES", ""))
+
+	config.RealtimeBroker = strings.ToLower(getEnv("REALTIME_BROKER", "memory"))
+
+	if smtpPort, err := strconv.Atoi(getEnv("SMTP_PORT", "587")); err == nil {
+		config.SMTPPort = smtpPort
+	} else {
+		config.SMTPPort = 587
+	}
+
+	if verificationTTL, err := time.ParseDuration(getEnv("EMAIL_VERIFICATION_TTL", "48h")); err == nil {
+		config.EmailVerificationTTL = verificationTTL
+	} else {
+		config.EmailVerificationTTL = 48 * time.Hour
+	}
+
+	if maxAttempts, err := strconv.Atoi(getEnv("NOTIFICATION_MAX_ATTEMPTS", "5")); err == nil && maxAttempts > 0 {
+		config.NotificationMaxAttempts = maxAttempts
+	} else {
+		config.NotificationMaxAttempts = 5
+	}
+
+	if baseDelay, err := time.ParseDuration(getEnv("NOTIFICATION_RETRY_BASE_DELAY", "1m")); err == nil {
+		config.NotificationRetryBaseDelay = baseDelay
+	} else {
+		config.NotificationRetryBaseDelay = time.Minute
+	}
+
+	if maxDelay, err := time.ParseDuration(getEnv("NOTIFICATION_RETRY_MAX_DELAY", "1h")); err == nil {
+		config.NotificationRetryMaxDelay = maxDelay
+	} else {
+		config.NotificationRetryMaxDelay = time.Hour
+	}
+
+	// Напоминания о дедлайне через запятую, например "24h,2h"
+	for _, part := range strings.Split(getEnv("DEADLINE_REMINDER_OFFSETS", "24h,2h"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if offset, err := time.ParseDuration(part); err == nil && offset > 0 {
+			config.DeadlineReminderOffsets = append(config.DeadlineReminderOffsets, offset)
+		}
+	}
+
 	if teacherID, err := strconv.ParseInt(getEnv("TEACHER_TELEGRAM_ID", "0"), 10, 64); err == nil {
 		config.TeacherTelegramID = teacherID
 	}
 
 	// Множественный список ID учителей через запятую
 	if idsCSV := getEnv("TEACHER_TELEGRAM_IDS", ""); idsCSV != "" {
-		var ids []int64
-		for _, part := range strings.Split(idsCSV, ",") {
-			part = strings.TrimSpace(part)
-			if part == "" {
-				continue
-			}
-			if id, err := strconv.ParseInt(part, 10, 64); err == nil {
-				ids = append(ids, id)
-			}
-		}
-		config.TeacherTelegramIDs = ids
+		config.TeacherTelegramIDs = parseTelegramIDs(idsCSV)
 	} else if config.TeacherTelegramID != 0 {
 		config.TeacherTelegramIDs = []int64{config.TeacherTelegramID}
 	}
+	config.AdminTelegramIDs = parseTelegramIDs(getEnv("ADMIN_TELEGRAM_IDS", ""))
 
 	return config, nil
 }
 
+// parseTelegramIDs разбирает список Telegram ID через запятую, пропуская некорректные значения
+func parseTelegramIDs(csv string) []int64 {
+	var ids []int64
+	for _, part := range strings.Split(csv, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if id, err := strconv.ParseInt(part, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// parseTrustedProxies разбирает список IP и CIDR через запятую, пропуская некорректные значения
+func parseTrustedProxies(csv string) []string {
+	var proxies []string
+	for _, part := range strings.Split(csv, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(part); err == nil || net.ParseIP(part) != nil {
+			proxies = append(proxies, part)
+		}
+	}
+	return proxies
+}
+
+// parseRateLimit разбирает правило "<limit>/<window>", при ошибке возвращает значение по умолчанию
+func parseRateLimit(value string, fallback ratelimit.Rule) ratelimit.Rule {
+	rule, err := ratelimit.ParseRule(value)
+	if err != nil {
+		return fallback
+	}
+	return rule
+}
+
 // getEnv получает переменную окружения или возвращает значение по умолчанию
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"edubot/internal/models"
+	"edubot/internal/services"
+)
+
+// accessTokenRouteScopes — маршруты, доступные по персональному токену, и нужная для них область.
+// Остальные маршруты (в том числе управление токенами и сессиями) принимают только вход через Telegram.
+var accessTokenRouteScopes = map[string]string{
+	"GET /api/teacher/assignments":             models.ScopeAssignmentsRead,
+	"POST /api/teacher/assignments":            models.ScopeAssignmentsWrite,
+	"PUT /api/teacher/assignments/:id":         models.ScopeAssignmentsWrite,
+	"DELETE /api/teacher/assignments/:id":      models.ScopeAssignmentsWrite,
+	"POST /api/teacher/groups/:id/assignments": models.ScopeAssignmentsWrite,
+	"GET /api/teacher/inbox":                   models.ScopeGradesRead,
+	"GET /api/teacher/inbox/:id":               models.ScopeGradesRead,
+	"POST /api/teacher/inbox/:id/grade":        models.ScopeGradesWrite,
+	"GET /api/teacher/students":                models.ScopeStudentsRead,
+	"GET /api/teacher/groups":                  models.ScopeStudentsRead,
+}
+
+// accessTokenScope возвращает область, нужную для маршрута; пустая строка — маршрут закрыт для токенов
+func accessTokenScope(method, fullPath string) string {
+	return accessTokenRouteScopes[method+" "+fullPath]
+}
+
+// AccessTokenHandler — управление персональными токенами для скриптов
+type AccessTokenHandler struct {
+	authService *services.AuthService
+}
+
+func NewAccessTokenHandler(authService *services.AuthService) *AccessTokenHandler {
+	return &AccessTokenHandler{
+		authService: authService,
+	}
+}
+
+// CreateAccessTokenRequest — новый персональный токен
+type CreateAccessTokenRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at"` // Необязательно; без него токен бессрочный
+}
+
+// POST /api/teacher/tokens - Выпустить персональный токен (значение показывается один раз)
+func (h *AccessTokenHandler) Create(c *gin.Context) {
+	var req CreateAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, raw, err := h.authService.CreateAccessToken(c.MustGet("user_id").(uuid.UUID), services.AccessTokenParams{
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTokenScope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "allowed_scopes": models.AccessTokenScopes})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"access_token": token, "token": raw})
+}
+
+// GET /api/teacher/tokens - Персональные токены текущего пользователя
+func (h *AccessTokenHandler) List(c *gin.Context) {
+	tokens, err := h.authService.ListAccessTokens(c.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"access_tokens": tokens, "allowed_scopes": models.AccessTokenScopes})
+}
+
+// DELETE /api/teacher/tokens/:id - Отозвать персональный токен
+func (h *AccessTokenHandler) Revoke(c *gin.Context) {
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+
+	if err := h.authService.RevokeAccessToken(c.MustGet("user_id").(uuid.UUID), tokenID); err != nil {
+		if errors.Is(err, services.ErrAccessTokenNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Access token not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke access token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Access token revoked"})
+}
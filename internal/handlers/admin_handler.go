@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"edubot/internal/services"
+)
+
+// AdminHandler — API администратора: приглашение, назначение и отключение преподавателей
+type AdminHandler struct {
+	authService *services.AuthService
+}
+
+func NewAdminHandler(authService *services.AuthService) *AdminHandler {
+	return &AdminHandler{
+		authService: authService,
+	}
+}
+
+// InviteTeacherRequest — приглашение преподавателя по Telegram ID
+type InviteTeacherRequest struct {
+	TelegramID int64  `json:"telegram_id" binding:"required"`
+	Username   string `json:"username"`
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+}
+
+// GET /api/admin/teachers - Список преподавателей
+func (h *AdminHandler) ListTeachers(c *gin.Context) {
+	teachers, err := h.authService.ListTeachers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get teachers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"teachers": teachers})
+}
+
+// POST /api/admin/teachers/invite - Пригласить преподавателя (учетная запись создается, если ее еще нет)
+func (h *AdminHandler) InviteTeacher(c *gin.Context) {
+	var req InviteTeacherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.authService.InviteTeacher(c.MustGet("user_id").(uuid.UUID), services.TeacherInviteParams{
+		TelegramID: req.TelegramID,
+		Username:   req.Username,
+		FirstName:  req.FirstName,
+		LastName:   req.LastName,
+	})
+	if err != nil {
+		h.writeRoleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// POST /api/admin/teachers/:id/promote - Назначить существующего пользователя преподавателем
+func (h *AdminHandler) PromoteTeacher(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	user, err := h.authService.PromoteToTeacher(c.MustGet("user_id").(uuid.UUID), userID)
+	if err != nil {
+		h.writeRoleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// POST /api/admin/teachers/:id/deactivate - Снять роль преподавателя и завершить его сессии
+func (h *AdminHandler) DeactivateTeacher(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	user, err := h.authService.DeactivateTeacher(c.MustGet("user_id").(uuid.UUID), userID)
+	if err != nil {
+		h.writeRoleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// GET /api/admin/role-changes - История смены ролей (user_id — фильтр по пользователю, limit — до 500)
+func (h *AdminHandler) ListRoleChanges(c *gin.Context) {
+	var userID *uuid.UUID
+	if raw := c.Query("user_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		userID = &id
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	changes, err := h.authService.ListRoleChanges(userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get role changes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"role_changes": changes})
+}
+
+func (h *AdminHandler) writeRoleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, services.ErrNotTeacher):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User is not a teacher"})
+	case errors.Is(err, services.ErrRoleChangeNotAllowed):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Role of this user cannot be changed"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
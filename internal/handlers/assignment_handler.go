@@ -3,6 +3,7 @@ package handlers
 import (
 	"edubot/internal/models"
 	"edubot/internal/services"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -202,7 +203,11 @@ func (h *AssignmentHandler) UpdateAssignment(c *gin.Context) {
 		assignment.Status = req.Status
 	}
 
-	if err := h.assignmentService.UpdateAssignment(assignment); err != nil {
+	if err := h.assignmentService.UpdateAssignment(actorFrom(c), assignment); err != nil {
+		if errors.Is(err, services.ErrAccessDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -240,13 +245,11 @@ func (h *AssignmentHandler) DeleteAssignment(c *gin.Context) {
 		return
 	}
 
-	teacherID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-
-	if err := h.assignmentService.DeleteAssignment(id, teacherID.(uuid.UUID)); err != nil {
+	if err := h.assignmentService.DeleteAssignment(id, actorFrom(c)); err != nil {
+		if errors.Is(err, services.ErrAccessDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"edubot/internal/models"
+	"edubot/internal/services"
+)
+
+// AssistantHandler — API ассистентов преподавателя: группы, где пользователь ассистент,
+// и работы учеников этих групп на проверку. Права проверяет политика доступа в сервисах.
+type AssistantHandler struct {
+	groupService   services.GroupService
+	gradingService services.GradingService
+}
+
+func NewAssistantHandler(groupService services.GroupService, gradingService services.GradingService) *AssistantHandler {
+	return &AssistantHandler{
+		groupService:   groupService,
+		gradingService: gradingService,
+	}
+}
+
+// GET /api/assistant/groups - Группы, где пользователь ассистент
+func (h *AssistantHandler) GetGroups(c *gin.Context) {
+	groups, err := h.groupService.ListGroupsByMemberRole(c.MustGet("user_id").(uuid.UUID), models.GroupRoleAssistant)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get groups"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"groups": groups})
+}
+
+// GET /api/assistant/inbox - Работы учеников групп ассистента (status: submitted по умолчанию, graded, all)
+func (h *AssistantHandler) GetInbox(c *gin.Context) {
+	var status models.AssignmentTargetStatus
+	switch c.DefaultQuery("status", "submitted") {
+	case "submitted", "pending":
+		status = models.AssignmentTargetStatusSubmitted
+	case "graded":
+		status = models.AssignmentTargetStatusGraded
+	case "all":
+		status = ""
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	targets, err := h.gradingService.GetAssistantTargets(c.MustGet("user_id").(uuid.UUID), status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get assignments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"assignments": targets,
+		"total":       len(targets),
+	})
+}
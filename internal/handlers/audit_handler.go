@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"edubot/internal/models"
+	"edubot/internal/repository"
+	"edubot/internal/services"
+)
+
+// AuditHandler — просмотр журнала изменений
+type AuditHandler struct {
+	auditService services.AuditService
+}
+
+func NewAuditHandler(auditService services.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// actorFrom собирает автора действия для журнала из контекста запроса
+func actorFrom(c *gin.Context) services.Actor {
+	actor := services.Actor{IP: c.ClientIP()}
+	if user, ok := c.Get("user"); ok {
+		actor.User, _ = user.(*models.User)
+	}
+	return actor
+}
+
+// GET /api/teacher/audit-log, GET /api/admin/audit-log - Журнал изменений
+// (entity_type, entity_id, actor_id — фильтры; limit — до 500, offset).
+// Преподаватель видит свои действия и изменения своих данных, администратор — все.
+func (h *AuditHandler) List(c *gin.Context) {
+	filter := repository.AuditLogFilter{EntityType: c.Query("entity_type")}
+
+	if raw := c.Query("entity_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+			return
+		}
+		filter.EntityID = &id
+	}
+	if raw := c.Query("actor_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid actor ID"})
+			return
+		}
+		filter.ActorID = &id
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+	if limit > 500 {
+		limit = 500
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	filter.Limit = limit
+	filter.Offset = offset
+
+	actor := actorFrom(c)
+	if actor.User == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	if actor.User.Role != models.RoleAdmin {
+		filter.VisibleTo = &actor.User.ID
+	}
+
+	entries, total, err := h.auditService.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get audit log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "total": total})
+}
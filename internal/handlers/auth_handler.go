@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 
 	"edubot/internal/models"
 	"edubot/internal/services"
+	"edubot/pkg/ratelimit"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -13,13 +16,17 @@ import (
 
 // AuthHandler представляет обработчик авторизации
 type AuthHandler struct {
-	authService *services.AuthService
+	authService        *services.AuthService
+	telegramIDLimiter  *ratelimit.Limiter // Входы с одним проверенным Telegram ID
+	authFailureLimiter *ratelimit.Limiter // Неудачные проверки подписи с одного IP
 }
 
 // NewAuthHandler создает новый обработчик авторизации
-func NewAuthHandler(authService *services.AuthService) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, telegramIDLimiter, authFailureLimiter *ratelimit.Limiter) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
+		authService:        authService,
+		telegramIDLimiter:  telegramIDLimiter,
+		authFailureLimiter: authFailureLimiter,
 	}
 }
 
@@ -32,19 +39,7 @@ type TelegramAuthRequest struct {
 	PhotoURL  string `json:"photo_url"`
 	AuthDate  int64  `json:"auth_date"`
 	Hash      string `json:"hash"`
-}
-
-// RegisterStudentRequest представляет запрос регистрации ученика
-type RegisterStudentRequest struct {
-	InviteCode string `json:"invite_code" binding:"required"`
-	Phone      string `json:"phone" binding:"required"`
-	Grade      int    `json:"grade" binding:"required,min=10,max=11"`
-	Subjects   string `json:"subjects" binding:"required"`
-}
-
-// RegisterStudentByCodeRequest представляет запрос регистрации ученика только по коду
-type RegisterStudentByCodeRequest struct {
-	InviteCode string `json:"invite_code" binding:"required"`
+	InitData  string `json:"init_data"` // Mini App: строка Telegram.WebApp.initData
 }
 
 // TeacherLoginRequest запрос для входа учителя (после Telegram-авторизации)
@@ -57,26 +52,6 @@ type SelectRoleRequest struct {
 	Role string `json:"role" binding:"required,oneof=teacher student"`
 }
 
-// CreateStudentByTeacherRequest запрос на создание ученика преподавателем
-type CreateStudentByTeacherRequest struct {
-	FirstName  string `json:"first_name" binding:"required"`
-	LastName   string `json:"last_name"`
-	Grade      int    `json:"grade" binding:"required,min=1,max=11"`
-	Subjects   string `json:"subjects"`
-	Phone      string `json:"phone"`
-	Username   string `json:"username"`
-	TelegramID int64  `json:"telegram_id"`
-}
-
-// AssignStudentRequest — teacher-driven назначение ученика без кода
-type AssignStudentRequest struct {
-	UserID     *uuid.UUID `json:"user_id"`
-	TelegramID *int64     `json:"telegram_id"`
-	Username   string     `json:"username"`
-	Grade      *int       `json:"grade"`
-	Subjects   string     `json:"subjects"`
-}
-
 // TrialRequestRequest представляет запрос на пробное занятие
 type TrialRequestRequest struct {
 	Name         string `json:"name" binding:"required"`
@@ -86,6 +61,7 @@ type TrialRequestRequest struct {
 	Comment      string `json:"comment"`
 	ContactType  string `json:"contact_type" binding:"required"`
 	ContactValue string `json:"contact_value" binding:"required"`
+	Website      string `json:"website"` // Скрытое поле-ловушка: человек его не видит и не заполняет
 }
 
 // SearchUsersRequest запрос для поиска пользователей
@@ -105,6 +81,10 @@ func (h *AuthHandler) TelegramAuth(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	ip := c.ClientIP()
+	if blockedRequest(c, h.authFailureLimiter, ip) {
+		return
+	}
 
 	authData := &services.TelegramAuthData{
 		ID:        req.ID,
@@ -114,48 +94,33 @@ func (h *AuthHandler) TelegramAuth(c *gin.Context) {
 		PhotoURL:  req.PhotoURL,
 		AuthDate:  req.AuthDate,
 		Hash:      req.Hash,
+		InitData:  req.InitData,
 	}
 
-	result, err := h.authService.AuthenticateWithTelegram(authData)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.authService.VerifyTelegramAuth(authData); err != nil {
+		// Неудачи считаем по IP: ID в непроверенных данных может быть чужим или пустым (init_data)
+		h.authFailureLimiter.Allow(ip)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
-
-	// Ставим jwt в cookie для удобства переходов по HTML
-	if result != nil && result.Token != "" {
-		c.SetCookie("jwt", result.Token, 3600*24, "/", "", false, true)
-	}
-	c.JSON(http.StatusOK, result)
-}
-
-// RegisterStudent регистрирует ученика по коду приглашения
-func (h *AuthHandler) RegisterStudent(c *gin.Context) {
-	var req RegisterStudentRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// Лимит по Telegram ID — только после проверки подписи, по проверенному ID
+	if !allowRequest(c, h.telegramIDLimiter, strconv.FormatInt(authData.ID, 10)) {
 		return
 	}
 
-	// Получаем Telegram ID из контекста (должен быть установлен middleware)
-	telegramIDStr, exists := c.Get("telegram_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "telegram_id not found"})
+	result, err := h.authService.AuthenticateWithTelegram(authData, clientInfo(c))
+	if errors.Is(err, services.ErrInvalidTelegramAuth) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
-
-	telegramID, err := strconv.ParseInt(telegramIDStr.(string), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid telegram_id"})
-		return
-	}
-
-	if err := h.authService.RegisterStudent(telegramID, req.InviteCode, req.Phone, req.Grade, req.Subjects); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Student registered successfully"})
+	// Ставим jwt в cookie для удобства переходов по HTML
+	setAuthCookies(c, result.TokenPair)
+	c.JSON(http.StatusOK, result)
 }
 
 // SubmitTrialRequest создает заявку на пробное занятие
@@ -166,6 +131,13 @@ func (h *AuthHandler) SubmitTrialRequest(c *gin.Context) {
 		return
 	}
 
+	// Заполненная ловушка — это бот: отвечаем как обычно, но заявку не сохраняем
+	if req.Website != "" {
+		log.Printf("Trial request honeypot triggered from %s", c.ClientIP())
+		c.JSON(http.StatusOK, gin.H{"message": "Trial request submitted successfully"})
+		return
+	}
+
 	// Для публичных заявок telegram_id не обязателен
 	trialRequest := &models.TrialRequest{
 		Name:         req.Name,
@@ -179,6 +151,10 @@ func (h *AuthHandler) SubmitTrialRequest(c *gin.Context) {
 	}
 
 	if err := h.authService.SubmitTrialRequest(trialRequest); err != nil {
+		if errors.Is(err, services.ErrDuplicateTrialRequest) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -186,17 +162,6 @@ func (h *AuthHandler) SubmitTrialRequest(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Trial request submitted successfully"})
 }
 
-// GenerateInviteCode генерирует код приглашения
-func (h *AuthHandler) GenerateInviteCode(c *gin.Context) {
-	code, err := h.authService.GenerateInviteCode()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"invite_code": code})
-}
-
 // GetProfile получает профиль пользователя
 func (h *AuthHandler) GetProfile(c *gin.Context) {
 	user, exists := c.Get("user")
@@ -221,7 +186,7 @@ func (h *AuthHandler) GetTrialRequests(c *gin.Context) {
 
 // GetStats получает статистику для панели управления
 func (h *AuthHandler) GetStats(c *gin.Context) {
-	stats, err := h.authService.GetStats()
+	stats, err := h.authService.GetStats(c.MustGet("user_id").(uuid.UUID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -232,7 +197,7 @@ func (h *AuthHandler) GetStats(c *gin.Context) {
 
 // GetStudents получает список учеников для учителя
 func (h *AuthHandler) GetStudents(c *gin.Context) {
-	students, err := h.authService.GetStudents()
+	students, err := h.authService.GetStudents(c.MustGet("user_id").(uuid.UUID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -241,104 +206,35 @@ func (h *AuthHandler) GetStudents(c *gin.Context) {
 	c.JSON(http.StatusOK, students)
 }
 
-// CreateStudentByTeacher создает ученика и возвращает код приглашения
-func (h *AuthHandler) CreateStudentByTeacher(c *gin.Context) {
-	var req CreateStudentByTeacherRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// UnlinkStudent отвязывает ученика от преподавателя
+func (h *AuthHandler) UnlinkStudent(c *gin.Context) {
+	studentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid student ID"})
 		return
 	}
 
-	// Если указан username без остальных полей — пытаемся привязать существующего
-	if req.Username != "" && req.FirstName == "" && req.Grade == 0 {
-		user, err := h.authService.LinkExistingStudentByUsername(req.Username)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := h.authService.UnlinkStudent(c.MustGet("user_id").(uuid.UUID), studentID); err != nil {
+		if errors.Is(err, services.ErrStudentNotLinked) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"user": user, "invite_code": user.InviteCode})
-		return
-	}
-
-	user, code, err := h.authService.CreateStudentByTeacher(
-		req.FirstName,
-		req.LastName,
-		req.Grade,
-		req.Subjects,
-		req.Phone,
-		req.Username,
-		req.TelegramID,
-	)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"user":        user,
-		"invite_code": code,
-	})
-}
-
-// AssignStudentToTeacher назначает гостя учеником (teacher-only)
-func (h *AuthHandler) AssignStudentToTeacher(c *gin.Context) {
-	// Проверяем роль
-	roleVal, exists := c.Get("user_role")
-	if !exists || roleVal != models.RoleTeacher {
-		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
-		return
-	}
-	userVal, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-	teacherID, ok := userVal.(uuid.UUID)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user id"})
-		return
-	}
-
-	var req AssignStudentRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	user, err := h.authService.AssignStudentToTeacher(teacherID, services.AssignStudentParams{
-		UserID:     req.UserID,
-		TelegramID: req.TelegramID,
-		Username:   req.Username,
-		Grade:      req.Grade,
-		Subjects:   req.Subjects,
-	})
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"user": user})
+	c.JSON(http.StatusOK, gin.H{"message": "Student unlinked"})
 }
 
-// RegisterStudentByCode регистрирует ученика только по коду приглашения
-func (h *AuthHandler) RegisterStudentByCode(c *gin.Context) {
-	var req RegisterStudentByCodeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Создаем временного пользователя и регистрируем его как ученика
-	user, token, err := h.authService.RegisterStudentByCode(req.InviteCode)
+// GetTeachers получает список преподавателей ученика
+func (h *AuthHandler) GetTeachers(c *gin.Context) {
+	teachers, err := h.authService.GetTeachers(c.MustGet("user_id").(uuid.UUID))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Student registered successfully",
-		"user":    user,
-		"token":   token,
-	})
+	c.JSON(http.StatusOK, gin.H{"teachers": teachers})
 }
 
 // TeacherLogin после Telegram-авторизации повышает роль до teacher при верном пароле и валидном Telegram ID
@@ -378,7 +274,7 @@ func (h *AuthHandler) TeacherLogin(c *gin.Context) {
 	}
 
 	// Выдаём новый токен с ролью teacher
-	token, err := h.authService.GenerateToken(user)
+	token, err := h.authService.GenerateToken(user, c.MustGet("session_id").(uuid.UUID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -408,7 +304,7 @@ func (h *AuthHandler) SelectRole(c *gin.Context) {
 		role = models.RoleStudent
 	}
 
-	token, err := h.authService.SelectRole(user, role)
+	token, err := h.authService.SelectRole(user, role, c.MustGet("session_id").(uuid.UUID))
 	if err != nil {
 		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		return
@@ -431,7 +327,7 @@ func (h *AuthHandler) ApproveTrialRequest(c *gin.Context) {
 		return
 	}
 
-	err := h.authService.ApproveTrialRequest(requestID)
+	err := h.authService.ApproveTrialRequest(requestID, actorFrom(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -455,7 +351,7 @@ func (h *AuthHandler) RejectTrialRequest(c *gin.Context) {
 		return
 	}
 
-	err := h.authService.RejectTrialRequest(requestID)
+	err := h.authService.RejectTrialRequest(requestID, actorFrom(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -464,7 +360,7 @@ func (h *AuthHandler) RejectTrialRequest(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Trial request rejected successfully"})
 }
 
-// SearchUsers ищет пользователей по запросу (только гости)
+// SearchUsers ищет пользователей по запросу (гости и свои ученики)
 func (h *AuthHandler) SearchUsers(c *gin.Context) {
 	var req SearchUsersRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -472,7 +368,7 @@ func (h *AuthHandler) SearchUsers(c *gin.Context) {
 		return
 	}
 
-	users, err := h.authService.SearchUsers(req.Query)
+	users, err := h.authService.SearchUsers(c.MustGet("user_id").(uuid.UUID), req.Query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -489,7 +385,7 @@ func (h *AuthHandler) HideTrialRequest(c *gin.Context) {
 		return
 	}
 
-	err := h.authService.HideTrialRequest(requestID)
+	err := h.authService.HideTrialRequest(requestID, actorFrom(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"edubot/internal/models"
 	"edubot/internal/services"
@@ -44,9 +46,21 @@ func (h *ChatHandler) GetThreads(c *gin.Context) {
 	}
 
 	// Добавляем информацию о непрочитанных сообщениях
+	threadIDs := make([]uuid.UUID, 0, len(threads))
+	for _, thread := range threads {
+		threadIDs = append(threadIDs, thread.ID)
+	}
+	unreadCounts, err := h.chatService.GetUnreadCounts(threadIDs, userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get unread counts"})
+		return
+	}
+
 	var result []gin.H
+	var totalUnread int64
 	for _, thread := range threads {
-		unreadCount, _ := h.chatService.GetUnreadCount(thread.ID, userUUID)
+		unreadCount := unreadCounts[thread.ID]
+		totalUnread += unreadCount
 
 		result = append(result, gin.H{
 			"id":              thread.ID,
@@ -61,7 +75,8 @@ func (h *ChatHandler) GetThreads(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"threads": result,
+		"threads":      result,
+		"total_unread": totalUnread,
 	})
 }
 
@@ -88,7 +103,7 @@ func (h *ChatHandler) GetThread(c *gin.Context) {
 
 	// Получаем тред
 	thread, err := h.chatService.GetThread(threadID)
-	if err != nil {
+	if err != nil || !h.chatService.CanAccessThread(thread, userUUID) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Thread not found"})
 		return
 	}
@@ -111,6 +126,13 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 		return
 	}
 
+	userUUID := c.MustGet("user_id").(uuid.UUID)
+	thread, err := h.chatService.GetThread(threadID)
+	if err != nil || !h.chatService.CanAccessThread(thread, userUUID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Thread not found"})
+		return
+	}
+
 	// Получаем параметры запроса
 	limitStr := c.DefaultQuery("limit", "50")
 	beforeStr := c.Query("before")
@@ -134,10 +156,25 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 		return
 	}
 
+	// Курсоры чтения остальных участников: сообщение «просмотрено», если его время не позже last_read_at
+	readCursors, err := h.chatService.ListReadCursors(threadID, userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get read receipts"})
+		return
+	}
+
+	pinned, err := h.chatService.ListPinned(threadID, userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pinned messages"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"messages": messages,
-		"limit":    limit,
-		"before":   before,
+		"messages":        messages,
+		"read_cursors":    readCursors,
+		"pinned_messages": pinned,
+		"limit":           limit,
+		"before":          before,
 	})
 }
 
@@ -163,9 +200,10 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 	}
 
 	var request struct {
-		Text     *string     `json:"text"`
-		MediaIDs []uuid.UUID `json:"media_ids"`
-		Kind     string      `json:"kind,omitempty"`
+		Text      *string     `json:"text"`
+		MediaIDs  []uuid.UUID `json:"media_ids"`
+		ReplyToID *uuid.UUID  `json:"reply_to_id"`
+		Kind      string      `json:"kind,omitempty"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -180,7 +218,7 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 	}
 
 	// Отправляем сообщение
-	message, err := h.chatService.SendMessage(threadID, userUUID, request.Text, request.MediaIDs, kind)
+	message, err := h.chatService.SendMessage(threadID, userUUID, request.Text, request.MediaIDs, request.ReplyToID, kind)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -291,7 +329,147 @@ func (h *ChatHandler) DeleteMessage(c *gin.Context) {
 	})
 }
 
+// POST /api/chat/messages/:id/reactions - Поставить реакцию ({"emoji": "👍"})
+func (h *ChatHandler) AddReaction(c *gin.Context) {
+	userUUID, messageID, ok := chatMessageParams(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Emoji string `json:"emoji" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	message, err := h.chatService.AddReaction(messageID, userUUID, request.Emoji)
+	if err != nil {
+		respondChatMessageError(c, err, "Failed to add reaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+	})
+}
+
+// DELETE /api/chat/messages/:id/reactions?emoji=👍 - Снять свою реакцию
+func (h *ChatHandler) RemoveReaction(c *gin.Context) {
+	userUUID, messageID, ok := chatMessageParams(c)
+	if !ok {
+		return
+	}
+
+	emoji := c.Query("emoji")
+	if emoji == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "emoji is required"})
+		return
+	}
+
+	message, err := h.chatService.RemoveReaction(messageID, userUUID, emoji)
+	if err != nil {
+		respondChatMessageError(c, err, "Failed to remove reaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+	})
+}
+
+// POST /api/chat/messages/:id/pin - Закрепить сообщение (в групповом чате — только преподаватель)
+func (h *ChatHandler) PinMessage(c *gin.Context) {
+	userUUID, messageID, ok := chatMessageParams(c)
+	if !ok {
+		return
+	}
+
+	message, err := h.chatService.PinMessage(messageID, userUUID)
+	if err != nil {
+		respondChatMessageError(c, err, "Failed to pin message")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+	})
+}
+
+// DELETE /api/chat/messages/:id/pin - Открепить сообщение
+func (h *ChatHandler) UnpinMessage(c *gin.Context) {
+	userUUID, messageID, ok := chatMessageParams(c)
+	if !ok {
+		return
+	}
+
+	message, err := h.chatService.UnpinMessage(messageID, userUUID)
+	if err != nil {
+		respondChatMessageError(c, err, "Failed to unpin message")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+	})
+}
+
+// GET /api/chat/threads/:id/pinned - Закрепленные сообщения чата
+func (h *ChatHandler) GetPinned(c *gin.Context) {
+	userUUID := c.MustGet("user_id").(uuid.UUID)
+	threadID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid thread ID"})
+		return
+	}
+
+	messages, err := h.chatService.ListPinned(threadID, userUUID)
+	if err != nil {
+		if errors.Is(err, services.ErrAccessDenied) || errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Thread not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pinned messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"messages": messages,
+	})
+}
+
+// chatMessageParams разбирает пользователя и ID сообщения; при ошибке ответ уже отправлен
+func chatMessageParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userUUID, ok := c.MustGet("user_id").(uuid.UUID)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	messageID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userUUID, messageID, true
+}
+
+// respondChatMessageError отвечает на ошибку операции с сообщением
+func respondChatMessageError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+	case errors.Is(err, services.ErrAccessDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	case errors.Is(err, services.ErrInvalidReaction):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "allowed": models.MessageReactionEmojis})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
 // POST /api/chat/threads/:id/read - Отметить чат как прочитанный
+// (message_id — прочитано до этого сообщения; без него — до последнего)
 func (h *ChatHandler) MarkAsRead(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -312,9 +490,27 @@ func (h *ChatHandler) MarkAsRead(c *gin.Context) {
 		return
 	}
 
+	var request struct {
+		MessageID *uuid.UUID `json:"message_id"`
+	}
+	// Тело необязательно
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+
 	// Отмечаем как прочитанное
-	if err := h.chatService.MarkAsRead(threadID, userUUID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark as read"})
+	if err := h.chatService.MarkAsRead(threadID, userUUID, request.MessageID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrAccessDenied), errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Thread not found"})
+		case errors.Is(err, services.ErrMessageNotInThread):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark as read"})
+		}
 		return
 	}
 
@@ -323,6 +519,39 @@ func (h *ChatHandler) MarkAsRead(c *gin.Context) {
 	})
 }
 
+// POST /api/chat/threads/:id/typing - Сообщить собеседникам, что пользователь печатает.
+// Клиент повторяет запрос раз в несколько секунд, пока идет набор.
+func (h *ChatHandler) Typing(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userUUID, ok := userID.(uuid.UUID)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	threadID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid thread ID"})
+		return
+	}
+
+	if err := h.chatService.SendTyping(threadID, userUUID); err != nil {
+		if errors.Is(err, services.ErrAccessDenied) || errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Thread not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send typing status"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // POST /api/chat/threads/student-teacher - Создать или получить чат с учителем
 func (h *ChatHandler) GetOrCreateStudentTeacherThread(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -349,7 +578,14 @@ func (h *ChatHandler) GetOrCreateStudentTeacherThread(c *gin.Context) {
 	// Создаем или получаем тред
 	thread, err := h.chatService.GetOrCreateStudentTeacherThread(userUUID, request.TeacherID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create thread"})
+		switch {
+		case errors.Is(err, services.ErrTeacherRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrStudentNotLinked):
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not a student of this teacher"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create thread"})
+		}
 		return
 	}
 
@@ -384,6 +620,10 @@ func (h *ChatHandler) GetOrCreateGroupThread(c *gin.Context) {
 	// Создаем или получаем тред
 	thread, err := h.chatService.GetOrCreateGroupThread(request.GroupID, userUUID)
 	if err != nil {
+		if errors.Is(err, services.ErrAccessDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create thread"})
 		return
 	}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"edubot/internal/services"
+)
+
+type EmailHandler struct {
+	emailService services.EmailService
+}
+
+func NewEmailHandler(emailService services.EmailService) *EmailHandler {
+	return &EmailHandler{
+		emailService: emailService,
+	}
+}
+
+// POST /api/profile/email - Указать email для уведомлений и отправить письмо с подтверждением
+func (h *EmailHandler) RequestVerification(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Email string `json:"email" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	if err := h.emailService.RequestVerification(userID.(uuid.UUID), req.Email); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidEmail):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrEmailDisabled):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// GET /api/public/email/verify?token=... - Подтверждение email по ссылке из письма
+func (h *EmailHandler) Verify(c *gin.Context) {
+	if _, err := h.emailService.Verify(c.Query("token")); err != nil {
+		c.Redirect(http.StatusFound, "/?email_verified=0")
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/?email_verified=1")
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -68,6 +69,7 @@ func (h *GroupHandler) ListGroups(c *gin.Context) {
 
 type memberReq struct {
 	UserID uuid.UUID `json:"user_id"`
+	Role   string    `json:"role"` // student (по умолчанию) или assistant
 }
 
 func (h *GroupHandler) AddMember(c *gin.Context) {
@@ -81,8 +83,8 @@ func (h *GroupHandler) AddMember(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := h.svc.AddMember(gid, req.UserID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.svc.AddMember(c.MustGet("user").(*models.User), gid, req.UserID, req.Role); err != nil {
+		c.JSON(groupErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
@@ -99,8 +101,8 @@ func (h *GroupHandler) RemoveMember(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
 		return
 	}
-	if err := h.svc.RemoveMember(gid, uid); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.svc.RemoveMember(c.MustGet("user").(*models.User), gid, uid); err != nil {
+		c.JSON(groupErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
@@ -123,9 +125,16 @@ func (h *GroupHandler) AssignHomework(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := h.svc.AssignHomeworkToGroup(gid, req.Title, req.Description, req.Subject, req.Grade, req.Level, req.DueAt); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.svc.AssignHomeworkToGroup(c.MustGet("user").(*models.User), gid, req.Title, req.Description, req.Subject, req.Grade, req.Level, req.DueAt); err != nil {
+		c.JSON(groupErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "assigned"})
 }
+
+func groupErrorStatus(err error) int {
+	if errors.Is(err, services.ErrAccessDenied) {
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"edubot/internal/models"
+	"edubot/internal/services"
+)
+
+// InviteHandler — коды приглашения учеников: выдача преподавателем и активация
+type InviteHandler struct {
+	inviteService services.InviteService
+}
+
+func NewInviteHandler(inviteService services.InviteService) *InviteHandler {
+	return &InviteHandler{
+		inviteService: inviteService,
+	}
+}
+
+// CreateInviteRequest — настройки кода; все поля необязательны
+type CreateInviteRequest struct {
+	GroupID   *uuid.UUID `json:"group_id"`
+	Grade     int        `json:"grade"`
+	Subjects  string     `json:"subjects"`
+	MaxUses   int        `json:"max_uses"`   // 0 — без ограничения
+	ExpiresAt *time.Time `json:"expires_at"` // RFC 3339; пусто — бессрочный
+}
+
+// InviteStudentRequest — кого пригласить персонально: user_id, telegram_id или username
+type InviteStudentRequest struct {
+	UserID     *uuid.UUID `json:"user_id"`
+	TelegramID *int64     `json:"telegram_id"`
+	Username   string     `json:"username"`
+	Grade      *int       `json:"grade"`
+	Subjects   string     `json:"subjects"`
+}
+
+// CreateStudentRequest — новый ученик; только username — пригласить существующего пользователя
+type CreateStudentRequest struct {
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	Grade      int    `json:"grade" binding:"omitempty,min=1,max=11"`
+	Subjects   string `json:"subjects"`
+	Phone      string `json:"phone"`
+	Username   string `json:"username"`
+	TelegramID int64  `json:"telegram_id"`
+}
+
+// inviteView — код вместе со ссылкой на бота
+type inviteView struct {
+	*models.Invite
+	Link string `json:"link,omitempty"`
+}
+
+// POST /api/teacher/invite-codes - Создать код приглашения (группа, класс, предметы, лимит, срок)
+func (h *InviteHandler) CreateInvite(c *gin.Context) {
+	var req CreateInviteRequest
+	// Тело необязательно: старый клиент вызывает /invite-code без параметров
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invite, err := h.inviteService.CreateInvite(c.MustGet("user_id").(uuid.UUID), services.InviteParams{
+		GroupID:   req.GroupID,
+		Grade:     req.Grade,
+		Subjects:  req.Subjects,
+		MaxUses:   req.MaxUses,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invite":      h.view(invite),
+		"invite_code": invite.Code,
+	})
+}
+
+// POST /api/teacher/students/assign - Отправить пользователю персональное приглашение в ученики
+func (h *InviteHandler) InviteStudent(c *gin.Context) {
+	var req InviteStudentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, invite, err := h.inviteService.InviteStudent(c.MustGet("user_id").(uuid.UUID), services.InviteStudentParams{
+		UserID:     req.UserID,
+		TelegramID: req.TelegramID,
+		Username:   req.Username,
+		Grade:      req.Grade,
+		Subjects:   req.Subjects,
+	})
+	if err != nil {
+		respondInviteStudentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":        user,
+		"invite":      h.view(invite),
+		"invite_code": invite.Code,
+	})
+}
+
+// POST /api/teacher/students - Выдать код приглашения новому ученику
+func (h *InviteHandler) CreateStudent(c *gin.Context) {
+	var req CreateStudentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	teacherID := c.MustGet("user_id").(uuid.UUID)
+	var user *models.User
+	var invite *models.Invite
+	var err error
+	if req.Username != "" && req.FirstName == "" && req.Grade == 0 {
+		// Только username — приглашаем уже зарегистрированного пользователя
+		user, invite, err = h.inviteService.InviteStudent(teacherID, services.InviteStudentParams{Username: req.Username})
+	} else {
+		if req.FirstName == "" || req.Grade == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "first_name and grade are required"})
+			return
+		}
+		user, invite, err = h.inviteService.CreateStudentInvite(teacherID, services.NewStudentParams{
+			FirstName:  req.FirstName,
+			LastName:   req.LastName,
+			Phone:      req.Phone,
+			TelegramID: req.TelegramID,
+			Grade:      req.Grade,
+			Subjects:   req.Subjects,
+		})
+	}
+	if err != nil {
+		respondInviteStudentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":        user,
+		"invite":      h.view(invite),
+		"invite_code": invite.Code,
+	})
+}
+
+// GET /api/teacher/invite-codes - Коды приглашения преподавателя
+func (h *InviteHandler) ListInvites(c *gin.Context) {
+	invites, err := h.inviteService.ListInvites(c.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get invites"})
+		return
+	}
+
+	views := make([]inviteView, 0, len(invites))
+	for _, invite := range invites {
+		views = append(views, h.view(invite))
+	}
+	c.JSON(http.StatusOK, gin.H{"invites": views})
+}
+
+// DELETE /api/teacher/invite-codes/:id - Отозвать код приглашения
+func (h *InviteHandler) RevokeInvite(c *gin.Context) {
+	inviteID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invite ID"})
+		return
+	}
+
+	if err := h.inviteService.RevokeInvite(c.MustGet("user_id").(uuid.UUID), inviteID); err != nil {
+		if errors.Is(err, services.ErrInviteNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invite not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke invite"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invite revoked"})
+}
+
+// POST /api/invites/redeem - Активировать код приглашения текущим пользователем
+func (h *InviteHandler) Redeem(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	invite, err := h.inviteService.Redeem(user, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidInvite):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired code"})
+		case errors.Is(err, services.ErrInviteRoleConflict):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only guests and students can use invite codes"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redeem invite"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":     user,
+		"group_id": invite.GroupID,
+	})
+}
+
+// respondInviteStudentError отвечает на ошибку персонального приглашения ученика
+func respondInviteStudentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, services.ErrInviteRoleConflict):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only guests and students can be invited"})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *InviteHandler) view(invite *models.Invite) inviteView {
+	return inviteView{Invite: invite, Link: h.inviteService.DeepLink(invite.Code)}
+}
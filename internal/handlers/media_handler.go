@@ -185,6 +185,12 @@ func (h *MediaHandler) GetMedia(c *gin.Context) {
 		return
 	}
 
+	allowed, err := h.mediaService.CheckMediaAccess(id, c.MustGet("user_id").(uuid.UUID))
+	if err != nil || !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"media": media})
 }
 
@@ -314,7 +320,16 @@ func (h *MediaHandler) GetEntityMedia(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"media": media})
+	// Возвращаем только файлы, доступные пользователю
+	userUUID := c.MustGet("user_id").(uuid.UUID)
+	visible := make([]*models.Media, 0, len(media))
+	for _, m := range media {
+		if allowed, err := h.mediaService.CheckMediaAccess(m.ID, userUUID); err == nil && allowed {
+			visible = append(visible, m)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"media": visible})
 }
 
 // UpdateMedia обновляет медиафайл
@@ -466,7 +481,7 @@ func (h *MediaHandler) GrantAccess(c *gin.Context) {
 		return
 	}
 
-	err = h.mediaService.GrantMediaAccess(id, req.UserID, req.Permission)
+	err = h.mediaService.GrantMediaAccess(id, req.UserID, req.Permission, actorFrom(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -515,7 +530,7 @@ func (h *MediaHandler) RevokeAccess(c *gin.Context) {
 		return
 	}
 
-	err = h.mediaService.RevokeMediaAccess(id, userID)
+	err = h.mediaService.RevokeMediaAccess(id, userID, actorFrom(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
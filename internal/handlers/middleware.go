@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"strings"
+	"sync/atomic"
 
 	"edubot/internal/models"
 	"edubot/internal/services"
+	"edubot/pkg/telegram"
 
 	"github.com/gin-gonic/gin"
 )
@@ -32,8 +35,38 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
             return
         }
 
-		// Валидируем токен
-		user, err := authService.ValidateToken(token)
+		// Персональный токен пускаем только на маршруты, открытые для его областей действия
+		if services.IsAccessToken(token) {
+			user, accessToken, err := authService.ValidateAccessTokenSecret(token)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+				c.Abort()
+				return
+			}
+			scope := accessTokenScope(c.Request.Method, c.FullPath())
+			if scope == "" {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Access tokens are not accepted for this endpoint"})
+				c.Abort()
+				return
+			}
+			if !accessToken.HasScope(scope) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Access token scope does not allow this request", "required_scope": scope})
+				c.Abort()
+				return
+			}
+
+			c.Set("user", user)
+			c.Set("access_token_id", accessToken.ID) // uuid.UUID
+			c.Set("user_id", user.ID)                // uuid.UUID
+			c.Set("telegram_id", user.TelegramID)
+			c.Set("user_role", user.Role) // models.UserRole
+
+			c.Next()
+			return
+		}
+
+		// Валидируем токен и проверяем, что сессия не отозвана
+		user, sessionID, err := authService.ValidateAccessToken(token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
@@ -42,6 +75,8 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 
 		// Сохраняем данные пользователя в контексте (строгие типы)
 		c.Set("user", user)
+		c.Set("session_id", sessionID) // uuid.UUID
+		c.Set("access_token", token)   // string; долгие соединения (SSE) перепроверяют его
 		c.Set("user_id", user.ID) // uuid.UUID
 		c.Set("telegram_id", user.TelegramID)
 		c.Set("user_role", user.Role) // models.UserRole
@@ -187,3 +222,26 @@ func RequireHTMLRoles(allowed ...models.UserRole) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// webhookRejected — счетчик отклоненных запросов к /webhook
+var webhookRejected int64
+
+// WebhookRejectedCount возвращает число запросов к /webhook без правильного secret_token
+func WebhookRejectedCount() int64 {
+	return atomic.LoadInt64(&webhookRejected)
+}
+
+// TelegramWebhookMiddleware пропускает только запросы с заголовком secret_token,
+// заданным при регистрации webhook. Пустой secret отклоняет все запросы.
+func TelegramWebhookMiddleware(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !telegram.VerifyWebhookSecret(secret, c.GetHeader(telegram.WebhookSecretHeader)) {
+			total := atomic.AddInt64(&webhookRejected, 1)
+			log.Printf("Rejected webhook request from %s without valid secret token (total rejected: %d)", c.ClientIP(), total)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid webhook secret"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"edubot/internal/models"
+	"edubot/internal/services"
+)
+
+type NotificationHandler struct {
+	notificationService services.NotificationService
+}
+
+func NewNotificationHandler(notificationService services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// GET /api/notifications/preferences - Настройки уведомлений пользователя
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	prefs, err := h.notificationService.GetPreferences(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// PUT /api/notifications/preferences - Обновить настройки уведомлений
+// Тело: {"channels": {"new_message": {"bot": false}}, "quiet_hours": {"enabled": true, "start": "22:00", "end": "08:00"}}
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.NotificationPreferences
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	prefs, err := h.notificationService.UpdatePreferences(userID.(uuid.UUID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// GET /api/teacher/notifications/failed?status=failed|bounced - Недоставленные уведомления учеников
+func (h *NotificationHandler) ListUndelivered(c *gin.Context) {
+	teacherID := c.MustGet("user_id").(uuid.UUID)
+	status := models.NotificationStatus(c.DefaultQuery("status", string(models.NotificationStatusFailed)))
+
+	notifications, err := h.notificationService.ListUndeliveredNotifications(teacherID, status)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"total":         len(notifications),
+	})
+}
+
+// POST /api/teacher/notifications/:id/requeue - Повторно поставить уведомление в очередь
+func (h *NotificationHandler) Requeue(c *gin.Context) {
+	teacherID := c.MustGet("user_id").(uuid.UUID)
+	notificationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	if err := h.notificationService.RequeueNotification(teacherID, notificationID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, services.ErrAccessDenied) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification requeued"})
+}
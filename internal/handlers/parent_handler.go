@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"edubot/internal/models"
+	"edubot/internal/services"
+)
+
+// ParentHandler — API родителей: привязка по коду и просмотр успеваемости детей (только чтение)
+type ParentHandler struct {
+	parentService services.ParentService
+}
+
+func NewParentHandler(parentService services.ParentService) *ParentHandler {
+	return &ParentHandler{
+		parentService: parentService,
+	}
+}
+
+// POST /api/teacher/students/:id/parent-invite - Выдать код приглашения для родителя ученика
+func (h *ParentHandler) CreateInvite(c *gin.Context) {
+	studentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid student ID"})
+		return
+	}
+
+	invite, err := h.parentService.CreateInvite(c.MustGet("user_id").(uuid.UUID), studentID)
+	if err != nil {
+		if errors.Is(err, services.ErrStudentNotLinked) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create parent invite"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":       invite.Code,
+		"expires_at": invite.ExpiresAt,
+	})
+}
+
+// POST /api/parent/link - Привязаться к ребенку по коду от преподавателя
+func (h *ParentHandler) AcceptInvite(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	child, err := h.parentService.AcceptInvite(c.MustGet("user").(*models.User), req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidParentInvite):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired code"})
+		case errors.Is(err, services.ErrParentRoleConflict):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Students and teachers cannot link as parents"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link child"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"child": child})
+}
+
+// GET /api/parent/children - Дети родителя
+func (h *ParentHandler) GetChildren(c *gin.Context) {
+	children, err := h.parentService.ListChildren(c.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get children"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"children": children})
+}
+
+// GET /api/parent/children/:id/assignments - Задания ребенка: статусы, оценки и отзывы
+func (h *ParentHandler) GetChildAssignments(c *gin.Context) {
+	studentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid student ID"})
+		return
+	}
+
+	targets, err := h.parentService.GetChildAssignments(c.MustGet("user_id").(uuid.UUID), studentID)
+	if err != nil {
+		h.writeChildError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"assignments": targets})
+}
+
+// GET /api/parent/children/:id/progress - Сводка успеваемости ребенка
+func (h *ParentHandler) GetChildProgress(c *gin.Context) {
+	studentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid student ID"})
+		return
+	}
+
+	progress, err := h.parentService.GetChildProgress(c.MustGet("user_id").(uuid.UUID), studentID)
+	if err != nil {
+		h.writeChildError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+func (h *ParentHandler) writeChildError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrChildNotLinked) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Child not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get child data"})
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"edubot/internal/services"
+)
+
+// PrivacyHandler — выгрузка и удаление данных пользователя
+type PrivacyHandler struct {
+	privacyService services.PrivacyService
+}
+
+func NewPrivacyHandler(privacyService services.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: privacyService,
+	}
+}
+
+// GET /api/teacher/students/:id/export, GET /api/admin/users/:id/export - ZIP-архив
+// со всеми данными пользователя. Преподаватель выгружает только своих учеников.
+func (h *PrivacyHandler) Export(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	actor := actorFrom(c)
+	if actor.User == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// Архив собирается во временный файл: ошибку можно вернуть до начала ответа
+	tmp, err := os.CreateTemp("", "edubot-export-*.zip")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare export"})
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	err = h.privacyService.Export(actor, userID, tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		default:
+			log.Printf("Failed to export user data %s: %v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export user data"})
+		}
+		return
+	}
+
+	c.FileAttachment(tmp.Name(), fmt.Sprintf("edubot-export-%s.zip", userID))
+}
+
+// EraseRequest — подтверждение удаления данных
+type EraseRequest struct {
+	Confirm bool `json:"confirm"`
+}
+
+// POST /api/admin/users/:id/erase - Безвозвратное удаление данных пользователя
+// (тело {"confirm": true}); возвращает отчет об удалении
+func (h *PrivacyHandler) Erase(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req EraseRequest
+	if err := c.ShouldBindJSON(&req); err != nil || !req.Confirm {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Erasure must be confirmed with \"confirm\": true"})
+		return
+	}
+
+	report, err := h.privacyService.Erase(actorFrom(c), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		case errors.Is(err, services.ErrErasureNotAllowed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		default:
+			log.Printf("Failed to erase user data %s: %v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to erase user data"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"edubot/pkg/ratelimit"
+)
+
+// RateLimitByIP ограничивает частоту запросов с одного IP
+func RateLimitByIP(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !allowRequest(c, limiter, c.ClientIP()) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// allowRequest учитывает запрос по ключу; при превышении лимита отвечает 429 с Retry-After
+func allowRequest(c *gin.Context, limiter *ratelimit.Limiter, key string) bool {
+	allowed, retryAfter := limiter.Allow(key)
+	if allowed {
+		return true
+	}
+	tooManyRequests(c, retryAfter)
+	return false
+}
+
+// blockedRequest не учитывает запрос, но отвечает 429, если лимит ключа уже исчерпан
+func blockedRequest(c *gin.Context, limiter *ratelimit.Limiter, key string) bool {
+	blocked, retryAfter := limiter.Blocked(key)
+	if blocked {
+		tooManyRequests(c, retryAfter)
+	}
+	return blocked
+}
+
+// tooManyRequests отвечает 429 с Retry-After
+func tooManyRequests(c *gin.Context, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many requests, try again later",
+		"retry_after": seconds,
+	})
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"edubot/internal/realtime"
+	"edubot/internal/services"
+)
+
+// realtimeHeartbeat — интервал комментариев-пингов, чтобы прокси не закрывали соединение.
+// С тем же интервалом перепроверяется access-токен потока.
+const realtimeHeartbeat = 25 * time.Second
+
+// RealtimeHandler — поток событий чата и уведомлений (Server-Sent Events)
+type RealtimeHandler struct {
+	hub         *realtime.Hub
+	authService *services.AuthService
+}
+
+func NewRealtimeHandler(hub *realtime.Hub, authService *services.AuthService) *RealtimeHandler {
+	return &RealtimeHandler{
+		hub:         hub,
+		authService: authService,
+	}
+}
+
+// GET /api/realtime/events - Поток событий пользователя (text/event-stream).
+// При переподключении передайте ID последнего события в since или заголовке Last-Event-ID
+// (EventSource делает это сам) — пропущенные события придут первыми. Событие resync
+// означает, что часть пропущенного потеряна и данные нужно перезагрузить через API.
+// Поток закрывается, когда access-токен истек или сессия отозвана: клиент обновляет
+// токен и переподключается, а после выхода переподключение получает 401.
+func (h *RealtimeHandler) Events(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	userUUID, ok := userID.(uuid.UUID)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	token := c.GetString("access_token")
+
+	since := c.Query("since")
+	if since == "" {
+		since = c.GetHeader("Last-Event-ID")
+	}
+
+	sub, replay, resync := h.hub.Subscribe(userUUID, since)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Отключаем буферизацию в nginx
+	c.Status(http.StatusOK)
+
+	if resync {
+		if !writeRealtimeEvent(c, realtime.Event{Type: realtime.EventResync, CreatedAt: time.Now()}) {
+			return
+		}
+	}
+	for _, event := range replay {
+		if !writeRealtimeEvent(c, event) {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(realtimeHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Клиент не успевал читать; EventSource переподключится с Last-Event-ID
+				return
+			}
+			if !writeRealtimeEvent(c, event) {
+				return
+			}
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			if _, _, err := h.authService.ValidateAccessToken(token); err != nil {
+				return
+			}
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeRealtimeEvent пишет событие в формате SSE; id не задается у событий без курсора (resync)
+func writeRealtimeEvent(c *gin.Context, event realtime.Event) bool {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return false
+	}
+	if event.ID != "" {
+		if _, err := fmt.Fprintf(c.Writer, "id: %s\n", event.ID); err != nil {
+			return false
+		}
+	}
+	_, err = fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event.Type, payload)
+	return err == nil
+}
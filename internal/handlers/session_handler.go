@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"edubot/internal/models"
+	"edubot/internal/services"
+)
+
+// refreshCookieName — httpOnly cookie с refresh-токеном; отправляется только на эндпоинты авторизации
+const (
+	refreshCookieName = "refresh_token"
+	refreshCookiePath = "/api/public/auth"
+)
+
+type SessionHandler struct {
+	authService *services.AuthService
+}
+
+func NewSessionHandler(authService *services.AuthService) *SessionHandler {
+	return &SessionHandler{
+		authService: authService,
+	}
+}
+
+// POST /api/public/auth/refresh - Обменять refresh-токен на новую пару токенов
+// Тело: {"refresh_token": "..."}; без тела используется cookie refresh_token
+func (h *SessionHandler) Refresh(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	_ = c.ShouldBindJSON(&req)
+	if req.RefreshToken == "" {
+		req.RefreshToken, _ = c.Cookie(refreshCookieName)
+	}
+	if req.RefreshToken == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token required"})
+		return
+	}
+
+	tokens, err := h.authService.RefreshSession(req.RefreshToken, clientInfo(c))
+	if err != nil {
+		clearAuthCookies(c)
+		if errors.Is(err, services.ErrInvalidRefreshToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+		return
+	}
+
+	setAuthCookies(c, tokens)
+	c.JSON(http.StatusOK, tokens)
+}
+
+// POST /api/auth/logout - Выйти: отозвать текущую сессию
+func (h *SessionHandler) Logout(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	sessionID := c.MustGet("session_id").(uuid.UUID)
+
+	if err := h.authService.RevokeSession(userID, sessionID, services.SessionRevokedLogout); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+		return
+	}
+
+	clearAuthCookies(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// GET /api/sessions - Активные сессии пользователя
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	currentID := c.MustGet("session_id").(uuid.UUID)
+
+	sessions, err := h.authService.ListSessions(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sessions"})
+		return
+	}
+
+	result := make([]gin.H, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, gin.H{
+			"id":           session.ID,
+			"device":       session.Device,
+			"user_agent":   session.UserAgent,
+			"ip":           session.IP,
+			"created_at":   session.CreatedAt,
+			"last_used_at": session.LastUsedAt,
+			"expires_at":   session.ExpiresAt,
+			"current":      session.ID == currentID,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": result,
+		"total":    len(result),
+	})
+}
+
+// DELETE /api/sessions/:id - Отозвать сессию
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	if err := h.authService.RevokeSession(userID, sessionID, services.SessionRevokedByUser); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, services.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// DELETE /api/sessions - Отозвать все сессии, кроме текущей
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	sessionID := c.MustGet("session_id").(uuid.UUID)
+
+	if err := h.authService.RevokeOtherSessions(userID, sessionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Other sessions revoked"})
+}
+
+// DELETE /api/teacher/students/:id/sessions - Завершить все сессии ученика
+func (h *SessionHandler) RevokeStudentSessions(c *gin.Context) {
+	studentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid student ID"})
+		return
+	}
+
+	if err := h.authService.RevokeStudentSessions(c.MustGet("user").(*models.User), studentID); err != nil {
+		if errors.Is(err, services.ErrStudentNotLinked) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Student sessions revoked"})
+}
+
+// clientInfo собирает сведения об устройстве для новой сессии
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+}
+
+// setAuthCookies ставит cookie jwt (для HTML-страниц) и httpOnly cookie с refresh-токеном
+func setAuthCookies(c *gin.Context, tokens *services.TokenPair) {
+	c.SetCookie("jwt", tokens.AccessToken, cookieMaxAge(tokens.AccessExpiresAt), "/", "", false, true)
+	c.SetCookie(refreshCookieName, tokens.RefreshToken, cookieMaxAge(tokens.RefreshExpiresAt), refreshCookiePath, "", false, true)
+}
+
+func clearAuthCookies(c *gin.Context) {
+	c.SetCookie("jwt", "", -1, "/", "", false, true)
+	c.SetCookie(refreshCookieName, "", -1, refreshCookiePath, "", false, true)
+}
+
+func cookieMaxAge(expiresAt time.Time) int {
+	return int(time.Until(expiresAt).Seconds())
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/google/uuid"
 
 	"edubot/internal/models"
+	"edubot/internal/policy"
 	"edubot/internal/services"
 )
 
@@ -18,6 +20,7 @@ type TeacherInboxHandler struct {
 	submissionService   services.SubmissionService
 	chatService         services.ChatService
 	notificationService services.NotificationService
+	policy              policy.Policy
 }
 
 func NewTeacherInboxHandler(
@@ -26,6 +29,7 @@ func NewTeacherInboxHandler(
 	submissionService services.SubmissionService,
 	chatService services.ChatService,
 	notificationService services.NotificationService,
+	pol policy.Policy,
 ) *TeacherInboxHandler {
 	return &TeacherInboxHandler{
 		gradingService:      gradingService,
@@ -33,6 +37,7 @@ func NewTeacherInboxHandler(
 		submissionService:   submissionService,
 		chatService:         chatService,
 		notificationService: notificationService,
+		policy:              pol,
 	}
 }
 
@@ -73,20 +78,8 @@ func (h *TeacherInboxHandler) GetInbox(c *gin.Context) {
 	case "graded":
 		targets, err = h.gradingService.GetGradedAssignments(teacherID)
 	default:
-		// Получаем все задания учителя
-		assignments, err := h.assignmentService.ListAssignmentsByTeacher(teacherID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get assignments"})
-			return
-		}
-
-		// Получаем все таргеты для этих заданий
-		for _, assignment := range assignments {
-			assignmentTargets, err := h.assignmentService.GetAssignmentTargetsByAssignment(assignment.ID)
-			if err == nil {
-				targets = append(targets, assignmentTargets...)
-			}
-		}
+		// Все таргеты заданий учителя для его учеников
+		targets, err = h.gradingService.GetTeacherTargets(teacherID)
 	}
 
 	if err != nil {
@@ -97,9 +90,10 @@ func (h *TeacherInboxHandler) GetInbox(c *gin.Context) {
 	// Применяем пагинацию
 	start := offset
 	end := offset + limit
-	if start >= len(targets) {
-		targets = []*models.AssignmentTarget{}
-	} else if end > len(targets) {
+	if start > len(targets) {
+		start = len(targets)
+	}
+	if end > len(targets) {
 		end = len(targets)
 	}
 
@@ -114,18 +108,9 @@ func (h *TeacherInboxHandler) GetInbox(c *gin.Context) {
 }
 
 // GET /api/teacher/inbox/:id - Получить детали задания для оценки
+// (также GET /api/assistant/inbox/:id)
 func (h *TeacherInboxHandler) GetAssignmentForGrading(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
-		return
-	}
-
-	teacherID, ok := userID.(uuid.UUID)
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	user := c.MustGet("user").(*models.User)
 
 	targetIDStr := c.Param("id")
 	targetID, err := uuid.Parse(targetIDStr)
@@ -141,8 +126,9 @@ func (h *TeacherInboxHandler) GetAssignmentForGrading(c *gin.Context) {
 		return
 	}
 
-	// Проверяем, что задание принадлежит учителю
-	if target.Assignment.TeacherID != teacherID {
+	// Проверяем права: преподаватель задания или ассистент группы
+	resource := policy.Resource{TeacherID: target.Assignment.TeacherID, GroupID: target.Assignment.GroupID}
+	if !h.policy.Can(user, policy.ActionViewSubmissions, resource) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
@@ -169,19 +155,13 @@ func (h *TeacherInboxHandler) GetAssignmentForGrading(c *gin.Context) {
 }
 
 // POST /api/teacher/inbox/:id/grade - Оценить задание
+// (также POST /api/assistant/inbox/:id/grade)
 func (h *TeacherInboxHandler) GradeAssignment(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	if _, exists := c.Get("user_id"); !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	teacherID, ok := userID.(uuid.UUID)
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
-
 	targetIDStr := c.Param("id")
 	targetID, err := uuid.Parse(targetIDStr)
 	if err != nil {
@@ -201,7 +181,11 @@ func (h *TeacherInboxHandler) GradeAssignment(c *gin.Context) {
 	}
 
 	// Оцениваем задание
-	feedback, err := h.gradingService.GradeAssignment(targetID, teacherID, request.Score, request.Text, request.MediaIDs)
+	feedback, err := h.gradingService.GradeAssignment(targetID, actorFrom(c), request.Score, request.Text, request.MediaIDs)
+	if errors.Is(err, services.ErrAccessDenied) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -380,30 +364,28 @@ func (h *TeacherInboxHandler) GetStatistics(c *gin.Context) {
 		return
 	}
 
+	// Таргеты считаем только для учеников учителя
+	targets, err := h.gradingService.GetTeacherTargets(teacherID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get assignments"})
+		return
+	}
+
 	// Подсчитываем статистику
-	var totalAssignments, pendingGrading, gradedAssignments int
+	totalAssignments := len(assignments)
+	var pendingGrading, gradedAssignments int
 	var totalScore float64
 	var scoreCount int
 
-	for _, assignment := range assignments {
-		totalAssignments++
-
-		// Получаем таргеты для этого задания
-		targets, err := h.assignmentService.GetAssignmentTargetsByAssignment(assignment.ID)
-		if err != nil {
-			continue
-		}
-
-		for _, target := range targets {
-			switch target.Status {
-			case models.AssignmentTargetStatusSubmitted:
-				pendingGrading++
-			case models.AssignmentTargetStatusGraded:
-				gradedAssignments++
-				if target.Score != nil {
-					totalScore += *target.Score
-					scoreCount++
-				}
+	for _, target := range targets {
+		switch target.Status {
+		case models.AssignmentTargetStatusSubmitted:
+			pendingGrading++
+		case models.AssignmentTargetStatusGraded:
+			gradedAssignments++
+			if target.Score != nil {
+				totalScore += *target.Score
+				scoreCount++
 			}
 		}
 	}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccessTokenPrefix — начало каждого персонального токена; по нему middleware отличает его от JWT
+const AccessTokenPrefix = "edu_pat_"
+
+// Области действия персональных токенов
+const (
+	ScopeAssignmentsRead  = "assignments:read"
+	ScopeAssignmentsWrite = "assignments:write"
+	ScopeGradesRead       = "grades:read"
+	ScopeGradesWrite      = "grades:write"
+	ScopeStudentsRead     = "students:read"
+)
+
+// AccessTokenScopes — все допустимые области действия
+var AccessTokenScopes = []string{
+	ScopeAssignmentsRead,
+	ScopeAssignmentsWrite,
+	ScopeGradesRead,
+	ScopeGradesWrite,
+	ScopeStudentsRead,
+}
+
+// AccessToken — именованный персональный токен для скриптов. Сам токен не хранится, только его хэш.
+type AccessToken struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name       string     `json:"name" gorm:"not null"`
+	TokenHash  string     `json:"-" gorm:"not null;uniqueIndex"` // SHA-256 токена
+	Prefix     string     `json:"prefix"`                        // Начало токена, чтобы узнать его в списке
+	Scopes     string     `json:"scopes"`                        // Области через пробел
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"` // nil — бессрочный
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Active сообщает, что токен не отозван и не истек
+func (t *AccessToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
+
+// HasScope сообщает, выдана ли токену область действия
+func (t *AccessToken) HasScope(scope string) bool {
+	for _, s := range strings.Fields(t.Scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditLog — запись журнала изменений: кто, что и над каким объектом сделал
+type AuditLog struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	ActorID    *uuid.UUID `json:"actor_id,omitempty" gorm:"type:uuid;index"` // nil — система
+	OwnerID    *uuid.UUID `json:"owner_id,omitempty" gorm:"type:uuid;index"` // Преподаватель, чьи данные изменены
+	Action     string     `json:"action" gorm:"not null"`
+	EntityType string     `json:"entity_type" gorm:"not null;index:idx_audit_entity"`
+	EntityID   uuid.UUID  `json:"entity_id" gorm:"type:uuid;index:idx_audit_entity"`
+	Before     string     `json:"before,omitempty" gorm:"type:text"` // JSON состояния до изменения
+	After      string     `json:"after,omitempty" gorm:"type:text"`  // JSON состояния после изменения
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"index"`
+
+	// Связи
+	Actor *User `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+}
@@ -50,12 +50,61 @@ type Message struct {
 	AuthorID  uuid.UUID      `json:"author_id" gorm:"type:uuid;not null"`
 	Text      *string        `json:"text,omitempty"`
 	Kind      MessageKind    `json:"kind" gorm:"type:varchar(20);default:'message'"`
+	ReplyToID *uuid.UUID     `json:"reply_to_id,omitempty" gorm:"type:uuid;index"` // Ответ на сообщение того же чата
+	PinnedAt  *time.Time     `json:"pinned_at,omitempty"`
+	PinnedBy  *uuid.UUID     `json:"pinned_by,omitempty" gorm:"type:uuid"`
 	CreatedAt time.Time      `json:"created_at"`
 	EditedAt  *time.Time     `json:"edited_at,omitempty"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 
 	// Связи
-	Thread ChatThread `json:"thread" gorm:"foreignKey:ThreadID"`
-	Author User       `json:"author" gorm:"foreignKey:AuthorID"`
-	Media  []Media    `json:"media" gorm:"many2many:message_media;"`
+	Thread    ChatThread        `json:"thread" gorm:"foreignKey:ThreadID"`
+	Author    User              `json:"author" gorm:"foreignKey:AuthorID"`
+	Media     []Media           `json:"media" gorm:"many2many:message_media;"`
+	ReplyTo   *Message          `json:"reply_to,omitempty" gorm:"foreignKey:ReplyToID"` // Цитата; nil, если исходное сообщение удалено
+	Reactions []MessageReaction `json:"reactions" gorm:"foreignKey:MessageID"`
+}
+
+// MessageReactionEmojis — допустимые реакции на сообщения
+var MessageReactionEmojis = []string{"👍", "👎", "❤️", "🔥", "😂", "😮", "😢", "🙏", "✅", "❓"}
+
+// IsReactionEmoji проверяет, что реакция из допустимого набора
+func IsReactionEmoji(emoji string) bool {
+	for _, allowed := range MessageReactionEmojis {
+		if allowed == emoji {
+			return true
+		}
+	}
+	return false
+}
+
+// MessageReaction — реакция пользователя на сообщение; одну эмодзи можно поставить один раз
+type MessageReaction struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	MessageID uuid.UUID `json:"message_id" gorm:"type:uuid;not null;uniqueIndex:idx_message_reaction"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_message_reaction"`
+	Emoji     string    `json:"emoji" gorm:"type:varchar(16);not null;uniqueIndex:idx_message_reaction"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChatReadCursor — до какого сообщения пользователь прочитал чат
+type ChatReadCursor struct {
+	ID                uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	ThreadID          uuid.UUID  `json:"thread_id" gorm:"type:uuid;not null;uniqueIndex:idx_chat_read_cursor"`
+	UserID            uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_chat_read_cursor"`
+	LastReadMessageID *uuid.UUID `json:"last_read_message_id,omitempty" gorm:"type:uuid"`
+	LastReadAt        time.Time  `json:"last_read_at"` // Время создания последнего прочитанного сообщения
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// TelegramMessageLink связывает сообщение бота с чатом приложения:
+// ответ (reply) на это сообщение в Telegram публикуется в чат
+type TelegramMessageLink struct {
+	ID                uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	ChatID            int64      `json:"chat_id" gorm:"not null;uniqueIndex:idx_telegram_message_link"`
+	TelegramMessageID int        `json:"telegram_message_id" gorm:"not null;uniqueIndex:idx_telegram_message_link"`
+	UserID            uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"` // Получатель; отвечать может только он
+	ThreadID          uuid.UUID  `json:"thread_id" gorm:"type:uuid;not null;index"`
+	MessageID         *uuid.UUID `json:"message_id,omitempty" gorm:"type:uuid"` // Сообщение чата, о котором уведомили
+	CreatedAt         time.Time  `json:"created_at"`
 }
@@ -24,6 +24,12 @@ type Group struct {
 	Members []GroupMember `json:"members" gorm:"foreignKey:GroupID"`
 }
 
+// Роли участника группы
+const (
+	GroupRoleStudent   = "student"
+	GroupRoleAssistant = "assistant" // Помощник преподавателя: проверяет задания и ведет чат группы
+)
+
 // GroupMember представляет участника группы
 type GroupMember struct {
 	ID        uuid.UUID      `json:"id" gorm:"type:text;primaryKey"`
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invite — код приглашения ученика от преподавателя. Код можно использовать несколько раз
+// (MaxUses), он может истекать и сразу добавлять ученика в группу с заданным классом и предметами.
+type Invite struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	Code      string     `json:"code" gorm:"uniqueIndex;not null"`
+	TeacherID uuid.UUID  `json:"teacher_id" gorm:"type:uuid;not null;index"`
+	GroupID   *uuid.UUID `json:"group_id,omitempty" gorm:"type:uuid"`   // Группа, в которую добавляется ученик
+	StudentID *uuid.UUID `json:"student_id,omitempty" gorm:"type:uuid"` // Персональный код: активировать может только этот пользователь
+	Grade     int        `json:"grade"`                                 // Класс ученика (0 — не менять)
+	Subjects  string     `json:"subjects"`                              // JSON массив предметов (пусто — не менять)
+	MaxUses   int        `json:"max_uses"`                              // 0 — без ограничения
+	Uses      int        `json:"uses" gorm:"default:0"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // nil — бессрочный
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// Связи
+	Teacher User   `json:"teacher" gorm:"foreignKey:TeacherID"`
+	Group   *Group `json:"group,omitempty" gorm:"foreignKey:GroupID"`
+}
+
+// Usable сообщает, можно ли еще воспользоваться кодом
+func (i *Invite) Usable(now time.Time) bool {
+	if i.RevokedAt != nil {
+		return false
+	}
+	if i.ExpiresAt != nil && !now.Before(*i.ExpiresAt) {
+		return false
+	}
+	return i.MaxUses == 0 || i.Uses < i.MaxUses
+}
+
+// InviteRedemption — факт использования кода пользователем; повторно один и тот же код не засчитывается
+type InviteRedemption struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	InviteID  uuid.UUID `json:"invite_id" gorm:"type:uuid;not null;uniqueIndex:idx_invite_user"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_invite_user"`
+	CreatedAt time.Time `json:"created_at"`
+}
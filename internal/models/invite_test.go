@@ -0,0 +1,33 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInviteUsable(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	tests := []struct {
+		name   string
+		invite Invite
+		want   bool
+	}{
+		{"unlimited", Invite{Uses: 100}, true},
+		{"uses left", Invite{MaxUses: 3, Uses: 2}, true},
+		{"exhausted", Invite{MaxUses: 3, Uses: 3}, false},
+		{"over limit", Invite{MaxUses: 1, Uses: 2}, false},
+		{"not expired", Invite{ExpiresAt: &future}, true},
+		{"expired", Invite{ExpiresAt: &past}, false},
+		{"expires now", Invite{ExpiresAt: &now}, false},
+		{"revoked", Invite{RevokedAt: &past}, false},
+		{"revoked with uses left", Invite{MaxUses: 5, RevokedAt: &past, ExpiresAt: &future}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.invite.Usable(now); got != tt.want {
+			t.Errorf("%s: Usable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
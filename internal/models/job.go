@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobRunStatus определяет статусы запуска фоновой задачи
+type JobRunStatus string
+
+const (
+	JobRunStatusRunning JobRunStatus = "running"
+	JobRunStatusSuccess JobRunStatus = "success"
+	JobRunStatusFailed  JobRunStatus = "failed"
+)
+
+// JobRun представляет запись истории запуска фоновой задачи
+type JobRun struct {
+	ID         uuid.UUID    `json:"id" gorm:"type:uuid;primaryKey"`
+	JobName    string       `json:"job_name" gorm:"type:varchar(100);not null;index"`
+	Instance   string       `json:"instance" gorm:"type:varchar(255)"`
+	Status     JobRunStatus `json:"status" gorm:"type:varchar(10);not null"`
+	StartedAt  time.Time    `json:"started_at" gorm:"not null;index"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+	Error      string       `json:"error,omitempty" gorm:"type:text"`
+}
+
+// JobLock представляет блокировку задачи, чтобы несколько инстансов не выполняли её одновременно
+type JobLock struct {
+	JobName     string    `json:"job_name" gorm:"type:varchar(100);primaryKey"`
+	LockedBy    string    `json:"locked_by" gorm:"type:varchar(255);not null"`
+	LockedUntil time.Time `json:"locked_until" gorm:"not null"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
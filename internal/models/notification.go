@@ -35,26 +35,80 @@ const (
 	NotificationStatusPending NotificationStatus = "pending"
 	NotificationStatusSent    NotificationStatus = "sent"
 	NotificationStatusRead    NotificationStatus = "read"
+	NotificationStatusBounced NotificationStatus = "bounced" // Адрес отклонил письмо, повторять бессмысленно
+	NotificationStatusFailed  NotificationStatus = "failed"  // Исчерпаны попытки доставки
 )
 
 // Notification представляет уведомление пользователю
 type Notification struct {
+	ID            uuid.UUID           `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID        uuid.UUID           `json:"user_id" gorm:"type:uuid;not null"`
+	Type          NotificationType    `json:"type" gorm:"type:varchar(30);not null"`
+	Title         string              `json:"title" gorm:"not null"`
+	Message       string              `json:"message" gorm:"not null"`
+	Payload       string              `json:"payload" gorm:"type:text"` // JSON с дополнительными данными
+	Channel       NotificationChannel `json:"channel" gorm:"type:varchar(10);not null"`
+	Status        NotificationStatus  `json:"status" gorm:"type:varchar(10);default:'pending'"`
+	Attempts      int                 `json:"attempts" gorm:"default:0"`              // Неудачных попыток доставки
+	NextAttemptAt *time.Time          `json:"next_attempt_at,omitempty" gorm:"index"` // Не отправлять раньше (тихие часы, повтор)
+	LastError     string              `json:"last_error,omitempty" gorm:"type:text"`
+	SentAt        *time.Time          `json:"sent_at,omitempty"`
+	ReadAt        *time.Time          `json:"read_at,omitempty"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt      `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Связи
+	User User `json:"user" gorm:"foreignKey:UserID"`
+}
+
+// NotificationPreference включает или выключает канал доставки для типа уведомлений.
+// Если записи нет, действует значение по умолчанию (см. DefaultChannelEnabled).
+type NotificationPreference struct {
 	ID        uuid.UUID           `json:"id" gorm:"type:uuid;primaryKey"`
-	UserID    uuid.UUID           `json:"user_id" gorm:"type:uuid;not null"`
-	Type      NotificationType    `json:"type" gorm:"type:varchar(30);not null"`
-	Title     string              `json:"title" gorm:"not null"`
-	Message   string              `json:"message" gorm:"not null"`
-	Payload   string              `json:"payload" gorm:"type:text"` // JSON с дополнительными данными
-	Channel   NotificationChannel `json:"channel" gorm:"type:varchar(10);not null"`
-	Status    NotificationStatus  `json:"status" gorm:"type:varchar(10);default:'pending'"`
-	SentAt    *time.Time          `json:"sent_at,omitempty"`
-	ReadAt    *time.Time          `json:"read_at,omitempty"`
+	UserID    uuid.UUID           `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_notification_pref"`
+	Type      NotificationType    `json:"type" gorm:"type:varchar(30);not null;uniqueIndex:idx_notification_pref"`
+	Channel   NotificationChannel `json:"channel" gorm:"type:varchar(10);not null;uniqueIndex:idx_notification_pref"`
+	Enabled   bool                `json:"enabled"`
 	CreatedAt time.Time           `json:"created_at"`
 	UpdatedAt time.Time           `json:"updated_at"`
-	DeletedAt gorm.DeletedAt      `json:"deleted_at,omitempty" gorm:"index"`
+}
 
-	// Связи
-	User User `json:"user" gorm:"foreignKey:UserID"`
+// NotificationSettings хранит общие настройки уведомлений пользователя
+type NotificationSettings struct {
+	UserID            uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey"`
+	QuietHoursEnabled bool      `json:"quiet_hours_enabled"`
+	QuietHoursStart   string    `json:"quiet_hours_start" gorm:"type:varchar(5);default:'22:00'"` // HH:MM в часовом поясе пользователя
+	QuietHoursEnd     string    `json:"quiet_hours_end" gorm:"type:varchar(5);default:'08:00'"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// NotificationTypes — все типы уведомлений, для которых можно настроить каналы
+var NotificationTypes = []NotificationType{
+	NotificationTypeNewAssignment,
+	NotificationTypeDeadlineReminder,
+	NotificationTypeOverdue,
+	NotificationTypeGradeReceived,
+	NotificationTypeNewMessage,
+	NotificationTypeGroupInvite,
+}
+
+// NotificationChannels — все каналы доставки
+var NotificationChannels = []NotificationChannel{
+	NotificationChannelBot,
+	NotificationChannelInApp,
+	NotificationChannelEmail,
+}
+
+// DefaultChannelEnabled возвращает настройку канала, если пользователь её не менял
+func DefaultChannelEnabled(channel NotificationChannel) bool {
+	return channel == NotificationChannelBot || channel == NotificationChannelInApp
+}
+
+// IsPush сообщает, доставляется ли канал вне приложения (на него действуют тихие часы)
+func (c NotificationChannel) IsPush() bool {
+	return c == NotificationChannelBot || c == NotificationChannelEmail
 }
 
 // Draft представляет черновик отправки ДЗ
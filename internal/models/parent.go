@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ParentStudent связывает родителя с ребенком-учеником. У ученика может быть
+// несколько родителей, у родителя — несколько детей.
+type ParentStudent struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	ParentID  uuid.UUID `json:"parent_id" gorm:"type:uuid;not null;uniqueIndex:idx_parent_student"`
+	StudentID uuid.UUID `json:"student_id" gorm:"type:uuid;not null;uniqueIndex:idx_parent_student;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ParentInvite — одноразовый код, который преподаватель выдает родителю ученика
+type ParentInvite struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	Code      string     `json:"code" gorm:"uniqueIndex;not null"`
+	StudentID uuid.UUID  `json:"student_id" gorm:"type:uuid;not null;index"`
+	CreatedBy uuid.UUID  `json:"created_by" gorm:"type:uuid;not null"` // Преподаватель
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedBy    *uuid.UUID `json:"used_by,omitempty" gorm:"type:uuid"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	// Связи
+	Student User `json:"student" gorm:"foreignKey:StudentID"`
+}
+
+// Usable сообщает, можно ли еще воспользоваться кодом
+func (i *ParentInvite) Usable(now time.Time) bool {
+	return i.UsedAt == nil && now.Before(i.ExpiresAt)
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RoleChange — запись истории смены роли пользователя
+type RoleChange struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	OldRole   UserRole   `json:"old_role"`
+	NewRole   UserRole   `json:"new_role" gorm:"not null"`
+	ChangedBy *uuid.UUID `json:"changed_by,omitempty" gorm:"type:uuid"` // nil — система (начальная настройка из env)
+	Reason    string     `json:"reason"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+
+	// Связи
+	User    User  `json:"user" gorm:"foreignKey:UserID"`
+	Changer *User `json:"changer,omitempty" gorm:"foreignKey:ChangedBy"`
+}
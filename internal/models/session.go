@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session представляет сессию входа: refresh-токен и устройство, с которого выполнен вход
+type Session struct {
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID           uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	RefreshTokenHash string     `json:"-" gorm:"not null"` // SHA-256 секрета текущего refresh-токена
+	UserAgent        string     `json:"user_agent"`
+	Device           string     `json:"device"` // Краткое описание устройства по User-Agent
+	IP               string     `json:"ip"`
+	CreatedAt        time.Time  `json:"created_at"`
+	LastUsedAt       time.Time  `json:"last_used_at"`
+	ExpiresAt        time.Time  `json:"expires_at" gorm:"index"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason    string     `json:"revoked_reason,omitempty"`
+}
+
+// Active сообщает, что сессия не отозвана и не истекла
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
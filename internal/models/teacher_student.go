@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TeacherStudent связывает преподавателя и ученика. Ученик может заниматься
+// у нескольких преподавателей (например, физика и математика у разных репетиторов).
+type TeacherStudent struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	TeacherID uuid.UUID `json:"teacher_id" gorm:"type:uuid;not null;uniqueIndex:idx_teacher_student"`
+	StudentID uuid.UUID `json:"student_id" gorm:"type:uuid;not null;uniqueIndex:idx_teacher_student;index"`
+	Subject   string    `json:"subject"` // Предмет, по которому занимаются у этого преподавателя
+	CreatedAt time.Time `json:"created_at"`
+}
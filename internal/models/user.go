@@ -14,6 +14,8 @@ const (
 	RoleGuest   UserRole = "guest"
 	RoleStudent UserRole = "student"
 	RoleTeacher UserRole = "teacher"
+	RoleParent  UserRole = "parent" // Родитель: только просмотр успеваемости своих детей
+	RoleAdmin   UserRole = "admin"  // Администратор: управляет преподавателями
 )
 
 // User представляет пользователя системы
@@ -32,6 +34,35 @@ type User struct {
 	CreatedAt  time.Time      `json:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Email для уведомлений; используется только после подтверждения
+	Email                  string     `json:"email"`
+	EmailVerified          bool       `json:"email_verified" gorm:"default:false"`
+	EmailVerificationToken string     `json:"-" gorm:"index"` // SHA-256 токена из письма
+	EmailVerificationSent  *time.Time `json:"-"`
+
+	// Бот не может писать пользователю (заблокировал бота или не начинал чат);
+	// сбрасывается, когда пользователь снова нажимает /start
+	BotUnreachable       bool       `json:"bot_unreachable" gorm:"default:false"`
+	BotUnreachableReason string     `json:"bot_unreachable_reason,omitempty"`
+	BotUnreachableAt     *time.Time `json:"bot_unreachable_at,omitempty"`
+}
+
+// Location возвращает часовой пояс пользователя (UTC, если не задан или неизвестен)
+func (u *User) Location() *time.Location {
+	if u.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// FormatTime форматирует время для показа пользователю в его часовом поясе
+func (u *User) FormatTime(t time.Time) string {
+	return t.In(u.Location()).Format("02.01.2006 15:04 MST")
 }
 
 // TrialRequest представляет заявку на пробное занятие
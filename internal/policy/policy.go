@@ -0,0 +1,79 @@
+package policy
+
+import (
+	"log"
+
+	"github.com/google/uuid"
+
+	"edubot/internal/models"
+)
+
+// Action — действие, на которое проверяются права
+type Action string
+
+const (
+	ActionViewSubmissions  Action = "view_submissions" // Смотреть сданные работы
+	ActionGrade            Action = "grade"            // Оценивать работы
+	ActionChat             Action = "chat"             // Читать и писать в чат
+	ActionEditAssignment   Action = "edit_assignment"  // Создавать и менять задания
+	ActionDeleteAssignment Action = "delete_assignment"
+	ActionManageMembers    Action = "manage_members" // Менять состав группы
+)
+
+// Resource описывает объект проверки: чей он и к какой группе относится
+type Resource struct {
+	TeacherID uuid.UUID  // Преподаватель-владелец (задания, группы, чата)
+	GroupID   *uuid.UUID // Группа, если объект групповой
+}
+
+// GroupRoles возвращает роль пользователя в группе; пустая строка — не участник
+type GroupRoles interface {
+	GetMemberRole(groupID, userID uuid.UUID) (string, error)
+}
+
+// Policy решает, может ли пользователь выполнить действие над объектом
+type Policy interface {
+	Can(user *models.User, action Action, resource Resource) bool
+}
+
+// Действия, доступные участникам группы в зависимости от их роли в ней
+var groupRoleActions = map[string]map[Action]bool{
+	models.GroupRoleAssistant: {
+		ActionViewSubmissions: true,
+		ActionGrade:           true,
+		ActionChat:            true,
+	},
+	models.GroupRoleStudent: {
+		ActionChat: true,
+	},
+}
+
+type policy struct {
+	groups GroupRoles
+}
+
+// New создает политику доступа на основе ролей в группах
+func New(groups GroupRoles) Policy {
+	return &policy{groups: groups}
+}
+
+// Can: преподаватель-владелец может всё; участник группы — только то,
+// что разрешено его роли в группе. Остальным доступ закрыт.
+func (p *policy) Can(user *models.User, action Action, resource Resource) bool {
+	if user == nil {
+		return false
+	}
+	if user.Role == models.RoleTeacher && resource.TeacherID == user.ID {
+		return true
+	}
+	if resource.GroupID == nil {
+		return false
+	}
+
+	role, err := p.groups.GetMemberRole(*resource.GroupID, user.ID)
+	if err != nil {
+		log.Printf("Failed to get group role of user %s in group %s: %v", user.ID, *resource.GroupID, err)
+		return false
+	}
+	return groupRoleActions[role][action]
+}
@@ -0,0 +1,95 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"edubot/internal/models"
+)
+
+// memberRoles — роли участников групп в памяти
+type memberRoles struct {
+	roles map[uuid.UUID]map[uuid.UUID]string
+	err   error
+}
+
+func (m *memberRoles) GetMemberRole(groupID, userID uuid.UUID) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	return m.roles[groupID][userID], nil
+}
+
+func TestCan(t *testing.T) {
+	teacher := &models.User{ID: uuid.New(), Role: models.RoleTeacher}
+	otherTeacher := &models.User{ID: uuid.New(), Role: models.RoleTeacher}
+	assistant := &models.User{ID: uuid.New(), Role: models.RoleStudent}
+	student := &models.User{ID: uuid.New(), Role: models.RoleStudent}
+	outsider := &models.User{ID: uuid.New(), Role: models.RoleStudent}
+	// Владелец с ролью, отличной от teacher (например, после понижения), прав владельца не имеет
+	demoted := &models.User{ID: teacher.ID, Role: models.RoleGuest}
+
+	groupID := uuid.New()
+	p := New(&memberRoles{roles: map[uuid.UUID]map[uuid.UUID]string{
+		groupID: {
+			assistant.ID:    models.GroupRoleAssistant,
+			student.ID:      models.GroupRoleStudent,
+			otherTeacher.ID: models.GroupRoleStudent,
+		},
+	}})
+
+	own := Resource{TeacherID: teacher.ID}
+	group := Resource{TeacherID: teacher.ID, GroupID: &groupID}
+
+	tests := []struct {
+		name     string
+		user     *models.User
+		action   Action
+		resource Resource
+		want     bool
+	}{
+		{"owner edits", teacher, ActionEditAssignment, own, true},
+		{"owner deletes in group", teacher, ActionDeleteAssignment, group, true},
+		{"other teacher without group", otherTeacher, ActionViewSubmissions, own, false},
+		{"other teacher as group student", otherTeacher, ActionGrade, group, false},
+		{"demoted owner", demoted, ActionEditAssignment, own, false},
+		{"nil user", nil, ActionChat, group, false},
+
+		{"assistant views", assistant, ActionViewSubmissions, group, true},
+		{"assistant grades", assistant, ActionGrade, group, true},
+		{"assistant chats", assistant, ActionChat, group, true},
+		{"assistant cannot edit", assistant, ActionEditAssignment, group, false},
+		{"assistant cannot delete", assistant, ActionDeleteAssignment, group, false},
+		{"assistant cannot manage", assistant, ActionManageMembers, group, false},
+		{"assistant outside group", assistant, ActionGrade, own, false},
+
+		{"student chats", student, ActionChat, group, true},
+		{"student cannot grade", student, ActionGrade, group, false},
+		{"student cannot view", student, ActionViewSubmissions, group, false},
+
+		{"outsider", outsider, ActionChat, group, false},
+	}
+	for _, tt := range tests {
+		if got := p.Can(tt.user, tt.action, tt.resource); got != tt.want {
+			t.Errorf("%s: Can(%s) = %v, want %v", tt.name, tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestCanDeniesOnLookupError(t *testing.T) {
+	groupID := uuid.New()
+	assistant := &models.User{ID: uuid.New(), Role: models.RoleStudent}
+	p := New(&memberRoles{err: errors.New("db is down")})
+
+	if p.Can(assistant, ActionChat, Resource{TeacherID: uuid.New(), GroupID: &groupID}) {
+		t.Error("expected access denied when group role lookup fails")
+	}
+
+	// Владельцу роль в группе не нужна
+	teacher := &models.User{ID: uuid.New(), Role: models.RoleTeacher}
+	if !p.Can(teacher, ActionManageMembers, Resource{TeacherID: teacher.ID, GroupID: &groupID}) {
+		t.Error("owner must not depend on group role lookup")
+	}
+}
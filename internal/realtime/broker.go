@@ -0,0 +1,46 @@
+package realtime
+
+import "sync"
+
+// Broker доставляет события всем экземплярам сервера. Память процесса подходит
+// для одного экземпляра, при нескольких нужен общий брокер (PostgresBroker).
+type Broker interface {
+	Publish(envelope Envelope) error
+	// Subscribe вызывает handler для каждого события, включая опубликованные этим же экземпляром
+	Subscribe(handler func(Envelope)) error
+	Close() error
+}
+
+// MemoryBroker — брокер в памяти процесса
+type MemoryBroker struct {
+	mu       sync.RWMutex
+	handlers []func(Envelope)
+}
+
+// NewMemoryBroker создает брокер в памяти
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{}
+}
+
+// Publish реализует Broker
+func (b *MemoryBroker) Publish(envelope Envelope) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, handler := range b.handlers {
+		handler(envelope)
+	}
+	return nil
+}
+
+// Subscribe реализует Broker
+func (b *MemoryBroker) Subscribe(handler func(Envelope)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+	return nil
+}
+
+// Close реализует Broker
+func (b *MemoryBroker) Close() error {
+	return nil
+}
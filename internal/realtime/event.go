@@ -0,0 +1,49 @@
+package realtime
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Типы событий
+const (
+	EventMessageCreated      = "message.created"
+	EventMessageUpdated      = "message.updated"
+	EventMessageDeleted      = "message.deleted"
+	EventNotificationCreated = "notification.created"
+	EventTyping              = "typing"
+	// EventResync — пропущенные события уже не восстановить, клиент должен перезагрузить данные
+	EventResync = "resync"
+)
+
+// Event — событие для клиента. ID служит курсором для переподключения:
+// строки ID упорядочены по времени создания.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Truncated bool            `json:"truncated,omitempty"` // Данные не поместились в брокер, их нужно запросить через API
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Envelope — событие с получателями, в таком виде оно проходит через брокер
+type Envelope struct {
+	Recipients []uuid.UUID `json:"recipients"`
+	Ephemeral  bool        `json:"ephemeral,omitempty"` // Не сохраняется для переподключения (например, «печатает»)
+	Event      Event       `json:"event"`
+}
+
+// newEventID — время в наносекундах фиксированной ширины и случайный суффикс,
+// чтобы ID с разных экземпляров сервера не совпадали
+func newEventID(now time.Time) string {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Sprintf("%019d-00000000", now.UnixNano())
+	}
+	return fmt.Sprintf("%019d-%s", now.UnixNano(), hex.EncodeToString(suffix))
+}
@@ -0,0 +1,215 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// subscriptionBuffer — сколько событий ждут отправки клиенту; медленный клиент отключается
+	subscriptionBuffer = 64
+	// historySize и historyTTL — сколько последних событий пользователя хранится для переподключения
+	historySize   = 200
+	historyTTL    = 10 * time.Minute
+	sweepInterval = time.Minute
+)
+
+// Publisher отправляет события пользователям; его используют сервисы
+type Publisher interface {
+	Publish(userIDs []uuid.UUID, eventType string, data interface{})
+	// PublishEphemeral — событие только для подключенных сейчас клиентов, без истории
+	PublishEphemeral(userIDs []uuid.UUID, eventType string, data interface{})
+}
+
+// Subscription — подключение одного клиента
+type Subscription struct {
+	UserID uuid.UUID
+	events chan Event
+	hub    *Hub
+}
+
+// Events возвращает канал событий; канал закрывается, если клиент не успевает читать
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close отключает подписку
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.removeLocked(s)
+}
+
+// userHistory — последние события пользователя
+type userHistory struct {
+	events []Event
+	// truncatedUpTo — ID последнего события, вытесненного по размеру истории
+	truncatedUpTo string
+}
+
+// Hub хранит подписки пользователей этого экземпляра и раздает им события из брокера
+type Hub struct {
+	broker    Broker
+	startedAt time.Time
+
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[*Subscription]struct{}
+	history     map[uuid.UUID]*userHistory
+	lastSweep   time.Time
+}
+
+// NewHub создает хаб и подписывает его на брокер
+func NewHub(broker Broker) (*Hub, error) {
+	h := &Hub{
+		broker:      broker,
+		startedAt:   time.Now(),
+		subscribers: make(map[uuid.UUID]map[*Subscription]struct{}),
+		history:     make(map[uuid.UUID]*userHistory),
+	}
+	if err := broker.Subscribe(h.deliver); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Publish реализует Publisher
+func (h *Hub) Publish(userIDs []uuid.UUID, eventType string, data interface{}) {
+	h.publish(userIDs, eventType, data, false)
+}
+
+// PublishEphemeral реализует Publisher
+func (h *Hub) PublishEphemeral(userIDs []uuid.UUID, eventType string, data interface{}) {
+	h.publish(userIDs, eventType, data, true)
+}
+
+func (h *Hub) publish(userIDs []uuid.UUID, eventType string, data interface{}, ephemeral bool) {
+	if len(userIDs) == 0 {
+		return
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Failed to encode realtime event %s: %v", eventType, err)
+		return
+	}
+	now := time.Now()
+	envelope := Envelope{
+		Recipients: userIDs,
+		Ephemeral:  ephemeral,
+		Event:      Event{ID: newEventID(now), Type: eventType, Data: raw, CreatedAt: now},
+	}
+	if err := h.broker.Publish(envelope); err != nil {
+		log.Printf("Failed to publish realtime event %s: %v", eventType, err)
+	}
+}
+
+// Subscribe подключает клиента. since — ID последнего полученного события:
+// пропущенные после него события возвращаются в replay. resync = true, если часть
+// пропущенного уже не восстановить и клиенту нужно перезагрузить данные через API.
+func (h *Hub) Subscribe(userID uuid.UUID, since string) (sub *Subscription, replay []Event, resync bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub = &Subscription{UserID: userID, events: make(chan Event, subscriptionBuffer), hub: h}
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[userID][sub] = struct{}{}
+
+	if since == "" {
+		return sub, nil, false
+	}
+
+	// Раньше горизонта история неполная: события до запуска экземпляра или старше historyTTL
+	horizon := h.startedAt
+	if expired := time.Now().Add(-historyTTL); expired.After(horizon) {
+		horizon = expired
+	}
+	history := h.history[userID]
+	resync = since < cursorAt(horizon) || (history != nil && since < history.truncatedUpTo)
+
+	if history != nil {
+		for _, event := range history.events {
+			if event.ID > since && event.CreatedAt.After(horizon) {
+				replay = append(replay, event)
+			}
+		}
+	}
+	return sub, replay, resync
+}
+
+// deliver получает событие из брокера и раздает его подписчикам этого экземпляра
+func (h *Hub) deliver(envelope Envelope) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !envelope.Ephemeral {
+		h.sweepLocked(time.Now())
+	}
+	for _, userID := range envelope.Recipients {
+		if !envelope.Ephemeral {
+			h.rememberLocked(userID, envelope.Event)
+		}
+		for sub := range h.subscribers[userID] {
+			select {
+			case sub.events <- envelope.Event:
+			default:
+				// Клиент не успевает читать: отключаем, он переподключится с курсором
+				h.removeLocked(sub)
+			}
+		}
+	}
+}
+
+func (h *Hub) rememberLocked(userID uuid.UUID, event Event) {
+	history := h.history[userID]
+	if history == nil {
+		history = &userHistory{}
+		h.history[userID] = history
+	}
+	history.events = append(history.events, event)
+	if extra := len(history.events) - historySize; extra > 0 {
+		history.truncatedUpTo = history.events[extra-1].ID
+		history.events = append([]Event(nil), history.events[extra:]...)
+	}
+}
+
+// sweepLocked раз в sweepInterval удаляет события старше historyTTL
+func (h *Hub) sweepLocked(now time.Time) {
+	if now.Sub(h.lastSweep) < sweepInterval {
+		return
+	}
+	h.lastSweep = now
+	expired := now.Add(-historyTTL)
+	for userID, history := range h.history {
+		keep := 0
+		for keep < len(history.events) && !history.events[keep].CreatedAt.After(expired) {
+			keep++
+		}
+		history.events = history.events[keep:]
+		if len(history.events) == 0 {
+			delete(h.history, userID)
+		}
+	}
+}
+
+func (h *Hub) removeLocked(sub *Subscription) {
+	subs := h.subscribers[sub.UserID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subscribers, sub.UserID)
+	}
+	close(sub.events)
+}
+
+// cursorAt — курсор, с которым сравниваются ID событий, созданных после t
+func cursorAt(t time.Time) string {
+	return fmt.Sprintf("%019d", t.UnixNano())
+}
@@ -0,0 +1,134 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	// postgresChannel — канал LISTEN/NOTIFY
+	postgresChannel = "edubot_realtime"
+	// maxNotifyPayload — NOTIFY принимает до 8000 байт, оставляем запас
+	maxNotifyPayload = 7500
+	reconnectDelay   = 5 * time.Second
+)
+
+// PostgresBroker раздает события между экземплярами через LISTEN/NOTIFY.
+// Публикация идет через общий пул gorm, прослушивание — через отдельное соединение.
+type PostgresBroker struct {
+	db     *gorm.DB
+	dsn    string
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.RWMutex
+	handlers []func(Envelope)
+	started  bool
+}
+
+// NewPostgresBroker создает брокер; dsn — строка подключения для прослушивания
+func NewPostgresBroker(db *gorm.DB, dsn string) *PostgresBroker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &PostgresBroker{db: db, dsn: dsn, ctx: ctx, cancel: cancel}
+}
+
+// Publish реализует Broker. Слишком большое событие уходит без данных с пометкой truncated.
+func (b *PostgresBroker) Publish(envelope Envelope) error {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxNotifyPayload {
+		envelope.Event.Data = nil
+		envelope.Event.Truncated = true
+		if payload, err = json.Marshal(envelope); err != nil {
+			return err
+		}
+	}
+	return b.db.Exec("SELECT pg_notify(?, ?)", postgresChannel, string(payload)).Error
+}
+
+// Subscribe реализует Broker; первое подключение запускает прослушивание
+func (b *PostgresBroker) Subscribe(handler func(Envelope)) error {
+	b.mu.Lock()
+	b.handlers = append(b.handlers, handler)
+	start := !b.started
+	b.started = true
+	b.mu.Unlock()
+
+	if !start {
+		return nil
+	}
+	conn, err := b.listen()
+	if err != nil {
+		return err
+	}
+	go b.run(conn)
+	return nil
+}
+
+// Close останавливает прослушивание
+func (b *PostgresBroker) Close() error {
+	b.cancel()
+	return nil
+}
+
+func (b *PostgresBroker) listen() (*pgx.Conn, error) {
+	conn, err := pgx.Connect(b.ctx, b.dsn)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Exec(b.ctx, "LISTEN "+postgresChannel); err != nil {
+		conn.Close(context.Background())
+		return nil, err
+	}
+	return conn, nil
+}
+
+// run читает уведомления и переподключается при обрыве соединения.
+// События, отправленные во время обрыва, до этого экземпляра не дойдут.
+func (b *PostgresBroker) run(conn *pgx.Conn) {
+	for {
+		notification, err := conn.WaitForNotification(b.ctx)
+		if err == nil {
+			b.dispatch(notification.Payload)
+			continue
+		}
+
+		conn.Close(context.Background())
+		if b.ctx.Err() != nil {
+			return
+		}
+		log.Printf("Realtime broker connection lost: %v", err)
+		for {
+			select {
+			case <-b.ctx.Done():
+				return
+			case <-time.After(reconnectDelay):
+			}
+			if conn, err = b.listen(); err == nil {
+				break
+			}
+			log.Printf("Realtime broker reconnect failed: %v", err)
+		}
+	}
+}
+
+func (b *PostgresBroker) dispatch(payload string) {
+	var envelope Envelope
+	if err := json.Unmarshal([]byte(payload), &envelope); err != nil {
+		log.Printf("Realtime broker: invalid payload: %v", err)
+		return
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, handler := range b.handlers {
+		handler(envelope)
+	}
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"time"
+
+	"edubot/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AccessTokenRepository интерфейс для работы с персональными токенами
+type AccessTokenRepository interface {
+	Create(token *models.AccessToken) error
+	GetByHash(hash string) (*models.AccessToken, error)
+	ListByUser(userID uuid.UUID) ([]*models.AccessToken, error)
+	Revoke(id, userID uuid.UUID) (bool, error)
+	RevokeAllByUser(userID uuid.UUID) error
+	TouchLastUsed(id uuid.UUID, at time.Time) error
+}
+
+// accessTokenRepository реализация репозитория персональных токенов
+type accessTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewAccessTokenRepository создает новый репозиторий персональных токенов
+func NewAccessTokenRepository(db *gorm.DB) AccessTokenRepository {
+	return &accessTokenRepository{db: db}
+}
+
+// Create сохраняет токен
+func (r *accessTokenRepository) Create(token *models.AccessToken) error {
+	if token.ID == uuid.Nil {
+		token.ID = uuid.New()
+	}
+	return r.db.Create(token).Error
+}
+
+// GetByHash находит токен по хэшу
+func (r *accessTokenRepository) GetByHash(hash string) (*models.AccessToken, error) {
+	var token models.AccessToken
+	if err := r.db.First(&token, "token_hash = ?", hash).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListByUser возвращает токены пользователя, включая отозванные (новые первыми)
+func (r *accessTokenRepository) ListByUser(userID uuid.UUID) ([]*models.AccessToken, error) {
+	var tokens []*models.AccessToken
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// Revoke отзывает токен пользователя; false — токен не найден или уже отозван
+func (r *accessTokenRepository) Revoke(id, userID uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.AccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// RevokeAllByUser отзывает все действующие токены пользователя
+func (r *accessTokenRepository) RevokeAllByUser(userID uuid.UUID) error {
+	return r.db.Model(&models.AccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// TouchLastUsed обновляет время последнего использования
+func (r *accessTokenRepository) TouchLastUsed(id uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.AccessToken{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
@@ -66,7 +66,7 @@ func (r *assignmentRepository) Create(assignment *models.Assignment) error {
 
 func (r *assignmentRepository) GetByID(id uuid.UUID) (*models.Assignment, error) {
 	var assignment models.Assignment
-	err := r.db.Preload("Teacher").Preload("Student").Preload("Comments.Author").
+	err := r.db.Preload("Teacher").Preload("Student").
 		Where("id = ?", id).First(&assignment).Error
 	return &assignment, err
 }
@@ -14,8 +14,12 @@ type AssignmentTargetRepository interface {
 	GetByID(id uuid.UUID) (*models.AssignmentTarget, error)
 	GetByAssignmentAndStudent(assignmentID, studentID uuid.UUID) (*models.AssignmentTarget, error)
 	ListByStudent(studentID uuid.UUID) ([]*models.AssignmentTarget, error)
+	ListByStudentWithFeedback(studentID uuid.UUID) ([]*models.AssignmentTarget, error)
 	ListByAssignment(assignmentID uuid.UUID) ([]*models.AssignmentTarget, error)
 	ListByStatus(status models.AssignmentTargetStatus) ([]*models.AssignmentTarget, error)
+	ListByTeacher(teacherID uuid.UUID, status models.AssignmentTargetStatus) ([]*models.AssignmentTarget, error)
+	ListByGroups(groupIDs []uuid.UUID, status models.AssignmentTargetStatus) ([]*models.AssignmentTarget, error)
+	ListPendingDueBetween(from, to time.Time) ([]*models.AssignmentTarget, error)
 	Update(target *models.AssignmentTarget) error
 	Delete(id uuid.UUID) error
 
@@ -68,6 +72,17 @@ func (r *assignmentTargetRepository) ListByStudent(studentID uuid.UUID) ([]*mode
 	return targets, err
 }
 
+// ListByStudentWithFeedback возвращает задания ученика вместе с отзывами преподавателя
+func (r *assignmentTargetRepository) ListByStudentWithFeedback(studentID uuid.UUID) ([]*models.AssignmentTarget, error) {
+	var targets []*models.AssignmentTarget
+	err := r.db.Preload("Assignment").Preload("Assignment.Teacher").
+		Preload("Feedbacks", func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC") }).
+		Where("student_id = ?", studentID).
+		Order("created_at DESC").
+		Find(&targets).Error
+	return targets, err
+}
+
 func (r *assignmentTargetRepository) ListByAssignment(assignmentID uuid.UUID) ([]*models.AssignmentTarget, error) {
 	var targets []*models.AssignmentTarget
 	err := r.db.Preload("Student").
@@ -86,6 +101,46 @@ func (r *assignmentTargetRepository) ListByStatus(status models.AssignmentTarget
 	return targets, err
 }
 
+// ListByTeacher возвращает назначения заданий преподавателя только для связанных с ним учеников;
+// пустой status — все статусы
+func (r *assignmentTargetRepository) ListByTeacher(teacherID uuid.UUID, status models.AssignmentTargetStatus) ([]*models.AssignmentTarget, error) {
+	var targets []*models.AssignmentTarget
+	query := r.db.Preload("Assignment").Preload("Student").
+		Joins("JOIN assignments ON assignment_targets.assignment_id = assignments.id").
+		Joins("JOIN teacher_students ON teacher_students.teacher_id = assignments.teacher_id AND teacher_students.student_id = assignment_targets.student_id").
+		Where("assignments.teacher_id = ? AND assignments.deleted_at IS NULL", teacherID)
+	if status != "" {
+		query = query.Where("assignment_targets.status = ?", status)
+	}
+	err := query.Order("assignment_targets.created_at DESC").Find(&targets).Error
+	return targets, err
+}
+
+// ListByGroups возвращает назначения групповых заданий указанных групп; пустой status — все статусы
+func (r *assignmentTargetRepository) ListByGroups(groupIDs []uuid.UUID, status models.AssignmentTargetStatus) ([]*models.AssignmentTarget, error) {
+	var targets []*models.AssignmentTarget
+	query := r.db.Preload("Assignment").Preload("Student").
+		Joins("JOIN assignments ON assignment_targets.assignment_id = assignments.id").
+		Where("assignments.group_id IN ? AND assignments.deleted_at IS NULL", groupIDs)
+	if status != "" {
+		query = query.Where("assignment_targets.status = ?", status)
+	}
+	err := query.Order("assignment_targets.created_at DESC").Find(&targets).Error
+	return targets, err
+}
+
+// ListPendingDueBetween возвращает несданные задания с дедлайном в интервале (from, to]
+func (r *assignmentTargetRepository) ListPendingDueBetween(from, to time.Time) ([]*models.AssignmentTarget, error) {
+	var targets []*models.AssignmentTarget
+	err := r.db.Preload("Assignment").Preload("Student").
+		Joins("JOIN assignments ON assignment_targets.assignment_id = assignments.id").
+		Where("assignment_targets.status = ? AND assignments.due_date > ? AND assignments.due_date <= ? AND assignments.deleted_at IS NULL",
+			models.AssignmentTargetStatusPending, from, to).
+		Order("assignments.due_date ASC").
+		Find(&targets).Error
+	return targets, err
+}
+
 func (r *assignmentTargetRepository) Update(target *models.AssignmentTarget) error {
 	return r.db.Save(target).Error
 }
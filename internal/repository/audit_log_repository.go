@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"edubot/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditLogFilter — условия выборки журнала изменений
+type AuditLogFilter struct {
+	EntityType string
+	EntityID   *uuid.UUID
+	ActorID    *uuid.UUID
+	VisibleTo  *uuid.UUID // Только записи, где пользователь автор или владелец данных
+	Limit      int
+	Offset     int
+}
+
+// AuditLogRepository интерфейс для работы с журналом изменений
+type AuditLogRepository interface {
+	Create(entry *models.AuditLog) error
+	List(filter AuditLogFilter) ([]models.AuditLog, int64, error)
+}
+
+// auditLogRepository реализация репозитория журнала изменений
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository создает новый репозиторий журнала изменений
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+// Create сохраняет запись журнала
+func (r *auditLogRepository) Create(entry *models.AuditLog) error {
+	return r.db.Create(entry).Error
+}
+
+// List возвращает записи журнала по фильтру (новые первыми) и их общее количество
+func (r *auditLogRepository) List(filter AuditLogFilter) ([]models.AuditLog, int64, error) {
+	query := r.db.Model(&models.AuditLog{})
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != nil {
+		query = query.Where("entity_id = ?", *filter.EntityID)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.VisibleTo != nil {
+		query = query.Where("actor_id = ? OR owner_id = ?", *filter.VisibleTo, *filter.VisibleTo)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.AuditLog
+	err := query.Preload("Actor").
+		Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&entries).Error
+	return entries, total, err
+}
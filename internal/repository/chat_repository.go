@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"edubot/internal/models"
 )
@@ -25,10 +26,19 @@ type ChatRepository interface {
 	ListMessages(threadID uuid.UUID, limit int, before *time.Time) ([]*models.Message, error)
 	UpdateMessage(message *models.Message) error
 	DeleteMessage(id uuid.UUID) error
+	GetLastMessage(threadID uuid.UUID) (*models.Message, error)
+
+	// Reactions and pins
+	AddReaction(reaction *models.MessageReaction) error
+	RemoveReaction(messageID, userID uuid.UUID, emoji string) (bool, error)
+	SetPinned(messageID uuid.UUID, pinnedBy *uuid.UUID, pinnedAt *time.Time) error
+	ListPinned(threadID uuid.UUID) ([]*models.Message, error)
 
 	// Unread counts
 	GetUnreadCount(threadID, userID uuid.UUID) (int64, error)
-	MarkAsRead(threadID, userID uuid.UUID) error
+	GetUnreadCounts(threadIDs []uuid.UUID, userID uuid.UUID) (map[uuid.UUID]int64, error)
+	MarkAsRead(cursor *models.ChatReadCursor) error
+	ListReadCursors(threadID uuid.UUID) ([]models.ChatReadCursor, error)
 }
 
 type chatRepository struct {
@@ -115,7 +125,8 @@ func (r *chatRepository) GetOrCreateGroupThread(groupID, teacherID uuid.UUID) (*
 func (r *chatRepository) ListThreadsForUser(userID uuid.UUID) ([]*models.ChatThread, error) {
 	var threads []*models.ChatThread
 	err := r.db.Preload("Student").Preload("Group").Preload("Teacher").
-		Where("student_id = ? OR teacher_id = ?", userID, userID).
+		Where("student_id = ? OR teacher_id = ? OR (type = ? AND group_id IN (?))", userID, userID, models.ChatThreadTypeGroup,
+			r.db.Model(&models.GroupMember{}).Select("group_id").Where("user_id = ?", userID)).
 		Order("last_message_at DESC NULLS LAST, created_at DESC").
 		Find(&threads).Error
 	return threads, err
@@ -134,12 +145,13 @@ func (r *chatRepository) CreateMessage(message *models.Message) error {
 	if message.ID == uuid.Nil {
 		message.ID = uuid.New()
 	}
-	return r.db.Create(message).Error
+	// Вложения уже сохранены: создаем только связи message_media
+	return r.db.Omit("Media.*").Create(message).Error
 }
 
 func (r *chatRepository) GetMessage(id uuid.UUID) (*models.Message, error) {
 	var message models.Message
-	err := r.db.Preload("Thread").Preload("Author").Preload("Media").
+	err := r.withMessageDetails(r.db.Preload("Thread")).
 		First(&message, "id = ?", id).Error
 	if err != nil {
 		return nil, err
@@ -149,7 +161,7 @@ func (r *chatRepository) GetMessage(id uuid.UUID) (*models.Message, error) {
 
 func (r *chatRepository) ListMessages(threadID uuid.UUID, limit int, before *time.Time) ([]*models.Message, error) {
 	var messages []*models.Message
-	query := r.db.Preload("Author").Preload("Media").
+	query := r.withMessageDetails(r.db).
 		Where("thread_id = ?", threadID).
 		Order("created_at DESC")
 
@@ -167,23 +179,125 @@ func (r *chatRepository) ListMessages(threadID uuid.UUID, limit int, before *tim
 
 func (r *chatRepository) UpdateMessage(message *models.Message) error {
 	message.EditedAt = &[]time.Time{time.Now()}[0]
-	return r.db.Save(message).Error
+	// Связанные записи (цитата, реакции, вложения) меняются своими методами
+	return r.db.Omit(clause.Associations).Save(message).Error
+}
+
+// withMessageDetails подгружает автора, вложения, цитату и реакции
+func (r *chatRepository) withMessageDetails(query *gorm.DB) *gorm.DB {
+	return query.Preload("Author").Preload("Media").
+		Preload("ReplyTo").Preload("ReplyTo.Author").
+		Preload("Reactions", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") })
+}
+
+// AddReaction ставит реакцию; повторная такая же реакция ничего не меняет
+func (r *chatRepository) AddReaction(reaction *models.MessageReaction) error {
+	if reaction.ID == uuid.Nil {
+		reaction.ID = uuid.New()
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "message_id"}, {Name: "user_id"}, {Name: "emoji"}},
+		DoNothing: true,
+	}).Create(reaction).Error
+}
+
+// RemoveReaction снимает реакцию; false — такой реакции не было
+func (r *chatRepository) RemoveReaction(messageID, userID uuid.UUID, emoji string) (bool, error) {
+	res := r.db.Where("message_id = ? AND user_id = ? AND emoji = ?", messageID, userID, emoji).
+		Delete(&models.MessageReaction{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// SetPinned закрепляет сообщение (pinnedAt != nil) или открепляет его
+func (r *chatRepository) SetPinned(messageID uuid.UUID, pinnedBy *uuid.UUID, pinnedAt *time.Time) error {
+	return r.db.Model(&models.Message{}).Where("id = ?", messageID).
+		Updates(map[string]interface{}{"pinned_at": pinnedAt, "pinned_by": pinnedBy}).Error
+}
+
+// ListPinned возвращает закрепленные сообщения чата, последние закрепленные первыми
+func (r *chatRepository) ListPinned(threadID uuid.UUID) ([]*models.Message, error) {
+	var messages []*models.Message
+	err := r.withMessageDetails(r.db).
+		Where("thread_id = ? AND pinned_at IS NOT NULL", threadID).
+		Order("pinned_at DESC").
+		Find(&messages).Error
+	return messages, err
 }
 
 func (r *chatRepository) DeleteMessage(id uuid.UUID) error {
 	return r.db.Delete(&models.Message{}, "id = ?", id).Error
 }
 
+// GetLastMessage возвращает последнее сообщение чата
+func (r *chatRepository) GetLastMessage(threadID uuid.UUID) (*models.Message, error) {
+	var message models.Message
+	err := r.db.Where("thread_id = ?", threadID).Order("created_at DESC").First(&message).Error
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// unreadMessages — чужие сообщения позже курсора чтения пользователя (без курсора — все чужие)
+func (r *chatRepository) unreadMessages(userID uuid.UUID) *gorm.DB {
+	return r.db.Model(&models.Message{}).
+		Joins("LEFT JOIN chat_read_cursors ON chat_read_cursors.thread_id = messages.thread_id AND chat_read_cursors.user_id = ?", userID).
+		Where("messages.author_id != ?", userID).
+		Where("chat_read_cursors.id IS NULL OR messages.created_at > chat_read_cursors.last_read_at")
+}
+
 func (r *chatRepository) GetUnreadCount(threadID, userID uuid.UUID) (int64, error) {
 	var count int64
-	err := r.db.Model(&models.Message{}).
-		Where("thread_id = ? AND author_id != ?", threadID, userID).
+	err := r.unreadMessages(userID).
+		Where("messages.thread_id = ?", threadID).
 		Count(&count).Error
 	return count, err
 }
 
-func (r *chatRepository) MarkAsRead(threadID, userID uuid.UUID) error {
-	// В простой реализации просто возвращаем nil
-	// В реальной системе здесь была бы таблица MessageRead
-	return nil
+// GetUnreadCounts считает непрочитанные сразу по нескольким чатам одним запросом
+func (r *chatRepository) GetUnreadCounts(threadIDs []uuid.UUID, userID uuid.UUID) (map[uuid.UUID]int64, error) {
+	counts := make(map[uuid.UUID]int64, len(threadIDs))
+	if len(threadIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		ThreadID uuid.UUID
+		Count    int64
+	}
+	err := r.unreadMessages(userID).
+		Select("messages.thread_id AS thread_id, COUNT(*) AS count").
+		Where("messages.thread_id IN ?", threadIDs).
+		Group("messages.thread_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.ThreadID] = row.Count
+	}
+	return counts, nil
+}
+
+// MarkAsRead сдвигает курсор чтения вперед; более старая отметка курсор не откатывает
+func (r *chatRepository) MarkAsRead(cursor *models.ChatReadCursor) error {
+	if cursor.ID == uuid.Nil {
+		cursor.ID = uuid.New()
+	}
+	cursor.UpdatedAt = time.Now()
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "thread_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_read_message_id", "last_read_at", "updated_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "chat_read_cursors.last_read_at < excluded.last_read_at"},
+		}},
+	}).Create(cursor).Error
+}
+
+// ListReadCursors возвращает курсоры чтения всех участников чата
+func (r *chatRepository) ListReadCursors(threadID uuid.UUID) ([]models.ChatReadCursor, error) {
+	var cursors []models.ChatReadCursor
+	err := r.db.Where("thread_id = ?", threadID).Order("last_read_at DESC").Find(&cursors).Error
+	return cursors, err
 }
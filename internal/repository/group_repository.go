@@ -20,6 +20,8 @@ type GroupRepository interface {
 	RemoveMember(groupID, userID uuid.UUID) error
 	ListMembers(groupID uuid.UUID) ([]*models.GroupMember, error)
 	IsMember(groupID, userID uuid.UUID) (bool, error)
+	GetMemberRole(groupID, userID uuid.UUID) (string, error)
+	ListByMember(userID uuid.UUID, role string) ([]*models.Group, error)
 }
 
 type groupRepository struct{ db *gorm.DB }
@@ -79,3 +81,26 @@ func (r *groupRepository) IsMember(groupID, userID uuid.UUID) (bool, error) {
 	err := r.db.Model(&models.GroupMember{}).Where("group_id = ? AND user_id = ?", groupID, userID).Count(&count).Error
 	return count > 0, err
 }
+
+// GetMemberRole возвращает роль пользователя в группе; пустая строка — не участник
+func (r *groupRepository) GetMemberRole(groupID, userID uuid.UUID) (string, error) {
+	var member models.GroupMember
+	err := r.db.Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error
+	if err == gorm.ErrRecordNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// ListByMember возвращает группы, где пользователь состоит с указанной ролью
+func (r *groupRepository) ListByMember(userID uuid.UUID, role string) ([]*models.Group, error) {
+	var gs []*models.Group
+	err := r.db.Joins("JOIN group_members ON group_members.group_id = groups.id AND group_members.deleted_at IS NULL").
+		Where("group_members.user_id = ? AND group_members.role = ?", userID, role).
+		Order("groups.created_at DESC").
+		Find(&gs).Error
+	return gs, err
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"edubot/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInviteExhausted — у кода закончились использования, или он отозван либо истек
+var ErrInviteExhausted = errors.New("invite has no uses left")
+
+// InviteRepository интерфейс для работы с кодами приглашения учеников
+type InviteRepository interface {
+	Create(invite *models.Invite) error
+	GetByCode(code string) (*models.Invite, error)
+	ListByTeacher(teacherID uuid.UUID) ([]*models.Invite, error)
+	Revoke(id, teacherID uuid.UUID) (bool, error)
+	Redeem(inviteID, userID uuid.UUID) (bool, error)
+}
+
+// inviteRepository реализация репозитория кодов приглашения
+type inviteRepository struct {
+	db *gorm.DB
+}
+
+// NewInviteRepository создает новый репозиторий кодов приглашения
+func NewInviteRepository(db *gorm.DB) InviteRepository {
+	return &inviteRepository{db: db}
+}
+
+// Create сохраняет код приглашения
+func (r *inviteRepository) Create(invite *models.Invite) error {
+	return r.db.Create(invite).Error
+}
+
+// GetByCode получает код приглашения с группой
+func (r *inviteRepository) GetByCode(code string) (*models.Invite, error) {
+	var invite models.Invite
+	err := r.db.Preload("Group").Where("code = ?", code).First(&invite).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// ListByTeacher возвращает коды преподавателя, новые первыми
+func (r *inviteRepository) ListByTeacher(teacherID uuid.UUID) ([]*models.Invite, error) {
+	var invites []*models.Invite
+	err := r.db.Preload("Group").
+		Where("teacher_id = ?", teacherID).
+		Order("created_at DESC").
+		Find(&invites).Error
+	return invites, err
+}
+
+// Revoke отзывает код преподавателя; false — код не найден или уже отозван
+func (r *inviteRepository) Revoke(id, teacherID uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.Invite{}).
+		Where("id = ? AND teacher_id = ? AND revoked_at IS NULL", id, teacherID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// Redeem засчитывает использование кода пользователем. Возвращает false, если пользователь
+// уже использовал этот код (счетчик не меняется), и ErrInviteExhausted, если использований не осталось
+// или код уже отозван либо истек.
+func (r *inviteRepository) Redeem(inviteID, userID uuid.UUID) (bool, error) {
+	redeemed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.InviteRedemption{}).
+			Where("invite_id = ? AND user_id = ?", inviteID, userID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		// Условие в UPDATE не дает превысить лимит при одновременных активациях
+		// и активировать код, отозванный или истекший после проверки
+		now := time.Now()
+		result := tx.Model(&models.Invite{}).
+			Where("id = ? AND (max_uses = 0 OR uses < max_uses)", inviteID).
+			Where("revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", now).
+			Updates(map[string]interface{}{"uses": gorm.Expr("uses + 1"), "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInviteExhausted
+		}
+
+		redeemed = true
+		return tx.Create(&models.InviteRedemption{
+			ID:        uuid.New(),
+			InviteID:  inviteID,
+			UserID:    userID,
+			CreatedAt: now,
+		}).Error
+	})
+	return redeemed, err
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"edubot/internal/models"
+	"edubot/pkg/database"
+)
+
+func newTestInviteRepository(t *testing.T) InviteRepository {
+	t.Helper()
+	t.Setenv("DATABASE_URL", "")
+	db, err := database.NewDatabase(filepath.Join(t.TempDir(), "edubot.db"))
+	if err != nil {
+		t.Fatalf("database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewInviteRepository(db.DB)
+}
+
+func createTestInvite(t *testing.T, repo InviteRepository, invite models.Invite) *models.Invite {
+	t.Helper()
+	invite.ID = uuid.New()
+	invite.Code = invite.ID.String()
+	invite.TeacherID = uuid.New()
+	if err := repo.Create(&invite); err != nil {
+		t.Fatalf("create invite: %v", err)
+	}
+	return &invite
+}
+
+func TestInviteRedeemLimit(t *testing.T) {
+	repo := newTestInviteRepository(t)
+	invite := createTestInvite(t, repo, models.Invite{MaxUses: 2})
+	first, second, third := uuid.New(), uuid.New(), uuid.New()
+
+	for _, userID := range []uuid.UUID{first, second} {
+		if redeemed, err := repo.Redeem(invite.ID, userID); err != nil || !redeemed {
+			t.Fatalf("Redeem: redeemed %v, err %v", redeemed, err)
+		}
+	}
+	// Повторная активация тем же пользователем не расходует использование
+	if redeemed, err := repo.Redeem(invite.ID, first); err != nil || redeemed {
+		t.Errorf("repeat: expected no-op, got redeemed %v, err %v", redeemed, err)
+	}
+	if _, err := repo.Redeem(invite.ID, third); !errors.Is(err, ErrInviteExhausted) {
+		t.Errorf("third user: expected ErrInviteExhausted, got %v", err)
+	}
+
+	stored, err := repo.GetByCode(invite.Code)
+	if err != nil {
+		t.Fatalf("GetByCode: %v", err)
+	}
+	if stored.Uses != 2 {
+		t.Errorf("uses = %d, want 2", stored.Uses)
+	}
+}
+
+func TestInviteRedeemUnlimited(t *testing.T) {
+	repo := newTestInviteRepository(t)
+	invite := createTestInvite(t, repo, models.Invite{})
+	for i := 0; i < 5; i++ {
+		if _, err := repo.Redeem(invite.ID, uuid.New()); err != nil {
+			t.Fatalf("Redeem %d: %v", i, err)
+		}
+	}
+}
+
+func TestInviteRedeemRevokedOrExpired(t *testing.T) {
+	repo := newTestInviteRepository(t)
+
+	revoked := createTestInvite(t, repo, models.Invite{})
+	if ok, err := repo.Revoke(revoked.ID, revoked.TeacherID); err != nil || !ok {
+		t.Fatalf("Revoke: %v %v", ok, err)
+	}
+	if _, err := repo.Redeem(revoked.ID, uuid.New()); !errors.Is(err, ErrInviteExhausted) {
+		t.Errorf("revoked: expected ErrInviteExhausted, got %v", err)
+	}
+
+	expiredAt := time.Now().Add(-time.Minute)
+	expired := createTestInvite(t, repo, models.Invite{ExpiresAt: &expiredAt})
+	if _, err := repo.Redeem(expired.ID, uuid.New()); !errors.Is(err, ErrInviteExhausted) {
+		t.Errorf("expired: expected ErrInviteExhausted, got %v", err)
+	}
+
+	for _, invite := range []*models.Invite{revoked, expired} {
+		stored, err := repo.GetByCode(invite.Code)
+		if err != nil {
+			t.Fatalf("GetByCode: %v", err)
+		}
+		if stored.Uses != 0 {
+			t.Errorf("invite %s: uses = %d, want 0", invite.Code, stored.Uses)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"edubot/internal/models"
+)
+
+type JobRepository interface {
+	// История запусков
+	CreateRun(run *models.JobRun) error
+	FinishRun(id uuid.UUID, status models.JobRunStatus, errText string) error
+	ListRuns(jobName string, limit int) ([]*models.JobRun, error)
+
+	// Блокировки
+	AcquireLock(jobName, owner string, ttl time.Duration) (bool, error)
+	ReleaseLock(jobName, owner string) error
+}
+
+type jobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+func (r *jobRepository) CreateRun(run *models.JobRun) error {
+	if run.ID == uuid.Nil {
+		run.ID = uuid.New()
+	}
+	return r.db.Create(run).Error
+}
+
+func (r *jobRepository) FinishRun(id uuid.UUID, status models.JobRunStatus, errText string) error {
+	now := time.Now()
+	return r.db.Model(&models.JobRun{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"finished_at": &now,
+			"error":       errText,
+		}).Error
+}
+
+func (r *jobRepository) ListRuns(jobName string, limit int) ([]*models.JobRun, error) {
+	var runs []*models.JobRun
+	query := r.db.Order("started_at DESC")
+	if jobName != "" {
+		query = query.Where("job_name = ?", jobName)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	err := query.Find(&runs).Error
+	return runs, err
+}
+
+// AcquireLock захватывает блокировку задачи, если она свободна, просрочена или уже принадлежит owner
+func (r *jobRepository) AcquireLock(jobName, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	// Создаем строку блокировки, если её ещё нет
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.JobLock{
+		JobName:     jobName,
+		LockedBy:    "",
+		LockedUntil: time.Time{},
+		UpdatedAt:   now,
+	}).Error; err != nil {
+		return false, err
+	}
+
+	// Атомарно забираем блокировку: UPDATE сработает только у одного инстанса
+	result := r.db.Model(&models.JobLock{}).
+		Where("job_name = ? AND (locked_until < ? OR locked_by = ? OR locked_by = '')", jobName, now, owner).
+		Updates(map[string]interface{}{
+			"locked_by":    owner,
+			"locked_until": now.Add(ttl),
+			"updated_at":   now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *jobRepository) ReleaseLock(jobName, owner string) error {
+	return r.db.Model(&models.JobLock{}).
+		Where("job_name = ? AND locked_by = ?", jobName, owner).
+		Updates(map[string]interface{}{
+			"locked_by":    "",
+			"locked_until": time.Time{},
+			"updated_at":   time.Now(),
+		}).Error
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"edubot/internal/models"
+)
+
+type NotificationPreferenceRepository interface {
+	ListByUser(userID uuid.UUID) ([]*models.NotificationPreference, error)
+	Upsert(preference *models.NotificationPreference) error
+
+	// Общие настройки (тихие часы)
+	GetSettings(userID uuid.UUID) (*models.NotificationSettings, error)
+	SaveSettings(settings *models.NotificationSettings) error
+}
+
+type notificationPreferenceRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationPreferenceRepository(db *gorm.DB) NotificationPreferenceRepository {
+	return &notificationPreferenceRepository{db: db}
+}
+
+func (r *notificationPreferenceRepository) ListByUser(userID uuid.UUID) ([]*models.NotificationPreference, error) {
+	var preferences []*models.NotificationPreference
+	err := r.db.Where("user_id = ?", userID).Find(&preferences).Error
+	return preferences, err
+}
+
+func (r *notificationPreferenceRepository) Upsert(preference *models.NotificationPreference) error {
+	if preference.ID == uuid.Nil {
+		preference.ID = uuid.New()
+	}
+	now := time.Now()
+	if preference.CreatedAt.IsZero() {
+		preference.CreatedAt = now
+	}
+	preference.UpdatedAt = now
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(preference).Error
+}
+
+// GetSettings возвращает настройки пользователя или значения по умолчанию, если он их не менял
+func (r *notificationPreferenceRepository) GetSettings(userID uuid.UUID) (*models.NotificationSettings, error) {
+	var settings models.NotificationSettings
+	err := r.db.First(&settings, "user_id = ?", userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.NotificationSettings{
+			UserID:          userID,
+			QuietHoursStart: "22:00",
+			QuietHoursEnd:   "08:00",
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *notificationPreferenceRepository) SaveSettings(settings *models.NotificationSettings) error {
+	return r.db.Save(settings).Error
+}
@@ -13,15 +13,22 @@ type NotificationRepository interface {
 	Create(notification *models.Notification) error
 	GetByID(id uuid.UUID) (*models.Notification, error)
 	ListByUser(userID uuid.UUID) ([]*models.Notification, error)
-	ListByStatus(status models.NotificationStatus) ([]*models.Notification, error)
+	ListByUserAndChannel(userID uuid.UUID, channel models.NotificationChannel) ([]*models.Notification, error)
+	ListDue(now time.Time) ([]*models.Notification, error)
+	ListByStatus(status models.NotificationStatus, userIDs []uuid.UUID) ([]*models.Notification, error)
 	ListByChannel(channel models.NotificationChannel) ([]*models.Notification, error)
 	Update(notification *models.Notification) error
 	Delete(id uuid.UUID) error
 
 	// Методы для массовых операций
 	CreateForGroup(userIDs []uuid.UUID, notificationType models.NotificationType, title, message, payload string) error
+	ExistsByPayload(userID uuid.UUID, notificationType models.NotificationType, payload string) (bool, error)
 	MarkAsSent(id uuid.UUID) error
 	MarkAsRead(id uuid.UUID) error
+	MarkAsBounced(id uuid.UUID, errText string) error
+	ScheduleRetry(id uuid.UUID, attempts int, nextAttemptAt time.Time, errText string) error
+	MarkAsFailed(id uuid.UUID, attempts int, errText string) error
+	Requeue(id uuid.UUID) error
 	CleanupOld(olderThan time.Time) error
 }
 
@@ -57,10 +64,32 @@ func (r *notificationRepository) ListByUser(userID uuid.UUID) ([]*models.Notific
 	return notifications, err
 }
 
-func (r *notificationRepository) ListByStatus(status models.NotificationStatus) ([]*models.Notification, error) {
+func (r *notificationRepository) ListByUserAndChannel(userID uuid.UUID, channel models.NotificationChannel) ([]*models.Notification, error) {
 	var notifications []*models.Notification
+	err := r.db.Where("user_id = ? AND channel = ?", userID, channel).
+		Order("created_at DESC").
+		Find(&notifications).Error
+	return notifications, err
+}
+
+// ListDue возвращает ожидающие уведомления, которые уже можно отправлять
+func (r *notificationRepository) ListDue(now time.Time) ([]*models.Notification, error) {
+	var notifications []*models.Notification
+	err := r.db.Preload("User").
+		Where("status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)", models.NotificationStatusPending, now).
+		Order("created_at ASC").
+		Find(&notifications).Error
+	return notifications, err
+}
+
+// ListByStatus возвращает уведомления указанных пользователей в статусе status
+func (r *notificationRepository) ListByStatus(status models.NotificationStatus, userIDs []uuid.UUID) ([]*models.Notification, error) {
+	var notifications []*models.Notification
+	if len(userIDs) == 0 {
+		return notifications, nil
+	}
 	err := r.db.Preload("User").
-		Where("status = ?", status).
+		Where("status = ? AND user_id IN ?", status, userIDs).
 		Order("created_at ASC").
 		Find(&notifications).Error
 	return notifications, err
@@ -102,6 +131,15 @@ func (r *notificationRepository) CreateForGroup(userIDs []uuid.UUID, notificatio
 	return r.db.Create(&notifications).Error
 }
 
+// ExistsByPayload проверяет, создавалось ли уже такое уведомление (включая очищенные)
+func (r *notificationRepository) ExistsByPayload(userID uuid.UUID, notificationType models.NotificationType, payload string) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Notification{}).
+		Where("user_id = ? AND type = ? AND payload = ?", userID, notificationType, payload).
+		Count(&count).Error
+	return count > 0, err
+}
+
 func (r *notificationRepository) MarkAsSent(id uuid.UUID) error {
 	now := time.Now()
 	return r.db.Model(&models.Notification{}).
@@ -124,6 +162,55 @@ func (r *notificationRepository) MarkAsRead(id uuid.UUID) error {
 		}).Error
 }
 
+// MarkAsBounced фиксирует окончательный отказ доставки
+func (r *notificationRepository) MarkAsBounced(id uuid.UUID, errText string) error {
+	return r.db.Model(&models.Notification{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     models.NotificationStatusBounced,
+			"last_error": errText,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// ScheduleRetry сохраняет ошибку попытки и откладывает следующую
+func (r *notificationRepository) ScheduleRetry(id uuid.UUID, attempts int, nextAttemptAt time.Time, errText string) error {
+	return r.db.Model(&models.Notification{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": &nextAttemptAt,
+			"last_error":      errText,
+			"updated_at":      time.Now(),
+		}).Error
+}
+
+// MarkAsFailed переводит уведомление в терминальный статус после исчерпания попыток
+func (r *notificationRepository) MarkAsFailed(id uuid.UUID, attempts int, errText string) error {
+	return r.db.Model(&models.Notification{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          models.NotificationStatusFailed,
+			"attempts":        attempts,
+			"next_attempt_at": nil,
+			"last_error":      errText,
+			"updated_at":      time.Now(),
+		}).Error
+}
+
+// Requeue возвращает недоставленное уведомление в очередь с обнуленным счетчиком попыток
+func (r *notificationRepository) Requeue(id uuid.UUID) error {
+	return r.db.Model(&models.Notification{}).
+		Where("id = ? AND status IN (?)", id,
+			[]models.NotificationStatus{models.NotificationStatusFailed, models.NotificationStatusBounced}).
+		Updates(map[string]interface{}{
+			"status":          models.NotificationStatusPending,
+			"attempts":        0,
+			"next_attempt_at": nil,
+			"updated_at":      time.Now(),
+		}).Error
+}
+
 func (r *notificationRepository) CleanupOld(olderThan time.Time) error {
 	return r.db.Where("created_at < ? AND status IN (?)",
 		olderThan,
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"time"
+
+	"edubot/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ParentRepository интерфейс для работы с родителями: связи с детьми и коды приглашения
+type ParentRepository interface {
+	Link(parentID, studentID uuid.UUID) error
+	IsLinked(parentID, studentID uuid.UUID) (bool, error)
+	ListChildren(parentID uuid.UUID) ([]models.User, error)
+	ListParentIDs(studentID uuid.UUID) ([]uuid.UUID, error)
+
+	CreateInvite(invite *models.ParentInvite) error
+	GetInviteByCode(code string) (*models.ParentInvite, error)
+	UseInvite(inviteID, parentID uuid.UUID) (bool, error)
+}
+
+// parentRepository реализация репозитория родителей
+type parentRepository struct {
+	db *gorm.DB
+}
+
+// NewParentRepository создает новый репозиторий родителей
+func NewParentRepository(db *gorm.DB) ParentRepository {
+	return &parentRepository{db: db}
+}
+
+// Link связывает родителя с ребенком; повторная связь не создает дубликат
+func (r *parentRepository) Link(parentID, studentID uuid.UUID) error {
+	link := &models.ParentStudent{
+		ID:        uuid.New(),
+		ParentID:  parentID,
+		StudentID: studentID,
+		CreatedAt: time.Now(),
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(link).Error
+}
+
+// IsLinked проверяет, является ли пользователь родителем ученика
+func (r *parentRepository) IsLinked(parentID, studentID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.ParentStudent{}).
+		Where("parent_id = ? AND student_id = ?", parentID, studentID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ListChildren возвращает детей родителя
+func (r *parentRepository) ListChildren(parentID uuid.UUID) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Joins("JOIN parent_students ON parent_students.student_id = users.id").
+		Where("parent_students.parent_id = ?", parentID).
+		Order("parent_students.created_at ASC").
+		Find(&users).Error
+	return users, err
+}
+
+// ListParentIDs возвращает ID родителей ученика
+func (r *parentRepository) ListParentIDs(studentID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&models.ParentStudent{}).
+		Where("student_id = ?", studentID).
+		Pluck("parent_id", &ids).Error
+	return ids, err
+}
+
+// CreateInvite сохраняет код приглашения родителя
+func (r *parentRepository) CreateInvite(invite *models.ParentInvite) error {
+	return r.db.Create(invite).Error
+}
+
+// GetInviteByCode получает код приглашения вместе с учеником
+func (r *parentRepository) GetInviteByCode(code string) (*models.ParentInvite, error) {
+	var invite models.ParentInvite
+	err := r.db.Preload("Student").Where("code = ?", code).First(&invite).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// UseInvite помечает код использованным. Возвращает false, если код уже успели использовать.
+func (r *parentRepository) UseInvite(inviteID, parentID uuid.UUID) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&models.ParentInvite{}).
+		Where("id = ? AND used_at IS NULL", inviteID).
+		Updates(map[string]interface{}{"used_by": parentID, "used_at": now})
+	return result.RowsAffected > 0, result.Error
+}
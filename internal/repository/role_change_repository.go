@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"edubot/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RoleChangeRepository интерфейс для работы с историей смены ролей
+type RoleChangeRepository interface {
+	Create(change *models.RoleChange) error
+	List(userID *uuid.UUID, limit int) ([]models.RoleChange, error)
+}
+
+// roleChangeRepository реализация репозитория истории смены ролей
+type roleChangeRepository struct {
+	db *gorm.DB
+}
+
+// NewRoleChangeRepository создает новый репозиторий истории смены ролей
+func NewRoleChangeRepository(db *gorm.DB) RoleChangeRepository {
+	return &roleChangeRepository{db: db}
+}
+
+// Create сохраняет запись о смене роли
+func (r *roleChangeRepository) Create(change *models.RoleChange) error {
+	return r.db.Create(change).Error
+}
+
+// List возвращает последние смены ролей, при userID != nil — только этого пользователя
+func (r *roleChangeRepository) List(userID *uuid.UUID, limit int) ([]models.RoleChange, error) {
+	var changes []models.RoleChange
+	query := r.db.Preload("User").Preload("Changer").Order("created_at DESC").Limit(limit)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	err := query.Find(&changes).Error
+	return changes, err
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"time"
+
+	"edubot/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SessionRepository интерфейс для работы с сессиями входа
+type SessionRepository interface {
+	Create(session *models.Session) error
+	GetByID(id uuid.UUID) (*models.Session, error)
+	ListActiveByUser(userID uuid.UUID) ([]*models.Session, error)
+	Rotate(id uuid.UUID, oldHash, newHash string, usedAt, expiresAt time.Time, userAgent, ip string) (bool, error)
+	Revoke(id uuid.UUID, reason string) error
+	RevokeAllByUser(userID uuid.UUID, exceptID *uuid.UUID, reason string) error
+	DeleteExpired(before time.Time) error
+}
+
+// sessionRepository реализация репозитория сессий
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository создает новый репозиторий сессий
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// Create создает сессию
+func (r *sessionRepository) Create(session *models.Session) error {
+	if session.ID == uuid.Nil {
+		session.ID = uuid.New()
+	}
+	return r.db.Create(session).Error
+}
+
+// GetByID получает сессию по ID
+func (r *sessionRepository) GetByID(id uuid.UUID) (*models.Session, error) {
+	var session models.Session
+	err := r.db.First(&session, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListActiveByUser возвращает неотозванные и неистекшие сессии пользователя
+func (r *sessionRepository) ListActiveByUser(userID uuid.UUID) ([]*models.Session, error) {
+	var sessions []*models.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Rotate заменяет refresh-токен, только если сессия активна и предъявлен текущий токен.
+// Возвращает false, если токен уже был заменен другим запросом.
+func (r *sessionRepository) Rotate(id uuid.UUID, oldHash, newHash string, usedAt, expiresAt time.Time, userAgent, ip string) (bool, error) {
+	result := r.db.Model(&models.Session{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL AND expires_at > ?", id, oldHash, usedAt).
+		Updates(map[string]interface{}{
+			"refresh_token_hash": newHash,
+			"last_used_at":       usedAt,
+			"expires_at":         expiresAt,
+			"user_agent":         userAgent,
+			"ip":                 ip,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Revoke отзывает сессию
+func (r *sessionRepository) Revoke(id uuid.UUID, reason string) error {
+	return r.db.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"revoked_reason": reason,
+		}).Error
+}
+
+// RevokeAllByUser отзывает все сессии пользователя, кроме exceptID (если задан)
+func (r *sessionRepository) RevokeAllByUser(userID uuid.UUID, exceptID *uuid.UUID, reason string) error {
+	query := r.db.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+	if exceptID != nil {
+		query = query.Where("id <> ?", *exceptID)
+	}
+	return query.Updates(map[string]interface{}{
+		"revoked_at":     time.Now(),
+		"revoked_reason": reason,
+	}).Error
+}
+
+// DeleteExpired удаляет сессии, истекшие до указанного момента
+func (r *sessionRepository) DeleteExpired(before time.Time) error {
+	return r.db.Where("expires_at < ?", before).Delete(&models.Session{}).Error
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"time"
+
+	"edubot/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TeacherStudentRepository интерфейс для работы со связями преподаватель–ученик
+type TeacherStudentRepository interface {
+	Link(teacherID, studentID uuid.UUID, subject string) error
+	Unlink(teacherID, studentID uuid.UUID) error
+	IsLinked(teacherID, studentID uuid.UUID) (bool, error)
+	ListStudents(teacherID uuid.UUID) ([]models.User, error)
+	ListStudentIDs(teacherID uuid.UUID) ([]uuid.UUID, error)
+	ListTeachers(studentID uuid.UUID) ([]models.User, error)
+	Backfill(defaultTeacherID *uuid.UUID) (int, error)
+}
+
+// teacherStudentRepository реализация репозитория связей преподаватель–ученик
+type teacherStudentRepository struct {
+	db *gorm.DB
+}
+
+// NewTeacherStudentRepository создает новый репозиторий связей преподаватель–ученик
+func NewTeacherStudentRepository(db *gorm.DB) TeacherStudentRepository {
+	return &teacherStudentRepository{db: db}
+}
+
+// Link связывает ученика с преподавателем; повторная связь не создает дубликат
+func (r *teacherStudentRepository) Link(teacherID, studentID uuid.UUID, subject string) error {
+	link := &models.TeacherStudent{
+		ID:        uuid.New(),
+		TeacherID: teacherID,
+		StudentID: studentID,
+		Subject:   subject,
+		CreatedAt: time.Now(),
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(link).Error
+}
+
+// Unlink удаляет связь ученика с преподавателем
+func (r *teacherStudentRepository) Unlink(teacherID, studentID uuid.UUID) error {
+	return r.db.Where("teacher_id = ? AND student_id = ?", teacherID, studentID).
+		Delete(&models.TeacherStudent{}).Error
+}
+
+// IsLinked проверяет, занимается ли ученик у преподавателя
+func (r *teacherStudentRepository) IsLinked(teacherID, studentID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.TeacherStudent{}).
+		Where("teacher_id = ? AND student_id = ?", teacherID, studentID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ListStudents возвращает учеников преподавателя
+func (r *teacherStudentRepository) ListStudents(teacherID uuid.UUID) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Joins("JOIN teacher_students ON teacher_students.student_id = users.id").
+		Where("teacher_students.teacher_id = ?", teacherID).
+		Order("users.created_at DESC").
+		Find(&users).Error
+	return users, err
+}
+
+// ListStudentIDs возвращает ID учеников преподавателя
+func (r *teacherStudentRepository) ListStudentIDs(teacherID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&models.TeacherStudent{}).
+		Where("teacher_id = ?", teacherID).
+		Pluck("student_id", &ids).Error
+	return ids, err
+}
+
+// ListTeachers возвращает преподавателей ученика
+func (r *teacherStudentRepository) ListTeachers(studentID uuid.UUID) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Joins("JOIN teacher_students ON teacher_students.teacher_id = users.id").
+		Where("teacher_students.student_id = ?", studentID).
+		Order("teacher_students.created_at ASC").
+		Find(&users).Error
+	return users, err
+}
+
+// Backfill восстанавливает связи по уже существующим данным: заданиям, группам и личным чатам.
+// Ученики, которые ни с кем не связаны, привязываются к defaultTeacherID (если задан) —
+// до появления связей все ученики принадлежали единственному преподавателю.
+// Выполняется только пока связей нет, чтобы не возвращать отвязанных учеников.
+func (r *teacherStudentRepository) Backfill(defaultTeacherID *uuid.UUID) (int, error) {
+	var existing int64
+	if err := r.db.Model(&models.TeacherStudent{}).Count(&existing).Error; err != nil {
+		return 0, err
+	}
+	if existing > 0 {
+		return 0, nil
+	}
+
+	type pair struct {
+		TeacherID uuid.UUID
+		StudentID uuid.UUID
+	}
+
+	queries := []*gorm.DB{
+		// Индивидуальные задания
+		r.db.Table("assignments").
+			Select("DISTINCT teacher_id, student_id").
+			Where("student_id IS NOT NULL AND deleted_at IS NULL"),
+		// Назначения заданий (в том числе групповых)
+		r.db.Table("assignment_targets").
+			Select("DISTINCT assignments.teacher_id, assignment_targets.student_id").
+			Joins("JOIN assignments ON assignments.id = assignment_targets.assignment_id").
+			Where("assignment_targets.deleted_at IS NULL AND assignments.deleted_at IS NULL"),
+		// Участники групп
+		r.db.Table("group_members").
+			Select("DISTINCT groups.teacher_id, group_members.user_id AS student_id").
+			Joins("JOIN groups ON groups.id = group_members.group_id").
+			Where("group_members.deleted_at IS NULL AND groups.deleted_at IS NULL AND group_members.role = ?", "student"),
+		// Личные чаты
+		r.db.Table("chat_threads").
+			Select("DISTINCT teacher_id, student_id").
+			Where("type = ? AND student_id IS NOT NULL AND deleted_at IS NULL", models.ChatThreadTypeStudentTeacher),
+	}
+
+	linked := 0
+	for _, query := range queries {
+		var pairs []pair
+		if err := query.Scan(&pairs).Error; err != nil {
+			return linked, err
+		}
+		for _, p := range pairs {
+			if p.TeacherID == uuid.Nil || p.StudentID == uuid.Nil || p.TeacherID == p.StudentID {
+				continue
+			}
+			created, err := r.linkIfMissing(p.TeacherID, p.StudentID)
+			if err != nil {
+				return linked, err
+			}
+			if created {
+				linked++
+			}
+		}
+	}
+
+	if defaultTeacherID == nil {
+		return linked, nil
+	}
+
+	var orphanIDs []uuid.UUID
+	err := r.db.Model(&models.User{}).
+		Where("role = ? AND id NOT IN (?)", models.RoleStudent, r.db.Model(&models.TeacherStudent{}).Select("student_id")).
+		Pluck("id", &orphanIDs).Error
+	if err != nil {
+		return linked, err
+	}
+	for _, studentID := range orphanIDs {
+		created, err := r.linkIfMissing(*defaultTeacherID, studentID)
+		if err != nil {
+			return linked, err
+		}
+		if created {
+			linked++
+		}
+	}
+	return linked, nil
+}
+
+func (r *teacherStudentRepository) linkIfMissing(teacherID, studentID uuid.UUID) (bool, error) {
+	exists, err := r.IsLinked(teacherID, studentID)
+	if err != nil || exists {
+		return false, err
+	}
+	return true, r.Link(teacherID, studentID, "")
+}
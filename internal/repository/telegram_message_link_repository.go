@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"edubot/internal/models"
+)
+
+// TelegramMessageLinkRepository хранит связи сообщений бота с чатами приложения
+type TelegramMessageLinkRepository interface {
+	Create(link *models.TelegramMessageLink) error
+	GetByTelegramMessage(chatID int64, telegramMessageID int) (*models.TelegramMessageLink, error)
+}
+
+type telegramMessageLinkRepository struct {
+	db *gorm.DB
+}
+
+// NewTelegramMessageLinkRepository создает новый репозиторий связей сообщений
+func NewTelegramMessageLinkRepository(db *gorm.DB) TelegramMessageLinkRepository {
+	return &telegramMessageLinkRepository{db: db}
+}
+
+func (r *telegramMessageLinkRepository) Create(link *models.TelegramMessageLink) error {
+	if link.ID == uuid.Nil {
+		link.ID = uuid.New()
+	}
+	return r.db.Create(link).Error
+}
+
+func (r *telegramMessageLinkRepository) GetByTelegramMessage(chatID int64, telegramMessageID int) (*models.TelegramMessageLink, error) {
+	var link models.TelegramMessageLink
+	err := r.db.Where("chat_id = ? AND telegram_message_id = ?", chatID, telegramMessageID).First(&link).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
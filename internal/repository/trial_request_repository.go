@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"strings"
+	"time"
+
 	"edubot/internal/models"
 
 	"github.com/google/uuid"
@@ -72,3 +75,13 @@ func (r *TrialRequestRepository) GetByTelegramID(telegramID int64) ([]models.Tri
 	err := r.db.Where("telegram_id = ?", telegramID).Order("created_at DESC").Find(&requests).Error
 	return requests, err
 }
+
+// ExistsByContactSince проверяет, была ли с этим контактом заявка после since (без учета регистра и пробелов)
+func (r *TrialRequestRepository) ExistsByContactSince(contactType, contactValue string, since time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.TrialRequest{}).
+		Where("contact_type = ? AND LOWER(TRIM(contact_value)) = ? AND created_at > ?",
+			contactType, strings.ToLower(strings.TrimSpace(contactValue)), since).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"strings"
+
+	"edubot/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserData — все данные, связанные с пользователем (включая мягко удаленные строки)
+type UserData struct {
+	User              models.User
+	AssignmentTargets []models.AssignmentTarget
+	Submissions       []models.Submission
+	Attachments       []models.Attachment
+	Feedback          []models.Feedback
+	Messages          []models.Message
+	Media             []models.Media
+	Notifications     []models.Notification
+	Drafts            []models.Draft
+	TeacherLinks      []models.TeacherStudent
+	ParentLinks       []models.ParentStudent
+	GroupMemberships  []models.GroupMember
+	TrialRequests     []models.TrialRequest
+}
+
+// ErasureResult — итог удаления данных пользователя
+type ErasureResult struct {
+	Deleted   map[string]int64 // Удалено строк по таблицам
+	FilePaths []string         // Локальные файлы удаленных вложений; удаляются после фиксации транзакции
+}
+
+// UserDataRepository собирает и удаляет данные пользователя по всем таблицам
+type UserDataRepository interface {
+	Collect(userID uuid.UUID) (*UserData, error)
+	Erase(userID uuid.UUID, anonymize map[string]interface{}) (*ErasureResult, error)
+}
+
+// userDataRepository реализация репозитория данных пользователя
+type userDataRepository struct {
+	db *gorm.DB
+}
+
+// NewUserDataRepository создает новый репозиторий данных пользователя
+func NewUserDataRepository(db *gorm.DB) UserDataRepository {
+	return &userDataRepository{db: db}
+}
+
+// userDataIDs — ID строк, принадлежащих пользователю, по которым ищутся зависимые данные
+type userDataIDs struct {
+	targets     []uuid.UUID
+	feedback    []uuid.UUID
+	submissions []uuid.UUID
+	threads     []uuid.UUID
+	messages    []uuid.UUID
+	media       []uuid.UUID
+}
+
+func (r *userDataRepository) collectIDs(tx *gorm.DB, userID uuid.UUID) (*userDataIDs, error) {
+	ids := &userDataIDs{}
+	// Session: каждый запрос строится заново, условия предыдущих не накапливаются
+	db := tx.Unscoped().Session(&gorm.Session{})
+	if err := db.Model(&models.AssignmentTarget{}).Where("student_id = ?", userID).Pluck("id", &ids.targets).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&models.Feedback{}).Where("assignment_target_id IN ?", ids.targets).Pluck("id", &ids.feedback).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&models.Submission{}).
+		Where("user_id = ? OR assignment_target_id IN ?", userID, ids.targets).
+		Pluck("id", &ids.submissions).Error; err != nil {
+		return nil, err
+	}
+	// Личные чаты ученика целиком, в групповых — только его сообщения
+	if err := db.Model(&models.ChatThread{}).Where("student_id = ?", userID).Pluck("id", &ids.threads).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&models.Message{}).
+		Where("author_id = ? OR thread_id IN ?", userID, ids.threads).
+		Pluck("id", &ids.messages).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&models.Media{}).Where("owner_id = ?", userID).Pluck("id", &ids.media).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// trialRequestsOf — заявки на пробный урок этого человека. Заявки не связаны с профилем,
+// поэтому ищутся по Telegram ID, телефону и username из профиля
+func trialRequestsOf(db *gorm.DB, user *models.User) *gorm.DB {
+	query := db.Where("1 = 0")
+	if user.TelegramID > 0 {
+		query = query.Or("telegram_id = ?", user.TelegramID)
+	}
+	if phone := strings.ToLower(strings.TrimSpace(user.Phone)); phone != "" {
+		query = query.Or("contact_type = ? AND LOWER(TRIM(contact_value)) = ?", "phone", phone)
+	}
+	if username := strings.ToLower(strings.TrimSpace(user.Username)); username != "" {
+		query = query.Or("contact_type = ? AND LOWER(TRIM(contact_value)) IN ?", "telegram", []string{username, "@" + username})
+	}
+	return query
+}
+
+// Collect возвращает данные пользователя для выгрузки
+func (r *userDataRepository) Collect(userID uuid.UUID) (*UserData, error) {
+	data := &UserData{}
+	db := r.db.Unscoped().Session(&gorm.Session{})
+	if err := db.First(&data.User, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+
+	ids, err := r.collectIDs(r.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	queries := []struct {
+		dest  interface{}
+		query *gorm.DB
+	}{
+		{&data.AssignmentTargets, db.Preload("Assignment").Where("id IN ?", ids.targets).Order("created_at")},
+		{&data.Submissions, db.Where("id IN ?", ids.submissions).Order("created_at")},
+		{&data.Attachments, db.Where("submission_id IN ?", ids.submissions).Order("created_at")},
+		{&data.Feedback, db.Where("id IN ?", ids.feedback).Order("created_at")},
+		{&data.Messages, db.Where("id IN ?", ids.messages).Order("created_at")},
+		{&data.Media, db.Where("id IN ?", ids.media).Order("created_at")},
+		{&data.Notifications, db.Where("user_id = ?", userID).Order("created_at")},
+		{&data.Drafts, db.Where("student_id = ?", userID).Order("created_at")},
+		{&data.TeacherLinks, db.Where("student_id = ?", userID).Order("created_at")},
+		{&data.ParentLinks, db.Where("student_id = ? OR parent_id = ?", userID, userID)},
+		{&data.GroupMemberships, db.Where("user_id = ?", userID).Order("joined_at")},
+		{&data.TrialRequests, trialRequestsOf(db, &data.User).Order("created_at")},
+	}
+	for _, q := range queries {
+		if err := q.query.Find(q.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// Erase безвозвратно удаляет данные пользователя (в обход мягкого удаления)
+// и обезличивает его профиль полями anonymize. Всё выполняется в одной транзакции.
+func (r *userDataRepository) Erase(userID uuid.UUID, anonymize map[string]interface{}) (*ErasureResult, error) {
+	result := &ErasureResult{Deleted: make(map[string]int64)}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Unscoped().First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		ids, err := r.collectIDs(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		var attachments []models.Attachment
+		if err := tx.Where("submission_id IN ?", ids.submissions).Find(&attachments).Error; err != nil {
+			return err
+		}
+		for _, a := range attachments {
+			if a.FilePath != "" {
+				result.FilePaths = append(result.FilePaths, a.FilePath)
+			}
+		}
+
+		db := tx.Unscoped().Session(&gorm.Session{})
+		// Порядок важен: сначала связи и дочерние строки, затем родительские
+		steps := []struct {
+			table string
+			query *gorm.DB
+			model interface{}
+		}{
+			{"message_media", db.Table("message_media").Where("message_id IN ? OR media_id IN ?", ids.messages, ids.media), nil},
+			{"feedback_media", db.Table("feedback_media").Where("feedback_id IN ? OR media_id IN ?", ids.feedback, ids.media), nil},
+			{"media_accesses", db.Where("media_id IN ? OR user_id = ?", ids.media, userID), &models.MediaAccess{}},
+			{"media_views", db.Where("media_id IN ? OR user_id = ?", ids.media, userID), &models.MediaView{}},
+			{"media", db.Where("id IN ?", ids.media), &models.Media{}},
+			{"message_reactions", db.Where("user_id = ? OR message_id IN ?", userID, ids.messages), &models.MessageReaction{}},
+			{"messages", db.Where("id IN ?", ids.messages), &models.Message{}},
+			{"telegram_message_links", db.Where("user_id = ? OR thread_id IN ?", userID, ids.threads), &models.TelegramMessageLink{}},
+			{"chat_read_cursors", db.Where("user_id = ? OR thread_id IN ?", userID, ids.threads), &models.ChatReadCursor{}},
+			{"chat_threads", db.Where("id IN ?", ids.threads), &models.ChatThread{}},
+			{"feedbacks", db.Where("id IN ?", ids.feedback), &models.Feedback{}},
+			{"attachments", db.Where("submission_id IN ?", ids.submissions), &models.Attachment{}},
+			{"submissions", db.Where("id IN ?", ids.submissions), &models.Submission{}},
+			{"drafts", db.Where("student_id = ? OR assignment_target_id IN ?", userID, ids.targets), &models.Draft{}},
+			{"assignment_targets", db.Where("id IN ?", ids.targets), &models.AssignmentTarget{}},
+			{"notifications", db.Where("user_id = ?", userID), &models.Notification{}},
+			{"notification_preferences", db.Where("user_id = ?", userID), &models.NotificationPreference{}},
+			{"notification_settings", db.Where("user_id = ?", userID), &models.NotificationSettings{}},
+			{"sessions", db.Where("user_id = ?", userID), &models.Session{}},
+			{"access_tokens", db.Where("user_id = ?", userID), &models.AccessToken{}},
+			{"teacher_students", db.Where("student_id = ?", userID), &models.TeacherStudent{}},
+			{"parent_students", db.Where("student_id = ? OR parent_id = ?", userID, userID), &models.ParentStudent{}},
+			{"parent_invites", db.Where("student_id = ?", userID), &models.ParentInvite{}},
+			{"group_members", db.Where("user_id = ?", userID), &models.GroupMember{}},
+			{"invite_redemptions", db.Where("user_id = ?", userID), &models.InviteRedemption{}},
+			{"role_changes", db.Where("user_id = ?", userID), &models.RoleChange{}},
+			{"comments", db.Where("author_id = ?", userID), &models.Comment{}},
+			{"user_assignments", db.Where("user_id = ?", userID), &models.UserAssignment{}},
+			{"student_progresses", db.Where("student_id = ?", userID), &models.StudentProgress{}},
+			{"trial_requests", trialRequestsOf(db, &user), &models.TrialRequest{}},
+		}
+		for _, step := range steps {
+			var res *gorm.DB
+			if step.model == nil {
+				// Таблицы связей many2many без собственной модели
+				res = step.query.Delete(map[string]interface{}{})
+			} else {
+				res = step.query.Delete(step.model)
+			}
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected > 0 {
+				result.Deleted[step.table] = res.RowsAffected
+			}
+		}
+
+		// Профиль остается обезличенным: на его ID ссылаются задания преподавателя и журнал изменений
+		return tx.Unscoped().Model(&models.User{}).Where("id = ?", userID).Updates(anonymize).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
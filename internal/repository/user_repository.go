@@ -1,7 +1,6 @@
 package repository
 
 import (
-	"fmt"
 	"time"
 
 	"edubot/internal/models"
@@ -15,13 +14,14 @@ type UserRepository interface {
 	Create(user *models.User) error
 	GetByID(id uuid.UUID) (*models.User, error)
 	GetByTelegramID(telegramID int64) (*models.User, error)
-	GetByInviteCode(code string) (*models.User, error)
 	GetByUsername(username string) (*models.User, error)
+	GetByEmailVerificationToken(tokenHash string) (*models.User, error)
 	Update(user *models.User) error
+	SetBotUnreachable(id uuid.UUID, reason string) error
+	ClearBotUnreachable(telegramID int64) error
 	Delete(id uuid.UUID) error
 	ListStudents() ([]models.User, error)
 	ListByRole(role models.UserRole) ([]models.User, error)
-	GenerateInviteCode() (string, error)
 	SearchByQuery(query string, role string) ([]models.User, error)
 }
 
@@ -63,10 +63,10 @@ func (r *userRepository) GetByTelegramID(telegramID int64) (*models.User, error)
 	return &user, nil
 }
 
-// GetByInviteCode получает пользователя по коду приглашения
-func (r *userRepository) GetByInviteCode(code string) (*models.User, error) {
+// GetByEmailVerificationToken получает пользователя по хэшу токена подтверждения email
+func (r *userRepository) GetByEmailVerificationToken(tokenHash string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("invite_code = ?", code).First(&user).Error
+	err := r.db.Where("email_verification_token = ?", tokenHash).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -88,6 +88,26 @@ func (r *userRepository) Update(user *models.User) error {
 	return r.db.Save(user).Error
 }
 
+// SetBotUnreachable отмечает, что бот не может писать пользователю
+func (r *userRepository) SetBotUnreachable(id uuid.UUID, reason string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"bot_unreachable":        true,
+		"bot_unreachable_reason": reason,
+		"bot_unreachable_at":     time.Now(),
+	}).Error
+}
+
+// ClearBotUnreachable снимает отметку после того, как пользователь снова написал боту
+func (r *userRepository) ClearBotUnreachable(telegramID int64) error {
+	return r.db.Model(&models.User{}).
+		Where("telegram_id = ? AND bot_unreachable = ?", telegramID, true).
+		Updates(map[string]interface{}{
+			"bot_unreachable":        false,
+			"bot_unreachable_reason": "",
+			"bot_unreachable_at":     nil,
+		}).Error
+}
+
 // Delete удаляет пользователя
 func (r *userRepository) Delete(id uuid.UUID) error {
 	return r.db.Delete(&models.User{}, "id = ?", id).Error
@@ -107,20 +127,6 @@ func (r *userRepository) ListByRole(role models.UserRole) ([]models.User, error)
 	return users, err
 }
 
-// GenerateInviteCode генерирует уникальный код приглашения
-func (r *userRepository) GenerateInviteCode() (string, error) {
-	for {
-		code := fmt.Sprintf("EDU%d", time.Now().Unix()%100000)
-
-		var count int64
-		r.db.Model(&models.User{}).Where("invite_code = ?", code).Count(&count)
-
-		if count == 0 {
-			return code, nil
-		}
-	}
-}
-
 // SearchByQuery ищет пользователей по запросу с фильтром по роли
 func (r *userRepository) SearchByQuery(query string, role string) ([]models.User, error) {
 	var users []models.User
@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func mustTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse("2006-01-02 15:04", value)
+	if err != nil {
+		t.Fatalf("bad time %q: %v", value, err)
+	}
+	return parsed
+}
+
+func TestParseCronRejectsInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("ParseCron(%q) expected error", spec)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		spec  string
+		after string
+		want  string
+	}{
+		{"*/10 * * * *", "2024-03-10 12:00", "2024-03-10 12:10"},
+		{"*/10 * * * *", "2024-03-10 12:05", "2024-03-10 12:10"},
+		{"0 * * * *", "2024-03-10 23:30", "2024-03-11 00:00"},
+		{"30 9 * * *", "2024-03-10 09:30", "2024-03-11 09:30"},
+		{"0 9-18/3 * * *", "2024-03-10 13:00", "2024-03-10 15:00"},
+		{"0 0 1,15 * *", "2024-03-02 00:00", "2024-03-15 00:00"},
+		{"0 0 * 2 *", "2024-03-02 00:00", "2025-02-01 00:00"},
+		{"0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		// 2024-03-10 — воскресенье; 7 тоже воскресенье
+		{"0 8 * * 1", "2024-03-10 10:00", "2024-03-11 08:00"},
+		{"0 8 * * 7", "2024-03-11 10:00", "2024-03-17 08:00"},
+		// Заданы оба поля дня — достаточно совпадения любого
+		{"0 0 20 * 1", "2024-03-10 10:00", "2024-03-11 00:00"},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseCron(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tt.spec, err)
+		}
+		got := schedule.Next(mustTime(t, tt.after))
+		if want := mustTime(t, tt.want); !got.Equal(want) {
+			t.Errorf("%q after %s: got %s, want %s", tt.spec, tt.after, got.Format("2006-01-02 15:04"), tt.want)
+		}
+	}
+}
+
+func TestCronNextImpossible(t *testing.T) {
+	schedule, err := ParseCron("0 0 31 2 *")
+	if err != nil {
+		t.Fatalf("ParseCron: %v", err)
+	}
+	if next := schedule.Next(mustTime(t, "2024-01-01 00:00")); !next.IsZero() {
+		t.Errorf("expected zero time, got %s", next)
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	after := mustTime(t, "2024-03-10 12:34")
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"@every 5m", "2024-03-10 12:39"},
+		{"@hourly", "2024-03-10 13:00"},
+		{"@daily", "2024-03-11 00:00"},
+		{"@weekly", "2024-03-17 00:00"},
+		{"  15 * * * *  ", "2024-03-10 13:15"},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q): %v", tt.spec, err)
+		}
+		if got, want := schedule.Next(after), mustTime(t, tt.want); !got.Equal(want) {
+			t.Errorf("%q: got %s, want %s", tt.spec, got.Format("2006-01-02 15:04"), tt.want)
+		}
+	}
+
+	for _, spec := range []string{"", "@every", "@every -1m", "@every soon", "@yearly"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) expected error", spec)
+		}
+	}
+}
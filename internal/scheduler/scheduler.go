@@ -0,0 +1,175 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"edubot/internal/models"
+	"edubot/internal/repository"
+)
+
+// Job описывает именованную фоновую задачу
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      func() error
+}
+
+// Scheduler запускает фоновые задачи по расписанию.
+// Каждый запуск пишется в историю job_runs, а блокировка в job_locks
+// не даёт нескольким инстансам выполнять одну задачу одновременно.
+type Scheduler struct {
+	jobRepo  repository.JobRepository
+	instance string
+	lockTTL  time.Duration
+
+	mu      sync.Mutex
+	jobs    []*Job
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	running bool
+}
+
+// NewScheduler создает планировщик. lockTTL — максимальное время удержания блокировки,
+// после которого её может забрать другой инстанс (на случай падения процесса).
+func NewScheduler(jobRepo repository.JobRepository, lockTTL time.Duration) *Scheduler {
+	hostname, _ := os.Hostname()
+	return &Scheduler{
+		jobRepo:  jobRepo,
+		instance: fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.New().String()[:8]),
+		lockTTL:  lockTTL,
+	}
+}
+
+// Register добавляет задачу с расписанием в формате ParseSchedule
+func (s *Scheduler) Register(name, spec string, run func() error) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return fmt.Errorf("job %s: scheduler already started", name)
+	}
+	for _, job := range s.jobs {
+		if job.Name == name {
+			return fmt.Errorf("job %s already registered", name)
+		}
+	}
+	s.jobs = append(s.jobs, &Job{Name: name, Schedule: schedule, Run: run})
+	return nil
+}
+
+// Start запускает все зарегистрированные задачи; после Stop планировщик можно запустить снова
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return
+	}
+	s.running = true
+	// Канал остановки свой у каждого запуска: закрытый канал прошлого запуска сразу завершил бы циклы
+	s.stop = make(chan struct{})
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(job, s.stop)
+	}
+	log.Printf("Scheduler started with %d jobs (instance %s)", len(s.jobs), s.instance)
+}
+
+// Stop останавливает планировщик и дожидается завершения текущих запусков
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stop)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(job *Job, stop <-chan struct{}) {
+	defer s.wg.Done()
+
+	for {
+		next := job.Schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("Job %s has no upcoming runs, stopping", job.Name)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.runOnce(job)
+		}
+	}
+}
+
+// runOnce выполняет задачу под блокировкой и записывает результат в историю
+func (s *Scheduler) runOnce(job *Job) {
+	acquired, err := s.jobRepo.AcquireLock(job.Name, s.instance, s.lockTTL)
+	if err != nil {
+		log.Printf("Job %s: failed to acquire lock: %v", job.Name, err)
+		return
+	}
+	if !acquired {
+		// Задачу сейчас выполняет другой инстанс
+		return
+	}
+	defer func() {
+		if err := s.jobRepo.ReleaseLock(job.Name, s.instance); err != nil {
+			log.Printf("Job %s: failed to release lock: %v", job.Name, err)
+		}
+	}()
+
+	run := &models.JobRun{
+		JobName:   job.Name,
+		Instance:  s.instance,
+		Status:    models.JobRunStatusRunning,
+		StartedAt: time.Now(),
+	}
+	if err := s.jobRepo.CreateRun(run); err != nil {
+		log.Printf("Job %s: failed to record run: %v", job.Name, err)
+	}
+
+	jobErr := s.safeRun(job)
+
+	status := models.JobRunStatusSuccess
+	errText := ""
+	if jobErr != nil {
+		status = models.JobRunStatusFailed
+		errText = jobErr.Error()
+		log.Printf("Job %s failed: %v", job.Name, jobErr)
+	}
+	if run.ID != uuid.Nil {
+		if err := s.jobRepo.FinishRun(run.ID, status, errText); err != nil {
+			log.Printf("Job %s: failed to finish run: %v", job.Name, err)
+		}
+	}
+}
+
+// safeRun выполняет задачу, превращая панику в ошибку
+func (s *Scheduler) safeRun(job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return job.Run()
+}
@@ -0,0 +1,117 @@
+package scheduler
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"edubot/internal/models"
+)
+
+// memoryJobRepository — JobRepository в памяти: блокировка всегда свободна
+type memoryJobRepository struct {
+	mu   sync.Mutex
+	runs []*models.JobRun
+}
+
+func (r *memoryJobRepository) CreateRun(run *models.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run.ID = uuid.New()
+	r.runs = append(r.runs, run)
+	return nil
+}
+
+func (r *memoryJobRepository) FinishRun(id uuid.UUID, status models.JobRunStatus, errText string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, run := range r.runs {
+		if run.ID == id {
+			run.Status = status
+			run.Error = errText
+		}
+	}
+	return nil
+}
+
+func (r *memoryJobRepository) ListRuns(jobName string, limit int) ([]*models.JobRun, error) {
+	return nil, nil
+}
+
+func (r *memoryJobRepository) AcquireLock(jobName, owner string, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (r *memoryJobRepository) ReleaseLock(jobName, owner string) error {
+	return nil
+}
+
+func waitForRun(t *testing.T, runs <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-runs:
+	case <-time.After(2 * time.Second):
+		t.Fatal("job did not run")
+	}
+}
+
+func TestSchedulerRestart(t *testing.T) {
+	s := NewScheduler(&memoryJobRepository{}, time.Minute)
+	runs := make(chan struct{}, 100)
+	if err := s.Register("tick", "@every 10ms", func() error {
+		runs <- struct{}{}
+		return nil
+	}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	s.Start()
+	waitForRun(t, runs)
+	s.Stop()
+
+	// После повторного Start задачи снова выполняются
+	for len(runs) > 0 {
+		<-runs
+	}
+	s.Start()
+	waitForRun(t, runs)
+	s.Stop()
+	s.Stop() // Повторная остановка ничего не делает
+}
+
+func TestSchedulerRecordsFailures(t *testing.T) {
+	repo := &memoryJobRepository{}
+	s := NewScheduler(repo, time.Minute)
+	job := &Job{Name: "boom", Schedule: Every(time.Hour), Run: func() error { panic("boom") }}
+
+	s.runOnce(job)
+
+	if len(repo.runs) != 1 {
+		t.Fatalf("expected 1 run, got %d", len(repo.runs))
+	}
+	if run := repo.runs[0]; run.Status != models.JobRunStatusFailed || run.Error == "" {
+		t.Errorf("expected failed run with error, got %s %q", run.Status, run.Error)
+	}
+}
+
+func TestRegisterRejectsDuplicatesAndRunning(t *testing.T) {
+	s := NewScheduler(&memoryJobRepository{}, time.Minute)
+	noop := func() error { return nil }
+	if err := s.Register("job", "@hourly", noop); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := s.Register("job", "@hourly", noop); err == nil {
+		t.Error("expected duplicate job error")
+	}
+	if err := s.Register("bad", "not a schedule", noop); err == nil {
+		t.Error("expected schedule error")
+	}
+
+	s.Start()
+	defer s.Stop()
+	if err := s.Register("late", "@hourly", noop); err == nil {
+		t.Error("expected error when registering on a running scheduler")
+	}
+}
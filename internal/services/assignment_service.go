@@ -2,6 +2,7 @@ package services
 
 import (
 	"edubot/internal/models"
+	"edubot/internal/policy"
 	"edubot/internal/repository"
 	"edubot/pkg/telegram"
 	"errors"
@@ -15,14 +16,18 @@ type LegacyAssignmentService struct {
 	userRepo       repository.UserRepository
 	mediaService   MediaService
 	telegramBot    *telegram.Bot
+	policy         policy.Policy
+	audit          AuditService
 }
 
-func NewLegacyAssignmentService(assignmentRepo repository.AssignmentRepository, userRepo repository.UserRepository, mediaService MediaService, telegramBot *telegram.Bot) *LegacyAssignmentService {
+func NewLegacyAssignmentService(assignmentRepo repository.AssignmentRepository, userRepo repository.UserRepository, mediaService MediaService, telegramBot *telegram.Bot, pol policy.Policy, audit AuditService) *LegacyAssignmentService {
 	return &LegacyAssignmentService{
 		assignmentRepo: assignmentRepo,
 		userRepo:       userRepo,
 		mediaService:   mediaService,
 		telegramBot:    telegramBot,
+		policy:         pol,
+		audit:          audit,
 	}
 }
 
@@ -37,7 +42,7 @@ func (s *LegacyAssignmentService) CreateAssignment(assignment *models.Assignment
 	if assignment.StudentID != nil {
 		student, err := s.userRepo.GetByID(*assignment.StudentID)
 		if err == nil && student.TelegramID != 0 {
-			s.telegramBot.SendAssignmentNotification(student.TelegramID, assignment.Title, assignment.Subject, assignment.DueDate.Format("02.01.2006 15:04"))
+			s.telegramBot.SendAssignmentNotification(student.TelegramID, assignment.Title, assignment.Subject, student.FormatTime(assignment.DueDate))
 		}
 	}
 	return nil
@@ -59,8 +64,31 @@ func (s *LegacyAssignmentService) GetUpcomingDeadlines(studentID uuid.UUID, days
 	return s.assignmentRepo.GetUpcomingDeadlines(studentID, days)
 }
 
-func (s *LegacyAssignmentService) UpdateAssignment(assignment *models.Assignment) error {
-	return s.assignmentRepo.Update(assignment)
+// UpdateAssignment сохраняет изменения задания, если actor вправе его редактировать
+func (s *LegacyAssignmentService) UpdateAssignment(actor Actor, assignment *models.Assignment) error {
+	// Права и состояние «до» берем из сохраненной версии, а не из присланной
+	current, err := s.assignmentRepo.GetByID(assignment.ID)
+	if err != nil {
+		return err
+	}
+	if !s.policy.Can(actor.User, policy.ActionEditAssignment, assignmentResource(current)) {
+		return ErrAccessDenied
+	}
+	before := assignmentSnapshot(current)
+
+	if err := s.assignmentRepo.Update(assignment); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:     AuditActionUpdate,
+		EntityType: AuditEntityAssignment,
+		EntityID:   assignment.ID,
+		OwnerID:    &current.TeacherID,
+		Before:     before,
+		After:      assignmentSnapshot(assignment),
+	})
+	return nil
 }
 
 func (s *LegacyAssignmentService) MarkAssignmentCompleted(assignmentID uuid.UUID, studentID uuid.UUID) error {
@@ -77,25 +105,41 @@ func (s *LegacyAssignmentService) MarkAssignmentCompleted(assignmentID uuid.UUID
 
 	teacher, err := s.userRepo.GetByID(assignment.TeacherID)
 	if err == nil && teacher.TelegramID != 0 {
-		s.telegramBot.SendAssignmentCompletedNotification(teacher.TelegramID, assignment.Title, assignment.Subject, assignment.DueDate.Format("02.01.2006 15:04"))
+		s.telegramBot.SendAssignmentCompletedNotification(teacher.TelegramID, assignment.Title, assignment.Subject, teacher.FormatTime(assignment.DueDate))
 	}
 
 	s.updateStudentProgress(studentID, assignment.Subject)
 	return nil
 }
 
-func (s *LegacyAssignmentService) DeleteAssignment(assignmentID uuid.UUID, teacherID uuid.UUID) error {
-	// Проверяем, что задание принадлежит учителю
+func (s *LegacyAssignmentService) DeleteAssignment(assignmentID uuid.UUID, actor Actor) error {
+	// Удалять задание может только его преподаватель (ассистентам нельзя)
 	assignment, err := s.assignmentRepo.GetByID(assignmentID)
 	if err != nil {
 		return err
 	}
 
-	if assignment.TeacherID != teacherID {
-		return errors.New("assignment does not belong to teacher")
+	if !s.policy.Can(actor.User, policy.ActionDeleteAssignment, assignmentResource(assignment)) {
+		return ErrAccessDenied
+	}
+
+	if err := s.assignmentRepo.Delete(assignmentID); err != nil {
+		return err
 	}
 
-	return s.assignmentRepo.Delete(assignmentID)
+	s.audit.Record(actor, AuditEntry{
+		Action:     AuditActionDelete,
+		EntityType: AuditEntityAssignment,
+		EntityID:   assignmentID,
+		OwnerID:    &assignment.TeacherID,
+		Before:     assignmentSnapshot(assignment),
+	})
+	return nil
+}
+
+// assignmentResource описывает задание для проверки политикой доступа
+func assignmentResource(assignment *models.Assignment) policy.Resource {
+	return policy.Resource{TeacherID: assignment.TeacherID, GroupID: assignment.GroupID}
 }
 
 // Comment methods
@@ -352,7 +396,7 @@ func (s *LegacyAssignmentService) SubmitAssignmentWithMedia(assignmentID uuid.UU
 			teacher.TelegramID,
 			assignment.Title,
 			assignment.Subject,
-			assignment.DueDate.Format("2006-01-02 15:04"),
+			teacher.FormatTime(assignment.DueDate),
 		)
 	}
 
@@ -1,13 +1,14 @@
 package services
 
 import (
+	"fmt"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
 
 	"edubot/internal/models"
 	"edubot/internal/repository"
-	"edubot/pkg/telegram"
 )
 
 type AssignmentService interface {
@@ -37,8 +38,8 @@ type assignmentService struct {
 	assignmentTargetRepo repository.AssignmentTargetRepository
 	groupRepo            repository.GroupRepository
 	userRepo             repository.UserRepository
-	notificationRepo     repository.NotificationRepository
-	bot                  *telegram.Bot
+	teacherStudentRepo   repository.TeacherStudentRepository
+	notificationService  NotificationService
 }
 
 func NewAssignmentService(
@@ -46,20 +47,31 @@ func NewAssignmentService(
 	assignmentTargetRepo repository.AssignmentTargetRepository,
 	groupRepo repository.GroupRepository,
 	userRepo repository.UserRepository,
-	notificationRepo repository.NotificationRepository,
-	bot *telegram.Bot,
+	teacherStudentRepo repository.TeacherStudentRepository,
+	notificationService NotificationService,
 ) AssignmentService {
 	return &assignmentService{
 		assignmentRepo:       assignmentRepo,
 		assignmentTargetRepo: assignmentTargetRepo,
 		groupRepo:            groupRepo,
 		userRepo:             userRepo,
-		notificationRepo:     notificationRepo,
-		bot:                  bot,
+		teacherStudentRepo:   teacherStudentRepo,
+		notificationService:  notificationService,
 	}
 }
 
 func (s *assignmentService) CreateAssignment(assignment *models.Assignment) error {
+	// Индивидуальное задание можно выдать только своему ученику
+	if assignment.StudentID != nil {
+		linked, err := s.teacherStudentRepo.IsLinked(assignment.TeacherID, *assignment.StudentID)
+		if err != nil {
+			return err
+		}
+		if !linked {
+			return ErrStudentNotLinked
+		}
+	}
+
 	if assignment.ID == uuid.Nil {
 		assignment.ID = uuid.New()
 	}
@@ -120,30 +132,22 @@ func (s *assignmentService) CreateGroupAssignment(teacherID, groupID uuid.UUID,
 		return nil, err
 	}
 
-	// Отправляем уведомления участникам группы
-	if s.bot != nil {
-		for _, member := range members {
-			student, err := s.userRepo.GetByID(member.UserID)
-			if err == nil && student.TelegramID != 0 {
-				s.bot.SendAssignmentNotification(
-					student.TelegramID,
-					assignment.Title,
-					assignment.Subject,
-					assignment.DueDate.Format("2006-01-02 15:04"),
-				)
-			}
+	// Уведомляем участников группы; дедлайн показываем в часовом поясе каждого ученика
+	payload := `{"assignment_id":"` + assignment.ID.String() + `","group_id":"` + groupID.String() + `"}`
+	for _, member := range members {
+		student, err := s.userRepo.GetByID(member.UserID)
+		if err != nil {
+			continue
+		}
+		if err := s.notificationService.CreateNotification(&models.Notification{
+			UserID:  student.ID,
+			Type:    models.NotificationTypeNewAssignment,
+			Title:   "Новое задание",
+			Message: fmt.Sprintf("%s\nПредмет: %s\nДедлайн: %s", assignment.Title, assignment.Subject, student.FormatTime(assignment.DueDate)),
+			Payload: payload,
+		}); err != nil {
+			log.Printf("Failed to create assignment notification for %s: %v", student.ID, err)
 		}
-	}
-
-	// Создаем уведомления в системе
-	if len(studentIDs) > 0 {
-		s.notificationRepo.CreateForGroup(
-			studentIDs,
-			models.NotificationTypeNewAssignment,
-			"Новое задание",
-			assignment.Title,
-			`{"assignment_id":"`+assignment.ID.String()+`","group_id":"`+groupID.String()+`"}`,
-		)
 	}
 
 	return assignment, nil
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"edubot/internal/models"
+	"edubot/internal/repository"
+)
+
+// Типы объектов журнала изменений
+const (
+	AuditEntityAssignment       = "assignment"
+	AuditEntityAssignmentTarget = "assignment_target"
+	AuditEntityMediaAccess      = "media_access"
+	AuditEntityTrialRequest     = "trial_request"
+)
+
+// Действия журнала изменений
+const (
+	AuditActionUpdate       = "update"
+	AuditActionDelete       = "delete"
+	AuditActionGrade        = "grade"
+	AuditActionGrantAccess  = "grant_access"
+	AuditActionRevokeAccess = "revoke_access"
+	AuditActionApprove      = "approve"
+	AuditActionReject       = "reject"
+	AuditActionHide         = "hide"
+)
+
+// Actor — кто выполняет действие: пользователь для проверки прав и IP для журнала
+type Actor struct {
+	User *models.User // nil — система (фоновые задачи)
+	IP   string
+}
+
+// UserID возвращает ID пользователя или nil для системы
+func (a Actor) UserID() *uuid.UUID {
+	if a.User == nil {
+		return nil
+	}
+	return &a.User.ID
+}
+
+// AuditEntry — описание изменения для журнала
+type AuditEntry struct {
+	Action     string
+	EntityType string
+	EntityID   uuid.UUID
+	OwnerID    *uuid.UUID  // Преподаватель, чьи данные изменены
+	Before     interface{} // Состояние до изменения (сериализуется в JSON)
+	After      interface{} // Состояние после изменения
+}
+
+type AuditService interface {
+	Record(actor Actor, entry AuditEntry)
+	List(filter repository.AuditLogFilter) ([]models.AuditLog, int64, error)
+}
+
+type auditService struct {
+	auditRepo repository.AuditLogRepository
+}
+
+func NewAuditService(auditRepo repository.AuditLogRepository) AuditService {
+	return &auditService{auditRepo: auditRepo}
+}
+
+// Record пишет запись в журнал. Ошибка записи только логируется:
+// действие уже выполнено и не должно откатываться из-за журнала.
+func (s *auditService) Record(actor Actor, entry AuditEntry) {
+	record := &models.AuditLog{
+		ID:         uuid.New(),
+		ActorID:    actor.UserID(),
+		OwnerID:    entry.OwnerID,
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		Before:     auditJSON(entry.Before),
+		After:      auditJSON(entry.After),
+		IP:         actor.IP,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.auditRepo.Create(record); err != nil {
+		log.Printf("Failed to write audit log (%s %s %s): %v", entry.Action, entry.EntityType, entry.EntityID, err)
+	}
+}
+
+func (s *auditService) List(filter repository.AuditLogFilter) ([]models.AuditLog, int64, error) {
+	return s.auditRepo.List(filter)
+}
+
+func auditJSON(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Failed to encode audit state: %v", err)
+		return ""
+	}
+	return string(data)
+}
+
+// assignmentSnapshot — поля задания, которые стоит видеть в журнале (без связей)
+func assignmentSnapshot(a *models.Assignment) map[string]interface{} {
+	return map[string]interface{}{
+		"title":       a.Title,
+		"description": a.Description,
+		"subject":     a.Subject,
+		"grade":       a.Grade,
+		"level":       a.Level,
+		"teacher_id":  a.TeacherID,
+		"group_id":    a.GroupID,
+		"student_id":  a.StudentID,
+		"due_date":    a.DueDate,
+		"status":      a.Status,
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"edubot/internal/models"
+)
+
+var (
+	ErrInvalidAccessToken     = errors.New("invalid, expired or revoked access token")
+	ErrInvalidTokenScope      = errors.New("unknown access token scope")
+	ErrAccessTokenNameMissing = errors.New("access token name is required")
+	ErrAccessTokenNotFound    = errors.New("access token not found")
+)
+
+// accessTokenTouchInterval — last_used_at обновляется не чаще, чтобы не писать в БД на каждый запрос
+const accessTokenTouchInterval = time.Minute
+
+// AccessTokenParams — параметры нового персонального токена
+type AccessTokenParams struct {
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time // nil — бессрочный
+}
+
+// IsAccessToken сообщает, что строка — персональный токен, а не JWT
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, models.AccessTokenPrefix)
+}
+
+// CreateAccessToken выпускает персональный токен. Сам токен возвращается только здесь, в БД хранится его хэш.
+func (s *AuthService) CreateAccessToken(userID uuid.UUID, params AccessTokenParams) (*models.AccessToken, string, error) {
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		return nil, "", ErrAccessTokenNameMissing
+	}
+	scopes, err := normalizeScopes(params.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if params.ExpiresAt != nil && !params.ExpiresAt.After(time.Now()) {
+		return nil, "", errors.New("expiration must be in the future")
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return nil, "", err
+	}
+	raw := models.AccessTokenPrefix + secret
+
+	token := &models.AccessToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashToken(raw),
+		Prefix:    raw[:len(models.AccessTokenPrefix)+8],
+		Scopes:    strings.Join(scopes, " "),
+		ExpiresAt: params.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+	if err := s.accessTokenRepo.Create(token); err != nil {
+		return nil, "", err
+	}
+	return token, raw, nil
+}
+
+// ListAccessTokens возвращает токены пользователя
+func (s *AuthService) ListAccessTokens(userID uuid.UUID) ([]*models.AccessToken, error) {
+	return s.accessTokenRepo.ListByUser(userID)
+}
+
+// RevokeAccessToken отзывает токен пользователя
+func (s *AuthService) RevokeAccessToken(userID, tokenID uuid.UUID) error {
+	revoked, err := s.accessTokenRepo.Revoke(tokenID, userID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAccessTokenNotFound
+	}
+	return nil
+}
+
+// ValidateAccessTokenSecret проверяет персональный токен и отмечает его использование
+func (s *AuthService) ValidateAccessTokenSecret(raw string) (*models.User, *models.AccessToken, error) {
+	if !IsAccessToken(raw) {
+		return nil, nil, ErrInvalidAccessToken
+	}
+	token, err := s.accessTokenRepo.GetByHash(hashToken(raw))
+	if err != nil {
+		return nil, nil, ErrInvalidAccessToken
+	}
+	now := time.Now()
+	if !token.Active(now) {
+		return nil, nil, ErrInvalidAccessToken
+	}
+
+	user, err := s.userRepo.GetByID(token.UserID)
+	if err != nil {
+		return nil, nil, ErrInvalidAccessToken
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= accessTokenTouchInterval {
+		if err := s.accessTokenRepo.TouchLastUsed(token.ID, now); err != nil {
+			log.Printf("Failed to update last use of access token %s: %v", token.ID, err)
+		}
+		token.LastUsedAt = &now
+	}
+	return user, token, nil
+}
+
+// normalizeScopes проверяет области действия и убирает повторы
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, ErrInvalidTokenScope
+	}
+	known := make(map[string]bool, len(models.AccessTokenScopes))
+	for _, scope := range models.AccessTokenScopes {
+		known[scope] = true
+	}
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !known[scope] {
+			return nil, ErrInvalidTokenScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"edubot/internal/models"
+	"edubot/internal/repository"
+)
+
+var (
+	// ErrNotTeacher — пользователь не является преподавателем
+	ErrNotTeacher = errors.New("user is not a teacher")
+	// ErrRoleChangeNotAllowed — роль этого пользователя нельзя менять через API
+	ErrRoleChangeNotAllowed = errors.New("role change is not allowed for this user")
+)
+
+// Причины смены роли
+const (
+	RoleChangeBootstrap  = "bootstrap" // Начальная роль из env
+	RoleChangeInvited    = "invited"
+	RoleChangePromoted   = "promoted"
+	RoleChangeDeactivate = "deactivated"
+	RoleChangeStudent    = "assigned_student"
+	RoleChangeParent     = "parent_invite"
+	RoleChangeInvite     = "invite_code"
+	RoleChangeSelected   = "selected_by_user"
+)
+
+// TeacherInviteParams — данные преподавателя, которого приглашает администратор
+type TeacherInviteParams struct {
+	TelegramID int64
+	Username   string
+	FirstName  string
+	LastName   string
+}
+
+// bootstrapRole — роль нового пользователя по спискам из env
+func (s *AuthService) bootstrapRole(telegramID int64) models.UserRole {
+	if _, ok := s.adminTelegramIDs[telegramID]; ok {
+		return models.RoleAdmin
+	}
+	if _, ok := s.teacherTelegramIDs[telegramID]; ok || telegramID == s.teacherTelegramID {
+		return models.RoleTeacher
+	}
+	return models.RoleGuest
+}
+
+// BootstrapAdmins назначает администраторами уже зарегистрированных пользователей из ADMIN_TELEGRAM_IDS.
+// Новые пользователи из списка получают роль при первом входе.
+func (s *AuthService) BootstrapAdmins() error {
+	for telegramID := range s.adminTelegramIDs {
+		user, err := s.userRepo.GetByTelegramID(telegramID)
+		if err != nil {
+			continue
+		}
+		if err := s.setRole(user, models.RoleAdmin, nil, RoleChangeBootstrap); err != nil {
+			return fmt.Errorf("failed to bootstrap admin %d: %w", telegramID, err)
+		}
+	}
+	return nil
+}
+
+// ListTeachers возвращает всех преподавателей
+func (s *AuthService) ListTeachers() ([]models.User, error) {
+	return s.userRepo.ListByRole(models.RoleTeacher)
+}
+
+// InviteTeacher делает пользователя с указанным Telegram ID преподавателем.
+// Если он еще не заходил, учетная запись создается заранее.
+func (s *AuthService) InviteTeacher(adminID uuid.UUID, params TeacherInviteParams) (*models.User, error) {
+	if params.TelegramID == 0 {
+		return nil, fmt.Errorf("telegram id is required")
+	}
+
+	if user, err := s.userRepo.GetByTelegramID(params.TelegramID); err == nil {
+		return s.PromoteToTeacher(adminID, user.ID)
+	}
+
+	now := time.Now()
+	user := &models.User{
+		ID:         uuid.New(),
+		TelegramID: params.TelegramID,
+		Username:   params.Username,
+		FirstName:  params.FirstName,
+		LastName:   params.LastName,
+		Role:       models.RoleTeacher,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, fmt.Errorf("failed to create teacher: %w", err)
+	}
+	s.recordRoleChange(user.ID, models.RoleGuest, models.RoleTeacher, &adminID, RoleChangeInvited)
+	s.notifyRole(user, "👩‍🏫 Вам открыт доступ преподавателя. Откройте мини‑приложение, чтобы начать работу.")
+	return user, nil
+}
+
+// PromoteToTeacher назначает существующего пользователя преподавателем
+func (s *AuthService) PromoteToTeacher(adminID, userID uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == models.RoleAdmin {
+		return nil, ErrRoleChangeNotAllowed
+	}
+	if user.Role == models.RoleTeacher {
+		return user, nil
+	}
+
+	if err := s.setRole(user, models.RoleTeacher, &adminID, RoleChangePromoted); err != nil {
+		return nil, err
+	}
+	s.notifyRole(user, "👩‍🏫 Вам открыт доступ преподавателя. Откройте мини‑приложение, чтобы начать работу.")
+	return user, nil
+}
+
+// DeactivateTeacher снимает роль преподавателя и завершает его сессии.
+// Данные преподавателя (задания, группы, связи с учениками) сохраняются.
+func (s *AuthService) DeactivateTeacher(adminID, userID uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != models.RoleTeacher {
+		return nil, ErrNotTeacher
+	}
+
+	if err := s.setRole(user, models.RoleGuest, &adminID, RoleChangeDeactivate); err != nil {
+		return nil, err
+	}
+	if err := s.RevokeAllSessions(user.ID, SessionRevokedRole); err != nil {
+		log.Printf("Failed to revoke sessions of deactivated teacher %s: %v", user.ID, err)
+	}
+	if err := s.accessTokenRepo.RevokeAllByUser(user.ID); err != nil {
+		log.Printf("Failed to revoke access tokens of deactivated teacher %s: %v", user.ID, err)
+	}
+	return user, nil
+}
+
+// ListRoleChanges возвращает историю смены ролей (всю или одного пользователя)
+func (s *AuthService) ListRoleChanges(userID *uuid.UUID, limit int) ([]models.RoleChange, error) {
+	return s.roleChangeRepo.List(userID, limit)
+}
+
+// setRole меняет роль пользователя и записывает, кто ее изменил
+func (s *AuthService) setRole(user *models.User, role models.UserRole, changedBy *uuid.UUID, reason string) error {
+	if user.Role == role {
+		return nil
+	}
+	oldRole := user.Role
+	user.Role = role
+	if err := s.userRepo.Update(user); err != nil {
+		user.Role = oldRole
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	s.recordRoleChange(user.ID, oldRole, role, changedBy, reason)
+	return nil
+}
+
+func (s *AuthService) recordRoleChange(userID uuid.UUID, oldRole, newRole models.UserRole, changedBy *uuid.UUID, reason string) {
+	recordRoleChange(s.roleChangeRepo, userID, oldRole, newRole, changedBy, reason)
+}
+
+// recordRoleChange пишет историю; ошибка записи не отменяет уже выполненную смену роли
+func recordRoleChange(repo repository.RoleChangeRepository, userID uuid.UUID, oldRole, newRole models.UserRole, changedBy *uuid.UUID, reason string) {
+	change := &models.RoleChange{
+		ID:        uuid.New(),
+		UserID:    userID,
+		OldRole:   oldRole,
+		NewRole:   newRole,
+		ChangedBy: changedBy,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+	if err := repo.Create(change); err != nil {
+		log.Printf("Failed to record role change of user %s (%s -> %s): %v", userID, oldRole, newRole, err)
+	}
+}
+
+func (s *AuthService) notifyRole(user *models.User, message string) {
+	if s.telegramBot != nil && user.TelegramID != 0 {
+		s.telegramBot.SendMessage(user.TelegramID, message)
+	}
+}
@@ -1,7 +1,11 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"edubot/internal/models"
@@ -16,35 +20,89 @@ import (
 type AuthService struct {
 	userRepo           repository.UserRepository
 	trialRepo          *repository.TrialRequestRepository
+	sessionRepo        repository.SessionRepository
+	accessTokenRepo    repository.AccessTokenRepository
+	teacherStudentRepo repository.TeacherStudentRepository
+	roleChangeRepo     repository.RoleChangeRepository
+	audit              AuditService
 	telegramBot        *telegram.Bot
 	jwtSecret          string
+	accessTTL          time.Duration
+	refreshTTL         time.Duration
+	trialDuplicateTTL  time.Duration
 	teacherTelegramID  int64
 	teacherTelegramIDs map[int64]struct{}
+	adminTelegramIDs   map[int64]struct{}
 	teacherPassword    string
+	botToken           string
+	authMaxAge         time.Duration
+	skipAuthValidation bool
+}
+
+var (
+	// ErrInvalidTelegramAuth — подпись данных Telegram не прошла проверку или данные устарели
+	ErrInvalidTelegramAuth = errors.New("invalid telegram auth data")
+	// ErrStudentNotLinked — ученик не занимается у этого преподавателя
+	ErrStudentNotLinked = errors.New("student is not linked to this teacher")
+	// ErrDuplicateTrialRequest — с этим контактом недавно уже оставляли заявку
+	ErrDuplicateTrialRequest = errors.New("a trial request with this contact was already submitted")
+)
+
+// TelegramAuthConfig — параметры проверки данных авторизации Telegram
+type TelegramAuthConfig struct {
+	BotToken string
+	MaxAge   time.Duration // Максимальный возраст auth_date
+	DevMode  bool          // Пропускать проверку подписи (только для локальной разработки)
 }
 
 // NewAuthService создает новый сервис авторизации
 func NewAuthService(
 	userRepo repository.UserRepository,
 	trialRepo *repository.TrialRequestRepository,
+	sessionRepo repository.SessionRepository,
+	accessTokenRepo repository.AccessTokenRepository,
+	teacherStudentRepo repository.TeacherStudentRepository,
+	roleChangeRepo repository.RoleChangeRepository,
+	audit AuditService,
 	telegramBot *telegram.Bot,
 	jwtSecret string,
+	accessTTL time.Duration,
+	refreshTTL time.Duration,
+	trialDuplicateTTL time.Duration,
 	teacherTelegramID int64,
 	teacherTelegramIDs []int64,
+	adminTelegramIDs []int64,
 	teacherPassword string,
+	telegramAuth TelegramAuthConfig,
 ) *AuthService {
 	idSet := make(map[int64]struct{})
 	for _, id := range teacherTelegramIDs {
 		idSet[id] = struct{}{}
 	}
+	adminSet := make(map[int64]struct{})
+	for _, id := range adminTelegramIDs {
+		adminSet[id] = struct{}{}
+	}
 	return &AuthService{
 		userRepo:           userRepo,
 		trialRepo:          trialRepo,
+		sessionRepo:        sessionRepo,
+		accessTokenRepo:    accessTokenRepo,
+		teacherStudentRepo: teacherStudentRepo,
+		roleChangeRepo:     roleChangeRepo,
+		audit:              audit,
 		telegramBot:        telegramBot,
 		jwtSecret:          jwtSecret,
+		accessTTL:          accessTTL,
+		refreshTTL:         refreshTTL,
+		trialDuplicateTTL:  trialDuplicateTTL,
 		teacherTelegramID:  teacherTelegramID,
 		teacherTelegramIDs: idSet,
+		adminTelegramIDs:   adminSet,
 		teacherPassword:    teacherPassword,
+		botToken:           telegramAuth.BotToken,
+		authMaxAge:         telegramAuth.MaxAge,
+		skipAuthValidation: telegramAuth.DevMode,
 	}
 }
 
@@ -57,23 +115,34 @@ type TelegramAuthData struct {
 	PhotoURL  string `json:"photo_url"`
 	AuthDate  int64  `json:"auth_date"`
 	Hash      string `json:"hash"`
+	InitData  string `json:"init_data"` // Telegram.WebApp.initData из Mini App
 }
 
 // AuthResult представляет результат авторизации
 type AuthResult struct {
 	User           *models.User `json:"user"`
-	Token          string       `json:"token"`
 	IsNewUser      bool         `json:"is_new_user"`
 	Role           string       `json:"role"`
 	AllowedTeacher bool         `json:"allowed_teacher"`
+	*TokenPair
 }
 
-// AuthenticateWithTelegram авторизует пользователя через Telegram
-func (s *AuthService) AuthenticateWithTelegram(authData *TelegramAuthData) (*AuthResult, error) {
-	// Проверяем подпись данных (в реальном приложении)
-	// if !s.validateTelegramAuth(authData) {
-	//     return nil, fmt.Errorf("invalid telegram auth data")
-	// }
+// VerifyTelegramAuth проверяет подпись данных Telegram и заменяет поля authData проверенными
+// (для Mini App — данными из init_data). После проверки authData.ID можно использовать как ключ.
+func (s *AuthService) VerifyTelegramAuth(authData *TelegramAuthData) error {
+	if err := s.validateTelegramAuth(authData); err != nil {
+		log.Printf("Rejected telegram auth for %d: %v", authData.ID, err)
+		return ErrInvalidTelegramAuth
+	}
+	return nil
+}
+
+// AuthenticateWithTelegram авторизует пользователя через Telegram и открывает новую сессию.
+// Подпись проверяется и здесь, так что предварительный VerifyTelegramAuth не обязателен.
+func (s *AuthService) AuthenticateWithTelegram(authData *TelegramAuthData, client ClientInfo) (*AuthResult, error) {
+	if err := s.VerifyTelegramAuth(authData); err != nil {
+		return nil, err
+	}
 
 	// Ищем существующего пользователя
 	user, err := s.userRepo.GetByTelegramID(authData.ID)
@@ -90,9 +159,9 @@ func (s *AuthService) AuthenticateWithTelegram(authData *TelegramAuthData) (*Aut
 			InviteCode: nil,              // Пустой invite code
 		}
 
-		// Проверяем, является ли пользователь преподавателем
-		if _, ok := s.teacherTelegramIDs[authData.ID]; ok || authData.ID == s.teacherTelegramID {
-			user.Role = models.RoleTeacher
+		// Начальная роль из env; дальше ролями управляет администратор
+		if role := s.bootstrapRole(authData.ID); role != models.RoleGuest {
+			user.Role = role
 		}
 
 		if err := s.userRepo.Create(user); err != nil {
@@ -105,6 +174,9 @@ func (s *AuthService) AuthenticateWithTelegram(authData *TelegramAuthData) (*Aut
 			isNewUser = false
 		} else {
 			isNewUser = true
+			if user.Role != models.RoleGuest {
+				s.recordRoleChange(user.ID, models.RoleGuest, user.Role, nil, RoleChangeBootstrap)
+			}
 		}
 	} else {
 		// Обновляем данные существующего пользователя
@@ -116,50 +188,62 @@ func (s *AuthService) AuthenticateWithTelegram(authData *TelegramAuthData) (*Aut
 		}
 	}
 
-	// Генерируем JWT токен
-	token, err := s.generateJWT(user)
+	// Открываем сессию и выдаем токены
+	tokens, err := s.startSession(user, client)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
 	return &AuthResult{
 		User:           user,
-		Token:          token,
+		TokenPair:      tokens,
 		IsNewUser:      isNewUser,
 		Role:           string(user.Role),
 		AllowedTeacher: s.IsTeacherTelegram(user.TelegramID),
 	}, nil
 }
 
-// RegisterStudent регистрирует ученика по коду приглашения
-func (s *AuthService) RegisterStudent(telegramID int64, inviteCode string, phone string, grade int, subjects string) error {
-	// Получаем пользователя
-	user, err := s.userRepo.GetByTelegramID(telegramID)
-	if err != nil {
-		return fmt.Errorf("user not found: %w", err)
+// EnsureTelegramUser возвращает пользователя по Telegram ID, а при первом обращении
+// (например, переход по ссылке в бота до входа в приложение) создает его
+func (s *AuthService) EnsureTelegramUser(telegramID int64, firstName, lastName, username string) (*models.User, error) {
+	if user, err := s.userRepo.GetByTelegramID(telegramID); err == nil {
+		return user, nil
 	}
 
-	// Проверяем код приглашения (в реальном приложении нужно проверить валидность кода)
-	if inviteCode == "" {
-		return fmt.Errorf("invite code is required")
+	user := &models.User{
+		ID:         uuid.New(),
+		TelegramID: telegramID,
+		FirstName:  firstName,
+		LastName:   lastName,
+		Username:   username,
+		Role:       s.bootstrapRole(telegramID),
 	}
-
-	// Обновляем данные пользователя
-	user.Role = models.RoleStudent
-	user.Phone = phone
-	user.Grade = grade
-	user.Subjects = subjects
-	user.InviteCode = &inviteCode
-
-	if err := s.userRepo.Update(user); err != nil {
-		return fmt.Errorf("failed to register student: %w", err)
+	if err := s.userRepo.Create(user); err != nil {
+		// Пользователя могли создать параллельно
+		if existing, gerr := s.userRepo.GetByTelegramID(telegramID); gerr == nil {
+			return existing, nil
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
-
-	return nil
+	if user.Role != models.RoleGuest {
+		s.recordRoleChange(user.ID, models.RoleGuest, user.Role, nil, RoleChangeBootstrap)
+	}
+	return user, nil
 }
 
 // SubmitTrialRequest создает заявку на пробное занятие
 func (s *AuthService) SubmitTrialRequest(request *models.TrialRequest) error {
+	// Повторная заявка с тем же контактом не создается и не беспокоит преподавателя еще раз
+	if s.trialDuplicateTTL > 0 {
+		duplicate, err := s.trialRepo.ExistsByContactSince(request.ContactType, request.ContactValue, time.Now().Add(-s.trialDuplicateTTL))
+		if err != nil {
+			return fmt.Errorf("failed to check trial requests: %w", err)
+		}
+		if duplicate {
+			return ErrDuplicateTrialRequest
+		}
+	}
+
 	// Создаем заявку
 	if err := s.trialRepo.Create(request); err != nil {
 		return fmt.Errorf("failed to create trial request: %w", err)
@@ -177,24 +261,25 @@ func (s *AuthService) SubmitTrialRequest(request *models.TrialRequest) error {
 		"created_at":    request.CreatedAt.Format("02.01.2006 15:04"),
 	}
 
-	fmt.Printf("Sending trial request notification to teacher ID: %d\n", s.teacherTelegramID)
+	if s.telegramBot == nil {
+		return nil
+	}
 	if err := s.telegramBot.SendTrialRequestNotification(s.teacherTelegramID, requestData); err != nil {
 		// Логируем ошибку, но не прерываем выполнение
-		fmt.Printf("Failed to send notification: %v\n", err)
-	} else {
-		fmt.Printf("Trial request notification sent successfully\n")
+		log.Printf("Failed to send trial request notification to %d: %v", s.teacherTelegramID, err)
 	}
 
 	return nil
 }
 
-// GenerateInviteCode генерирует код приглашения для преподавателя
-func (s *AuthService) GenerateInviteCode() (string, error) {
-	return s.userRepo.GenerateInviteCode()
-}
-
 // ValidateToken валидирует JWT токен
 func (s *AuthService) ValidateToken(tokenString string) (*models.User, error) {
+	user, _, err := s.ValidateAccessToken(tokenString)
+	return user, err
+}
+
+// ValidateAccessToken валидирует access-токен и проверяет, что его сессия не отозвана
+func (s *AuthService) ValidateAccessToken(tokenString string) (*models.User, uuid.UUID, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -203,43 +288,58 @@ func (s *AuthService) ValidateToken(tokenString string) (*models.User, error) {
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("invalid token: %w", err)
+		return nil, uuid.Nil, fmt.Errorf("invalid token: %w", err)
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userIDStr, ok := claims["user_id"].(string)
-		if !ok {
-			return nil, fmt.Errorf("invalid token claims")
-		}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, uuid.Nil, fmt.Errorf("invalid token")
+	}
 
-		userID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			return nil, fmt.Errorf("invalid user ID in token: %w", err)
-		}
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		return nil, uuid.Nil, fmt.Errorf("invalid token claims")
+	}
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("invalid user ID in token: %w", err)
+	}
 
-		user, err := s.userRepo.GetByID(userID)
-		if err != nil {
-			return nil, fmt.Errorf("user not found: %w", err)
-		}
+	// Токены без сессии (выданные до появления сессий) не принимаются
+	sessionIDStr, _ := claims["sid"].(string)
+	sessionID, err := uuid.Parse(sessionIDStr)
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("invalid session in token")
+	}
+	session, err := s.sessionRepo.GetByID(sessionID)
+	if err != nil || session.UserID != userID || !session.Active(time.Now()) {
+		return nil, uuid.Nil, ErrSessionRevoked
+	}
 
-		return user, nil
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("user not found: %w", err)
 	}
 
-	return nil, fmt.Errorf("invalid token")
+	return user, sessionID, nil
 }
 
-// generateJWT генерирует JWT токен для пользователя
-func (s *AuthService) generateJWT(user *models.User) (string, error) {
+// generateJWT генерирует короткоживущий access-токен, привязанный к сессии
+func (s *AuthService) generateJWT(user *models.User, sessionID uuid.UUID) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.accessTTL)
 	claims := jwt.MapClaims{
 		"user_id":     user.ID.String(),
+		"sid":         sessionID.String(),
 		"telegram_id": user.TelegramID,
 		"role":        user.Role,
-		"exp":         time.Now().Add(24 * time.Hour).Unix(),
-		"iat":         time.Now().Unix(),
+		"exp":         expiresAt.Unix(),
+		"iat":         now.Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtSecret))
+	signed, err := token.SignedString([]byte(s.jwtSecret))
+	return signed, expiresAt, err
 }
 
 // Public helpers for handlers
@@ -262,67 +362,23 @@ func (s *AuthService) UpdateUser(user *models.User) error {
 	return s.userRepo.Update(user)
 }
 
-func (s *AuthService) GenerateToken(user *models.User) (string, error) {
-	return s.generateJWT(user)
-}
-
-// AssignStudentParams параметры назначения ученика без участия ученика
-type AssignStudentParams struct {
-	UserID     *uuid.UUID
-	TelegramID *int64
-	Username   string
-	Grade      *int
-	Subjects   string
-}
-
-// AssignStudentToTeacher апгрейдит роль пользователя до student (teacher-driven)
-func (s *AuthService) AssignStudentToTeacher(teacherID uuid.UUID, params AssignStudentParams) (*models.User, error) {
-	var user *models.User
-	var err error
-	if params.UserID != nil {
-		user, err = s.userRepo.GetByID(*params.UserID)
-	} else if params.TelegramID != nil && *params.TelegramID != 0 {
-		user, err = s.userRepo.GetByTelegramID(*params.TelegramID)
-	} else if params.Username != "" {
-		user, err = s.userRepo.GetByUsername(params.Username)
-	} else {
-		return nil, fmt.Errorf("no identifier provided")
-	}
-	if err != nil {
-		return nil, err
-	}
-
-	// Обновление роли и учебных атрибутов
-	user.Role = models.RoleStudent
-	if params.Grade != nil {
-		user.Grade = *params.Grade
-	}
-	if params.Subjects != "" {
-		user.Subjects = params.Subjects
-	}
-	if err := s.userRepo.Update(user); err != nil {
-		return nil, err
-	}
-
-	// Уведомление ученику
-	if s.telegramBot != nil && user.TelegramID != 0 {
-		s.telegramBot.SendMessage(user.TelegramID, "🎓 Вы назначены учеником. Откройте мини‑приложение для заданий.")
-	}
-	return user, nil
+// GenerateToken выдает новый access-токен для текущей сессии (например, после смены роли)
+func (s *AuthService) GenerateToken(user *models.User, sessionID uuid.UUID) (string, error) {
+	token, _, err := s.generateJWT(user, sessionID)
+	return token, err
 }
 
 // SelectRole меняет роль пользователя и возвращает новый токен
-func (s *AuthService) SelectRole(user *models.User, role models.UserRole) (string, error) {
+func (s *AuthService) SelectRole(user *models.User, role models.UserRole, sessionID uuid.UUID) (string, error) {
 	if role == models.RoleTeacher {
 		if !s.IsTeacherTelegram(user.TelegramID) {
 			return "", fmt.Errorf("not allowed to be teacher")
 		}
 	}
-	user.Role = role
-	if err := s.userRepo.Update(user); err != nil {
+	if err := s.setRole(user, role, &user.ID, RoleChangeSelected); err != nil {
 		return "", err
 	}
-	return s.generateJWT(user)
+	return s.GenerateToken(user, sessionID)
 }
 
 // GetTrialRequests получает все заявки на пробные занятия
@@ -330,10 +386,10 @@ func (s *AuthService) GetTrialRequests() ([]models.TrialRequest, error) {
 	return s.trialRepo.GetVisible()
 }
 
-// GetStats получает статистику для панели управления
-func (s *AuthService) GetStats() (map[string]interface{}, error) {
-	// Получаем количество учеников
-	students, err := s.userRepo.ListByRole(models.RoleStudent)
+// GetStats получает статистику для панели управления преподавателя
+func (s *AuthService) GetStats(teacherID uuid.UUID) (map[string]interface{}, error) {
+	// Получаем количество учеников преподавателя
+	students, err := s.teacherStudentRepo.ListStudentIDs(teacherID)
 	if err != nil {
 		return nil, err
 	}
@@ -361,106 +417,80 @@ func (s *AuthService) GetStats() (map[string]interface{}, error) {
 	}, nil
 }
 
-// GetStudents получает список всех учеников
-func (s *AuthService) GetStudents() ([]models.User, error) {
-	return s.userRepo.ListByRole("student")
+// GetStudents получает список учеников преподавателя
+func (s *AuthService) GetStudents(teacherID uuid.UUID) ([]models.User, error) {
+	return s.teacherStudentRepo.ListStudents(teacherID)
 }
 
-// LinkExistingStudentByUsername привязывает существующего пользователя к роли студента
-func (s *AuthService) LinkExistingStudentByUsername(username string) (*models.User, error) {
-	if username == "" {
-		return nil, fmt.Errorf("username is required")
-	}
-	user, err := s.userRepo.GetByUsername(username)
+// GetTeachers получает список преподавателей ученика
+func (s *AuthService) GetTeachers(studentID uuid.UUID) ([]models.User, error) {
+	return s.teacherStudentRepo.ListTeachers(studentID)
+}
+
+// UnlinkStudent отвязывает ученика от преподавателя (у других преподавателей он остается)
+func (s *AuthService) UnlinkStudent(teacherID, studentID uuid.UUID) error {
+	linked, err := s.teacherStudentRepo.IsLinked(teacherID, studentID)
 	if err != nil {
-		return nil, fmt.Errorf("user not found")
+		return err
 	}
-	user.Role = models.RoleStudent
-	if err := s.userRepo.Update(user); err != nil {
-		return nil, fmt.Errorf("failed to update user: %w", err)
+	if !linked {
+		return ErrStudentNotLinked
 	}
-	return user, nil
+	return s.teacherStudentRepo.Unlink(teacherID, studentID)
 }
 
-// RegisterStudentByCode регистрирует ученика только по коду приглашения
-func (s *AuthService) RegisterStudentByCode(inviteCode string) (*models.User, string, error) {
-	// Проверяем код приглашения
-	if inviteCode == "" {
-		return nil, "", fmt.Errorf("invite code is required")
-	}
-	// Пытаемся найти уже созданного ученика с этим кодом (учитель создаёт заранее)
-	if existing, err := s.userRepo.GetByInviteCode(inviteCode); err == nil && existing != nil {
-		// Генерируем токен для существующего ученика
-		token, err := s.generateJWT(existing)
+// validateTelegramAuth проверяет подпись Mini App (init_data) или Login Widget (hash).
+// Для Mini App данные пользователя берутся из подписанного init_data.
+func (s *AuthService) validateTelegramAuth(authData *TelegramAuthData) error {
+	if authData.InitData != "" {
+		var initData *telegram.WebAppInitData
+		var err error
+		if s.skipAuthValidation {
+			initData, err = telegram.ParseWebAppInitData(authData.InitData)
+		} else {
+			initData, err = telegram.ValidateWebAppInitData(authData.InitData, s.botToken, s.authMaxAge)
+		}
 		if err != nil {
-			return nil, "", fmt.Errorf("failed to generate token: %w", err)
+			return err
 		}
-		return existing, token, nil
-	}
-
-	// Если не нашли — создаём ученика (fallback)
-	user := &models.User{
-		ID:         uuid.New(),
-		TelegramID: 0,
-		Role:       models.RoleStudent,
-		InviteCode: &inviteCode,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+		authData.ID = initData.User.ID
+		authData.FirstName = initData.User.FirstName
+		authData.LastName = initData.User.LastName
+		authData.Username = initData.User.Username
+		authData.PhotoURL = initData.User.PhotoURL
+		authData.AuthDate = initData.AuthDate.Unix()
+		return nil
+	}
+
+	if s.skipAuthValidation {
+		if authData.ID == 0 {
+			return errors.New("telegram id is required")
+		}
+		return nil
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
-		return nil, "", fmt.Errorf("failed to create user: %w", err)
+	// Виджет присылает только заполненные поля — их же и подписывает
+	fields := map[string]string{
+		"id":        strconv.FormatInt(authData.ID, 10),
+		"auth_date": strconv.FormatInt(authData.AuthDate, 10),
+		"hash":      authData.Hash,
 	}
-
-	// Генерируем JWT токен
-	token, err := s.generateJWT(user)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to generate token: %w", err)
+	optional := map[string]string{
+		"first_name": authData.FirstName,
+		"last_name":  authData.LastName,
+		"username":   authData.Username,
+		"photo_url":  authData.PhotoURL,
 	}
-
-	return user, token, nil
-}
-
-// CreateStudentByTeacher создает ученика от имени преподавателя и выдает код приглашения
-func (s *AuthService) CreateStudentByTeacher(firstName string, lastName string, grade int, subjects string, phone string, username string, telegramID int64) (*models.User, string, error) {
-	// Сгенерировать уникальный инвайт-код
-	code, err := s.userRepo.GenerateInviteCode()
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to generate invite code: %w", err)
-	}
-
-	// Сформировать пользователя
-	user := &models.User{
-		ID:         uuid.New(),
-		TelegramID: telegramID, // может быть 0, если неизвестен
-		Username:   username,
-		FirstName:  firstName,
-		LastName:   lastName,
-		Role:       models.RoleStudent,
-		Phone:      phone,
-		Grade:      grade,
-		Subjects:   subjects,
-		InviteCode: &code,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
-	}
-
-	if err := s.userRepo.Create(user); err != nil {
-		return nil, "", fmt.Errorf("failed to create student: %w", err)
+	for key, value := range optional {
+		if value != "" {
+			fields[key] = value
+		}
 	}
-
-	return user, code, nil
-}
-
-// validateTelegramAuth валидирует данные авторизации Telegram (упрощенная версия)
-func (s *AuthService) validateTelegramAuth(authData *TelegramAuthData) bool {
-	// В реальном приложении здесь должна быть проверка подписи
-	// с использованием секретного ключа бота
-	return true
+	return telegram.ValidateLoginWidget(fields, s.botToken, s.authMaxAge)
 }
 
 // ApproveTrialRequest одобряет заявку на пробный урок
-func (s *AuthService) ApproveTrialRequest(requestID string) error {
+func (s *AuthService) ApproveTrialRequest(requestID string, actor Actor) error {
 	id, err := uuid.Parse(requestID)
 	if err != nil {
 		return fmt.Errorf("invalid request ID: %w", err)
@@ -471,10 +501,12 @@ func (s *AuthService) ApproveTrialRequest(requestID string) error {
 		return fmt.Errorf("failed to get trial request: %w", err)
 	}
 
+	oldStatus := request.Status
 	request.Status = "approved"
 	if err := s.trialRepo.Update(request); err != nil {
 		return fmt.Errorf("failed to update trial request: %w", err)
 	}
+	s.recordTrialStatus(actor, AuditActionApprove, request, oldStatus)
 
 	// Отправляем уведомление заявителю (если есть telegram_id)
 	if request.TelegramID != 0 && s.telegramBot != nil {
@@ -486,7 +518,7 @@ func (s *AuthService) ApproveTrialRequest(requestID string) error {
 }
 
 // RejectTrialRequest отклоняет заявку на пробный урок
-func (s *AuthService) RejectTrialRequest(requestID string) error {
+func (s *AuthService) RejectTrialRequest(requestID string, actor Actor) error {
 	id, err := uuid.Parse(requestID)
 	if err != nil {
 		return fmt.Errorf("invalid request ID: %w", err)
@@ -497,10 +529,12 @@ func (s *AuthService) RejectTrialRequest(requestID string) error {
 		return fmt.Errorf("failed to get trial request: %w", err)
 	}
 
+	oldStatus := request.Status
 	request.Status = "rejected"
 	if err := s.trialRepo.Update(request); err != nil {
 		return fmt.Errorf("failed to update trial request: %w", err)
 	}
+	s.recordTrialStatus(actor, AuditActionReject, request, oldStatus)
 
 	// Отправляем уведомление заявителю (если есть telegram_id)
 	if request.TelegramID != 0 && s.telegramBot != nil {
@@ -511,19 +545,51 @@ func (s *AuthService) RejectTrialRequest(requestID string) error {
 	return nil
 }
 
-// SearchUsers ищет пользователей по запросу (только гости)
-func (s *AuthService) SearchUsers(query string) ([]models.User, error) {
-	// Ищем только пользователей с ролью "guest"
+// recordTrialStatus пишет в журнал смену статуса заявки на пробный урок
+func (s *AuthService) recordTrialStatus(actor Actor, action string, request *models.TrialRequest, oldStatus string) {
+	s.audit.Record(actor, AuditEntry{
+		Action:     action,
+		EntityType: AuditEntityTrialRequest,
+		EntityID:   request.ID,
+		Before:     map[string]interface{}{"status": oldStatus},
+		After:      map[string]interface{}{"status": request.Status},
+	})
+}
+
+// SearchUsers ищет пользователей по запросу: гостей и учеников этого преподавателя.
+// Ученики других преподавателей в поиск не попадают.
+func (s *AuthService) SearchUsers(teacherID uuid.UUID, query string) ([]models.User, error) {
 	users, err := s.userRepo.SearchByQuery(query, "guest")
 	if err != nil {
 		return nil, fmt.Errorf("failed to search users: %w", err)
 	}
 
+	students, err := s.teacherStudentRepo.ListStudents(teacherID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+	query = strings.ToLower(strings.TrimSpace(query))
+	for _, student := range students {
+		if query == "" || matchesUserQuery(&student, query) {
+			users = append(users, student)
+		}
+	}
+
 	return users, nil
 }
 
+// matchesUserQuery проверяет, что имя, фамилия, username или telegram_id содержат запрос
+func matchesUserQuery(user *models.User, query string) bool {
+	for _, field := range []string{user.FirstName, user.LastName, user.Username, strconv.FormatInt(user.TelegramID, 10)} {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
 // HideTrialRequest скрывает заявку на пробный урок (устанавливает статус "hidden")
-func (s *AuthService) HideTrialRequest(requestID string) error {
+func (s *AuthService) HideTrialRequest(requestID string, actor Actor) error {
 	id, err := uuid.Parse(requestID)
 	if err != nil {
 		return fmt.Errorf("invalid request ID: %w", err)
@@ -534,10 +600,12 @@ func (s *AuthService) HideTrialRequest(requestID string) error {
 		return fmt.Errorf("failed to get trial request: %w", err)
 	}
 
+	oldStatus := request.Status
 	request.Status = "hidden"
 	if err := s.trialRepo.Update(request); err != nil {
 		return fmt.Errorf("failed to update trial request: %w", err)
 	}
+	s.recordTrialStatus(actor, AuditActionHide, request, oldStatus)
 
 	return nil
 }
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"edubot/internal/models"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrSessionRevoked      = errors.New("session has been revoked")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+// Причины отзыва сессии
+const (
+	SessionRevokedLogout  = "logout"
+	SessionRevokedByUser  = "revoked_by_user"
+	SessionRevokedByAdmin = "revoked_by_teacher"
+	SessionRevokedReuse   = "refresh_token_reuse"
+	SessionRevokedRole    = "role_changed"
+)
+
+// ClientInfo — сведения о клиенте, с которого выполняется вход
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// TokenPair — короткоживущий access-токен и refresh-токен сессии
+type TokenPair struct {
+	AccessToken      string    `json:"token"`
+	AccessExpiresAt  time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	SessionID        uuid.UUID `json:"session_id"`
+}
+
+// startSession создает сессию и выдает пару токенов
+func (s *AuthService) startSession(user *models.User, client ClientInfo) (*TokenPair, error) {
+	secret, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		ID:               uuid.New(),
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(secret),
+		UserAgent:        client.UserAgent,
+		Device:           describeDevice(client.UserAgent),
+		IP:               client.IP,
+		CreatedAt:        now,
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(s.refreshTTL),
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	return s.tokenPair(user, session, secret)
+}
+
+// RefreshSession меняет refresh-токен на новую пару токенов (ротация).
+// Повторное предъявление уже замененного токена означает его утечку — сессия отзывается.
+func (s *AuthService) RefreshSession(refreshToken string, client ClientInfo) (*TokenPair, error) {
+	sessionID, secret, ok := parseRefreshToken(refreshToken)
+	if !ok {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	session, err := s.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	now := time.Now()
+	if !session.Active(now) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	newSecret, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := s.sessionRepo.Rotate(session.ID, hashToken(secret), hashToken(newSecret), now, now.Add(s.refreshTTL), client.UserAgent, client.IP)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		log.Printf("Refresh token reuse detected for session %s, revoking", session.ID)
+		if err := s.sessionRepo.Revoke(session.ID, SessionRevokedReuse); err != nil {
+			log.Printf("Failed to revoke session %s: %v", session.ID, err)
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetByID(session.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	session.ExpiresAt = now.Add(s.refreshTTL)
+	return s.tokenPair(user, session, newSecret)
+}
+
+// ListSessions возвращает активные сессии пользователя
+func (s *AuthService) ListSessions(userID uuid.UUID) ([]*models.Session, error) {
+	return s.sessionRepo.ListActiveByUser(userID)
+}
+
+// RevokeSession отзывает сессию пользователя
+func (s *AuthService) RevokeSession(userID, sessionID uuid.UUID, reason string) error {
+	session, err := s.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	return s.sessionRepo.Revoke(sessionID, reason)
+}
+
+// RevokeOtherSessions отзывает все сессии пользователя, кроме текущей
+func (s *AuthService) RevokeOtherSessions(userID, currentSessionID uuid.UUID) error {
+	return s.sessionRepo.RevokeAllByUser(userID, &currentSessionID, SessionRevokedByUser)
+}
+
+// RevokeAllSessions отзывает все сессии пользователя (например, ученика исключили)
+func (s *AuthService) RevokeAllSessions(userID uuid.UUID, reason string) error {
+	return s.sessionRepo.RevokeAllByUser(userID, nil, reason)
+}
+
+// RevokeStudentSessions завершает все сессии ученика. Преподаватель может
+// разлогинить только своего ученика, администратор — любого ученика.
+func (s *AuthService) RevokeStudentSessions(actor *models.User, studentID uuid.UUID) error {
+	student, err := s.userRepo.GetByID(studentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrStudentNotLinked
+	}
+	if err != nil {
+		return err
+	}
+	if student.Role != models.RoleStudent {
+		return ErrStudentNotLinked
+	}
+	if actor.Role != models.RoleAdmin {
+		linked, err := s.teacherStudentRepo.IsLinked(actor.ID, studentID)
+		if err != nil {
+			return err
+		}
+		if !linked {
+			return ErrStudentNotLinked
+		}
+	}
+	return s.RevokeAllSessions(studentID, SessionRevokedByAdmin)
+}
+
+// CleanupExpiredSessions удаляет давно истекшие сессии
+func (s *AuthService) CleanupExpiredSessions() error {
+	return s.sessionRepo.DeleteExpired(time.Now().Add(-7 * 24 * time.Hour))
+}
+
+func (s *AuthService) tokenPair(user *models.User, session *models.Session, secret string) (*TokenPair, error) {
+	accessToken, expiresAt, err := s.generateJWT(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  expiresAt,
+		RefreshToken:     session.ID.String() + "." + secret,
+		RefreshExpiresAt: session.ExpiresAt,
+		SessionID:        session.ID,
+	}, nil
+}
+
+// parseRefreshToken разбирает токен вида "<session_id>.<secret>"
+func parseRefreshToken(token string) (uuid.UUID, string, bool) {
+	idPart, secret, found := strings.Cut(token, ".")
+	if !found || secret == "" {
+		return uuid.Nil, "", false
+	}
+	id, err := uuid.Parse(idPart)
+	if err != nil {
+		return uuid.Nil, "", false
+	}
+	return id, secret, true
+}
+
+// describeDevice возвращает краткое описание устройства по User-Agent
+func describeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	var platform string
+	switch {
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"):
+		platform = "iOS"
+	case strings.Contains(ua, "android"):
+		platform = "Android"
+	case strings.Contains(ua, "windows"):
+		platform = "Windows"
+	case strings.Contains(ua, "mac os"), strings.Contains(ua, "macintosh"):
+		platform = "macOS"
+	case strings.Contains(ua, "linux"):
+		platform = "Linux"
+	}
+
+	var client string
+	switch {
+	case strings.Contains(ua, "telegram"):
+		client = "Telegram"
+	case strings.Contains(ua, "edg/"):
+		client = "Edge"
+	case strings.Contains(ua, "firefox"):
+		client = "Firefox"
+	case strings.Contains(ua, "chrome"):
+		client = "Chrome"
+	case strings.Contains(ua, "safari"):
+		client = "Safari"
+	}
+
+	switch {
+	case client != "" && platform != "":
+		return client + ", " + platform
+	case client != "":
+		return client
+	case platform != "":
+		return platform
+	default:
+		return "Неизвестное устройство"
+	}
+}
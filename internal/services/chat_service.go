@@ -2,68 +2,140 @@ package services
 
 import (
 	"errors"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"edubot/internal/models"
+	"edubot/internal/policy"
+	"edubot/internal/realtime"
 	"edubot/internal/repository"
-	"edubot/pkg/telegram"
 )
 
+// ErrTeacherRequired — у ученика несколько преподавателей, нужно указать teacher_id
+var ErrTeacherRequired = errors.New("teacher_id is required")
+
+// ErrMessageNotInThread — сообщение из другого чата
+var ErrMessageNotInThread = errors.New("message does not belong to this thread")
+
+// ErrInvalidReaction — реакции нет в списке допустимых
+var ErrInvalidReaction = errors.New("unsupported reaction")
+
 type ChatService interface {
 	// Thread operations
 	GetOrCreateStudentTeacherThread(studentID, teacherID uuid.UUID) (*models.ChatThread, error)
-	GetOrCreateGroupThread(groupID, teacherID uuid.UUID) (*models.ChatThread, error)
+	GetOrCreateGroupThread(groupID, userID uuid.UUID) (*models.ChatThread, error)
 	GetThread(id uuid.UUID) (*models.ChatThread, error)
+	CanAccessThread(thread *models.ChatThread, userID uuid.UUID) bool
 	ListThreadsForUser(userID uuid.UUID) ([]*models.ChatThread, error)
 	UpdateThread(thread *models.ChatThread) error
 
 	// Message operations
-	SendMessage(threadID, authorID uuid.UUID, text *string, mediaIDs []uuid.UUID, kind models.MessageKind) (*models.Message, error)
+	SendMessage(threadID, authorID uuid.UUID, text *string, mediaIDs []uuid.UUID, replyToID *uuid.UUID, kind models.MessageKind) (*models.Message, error)
 	GetMessage(id uuid.UUID) (*models.Message, error)
 	ListMessages(threadID uuid.UUID, limit int, before *time.Time) ([]*models.Message, error)
 	UpdateMessage(message *models.Message) error
 	DeleteMessage(id uuid.UUID) error
 
+	// Reactions and pins
+	AddReaction(messageID, userID uuid.UUID, emoji string) (*models.Message, error)
+	RemoveReaction(messageID, userID uuid.UUID, emoji string) (*models.Message, error)
+	PinMessage(messageID, userID uuid.UUID) (*models.Message, error)
+	UnpinMessage(messageID, userID uuid.UUID) (*models.Message, error)
+	ListPinned(threadID, userID uuid.UUID) ([]*models.Message, error)
+
 	// Unread operations
 	GetUnreadCount(threadID, userID uuid.UUID) (int64, error)
-	MarkAsRead(threadID, userID uuid.UUID) error
+	GetUnreadCounts(threadIDs []uuid.UUID, userID uuid.UUID) (map[uuid.UUID]int64, error)
+	MarkAsRead(threadID, userID uuid.UUID, messageID *uuid.UUID) error
+	ListReadCursors(threadID, exceptUserID uuid.UUID) ([]models.ChatReadCursor, error)
+
+	// Realtime
+	SendTyping(threadID, userID uuid.UUID) error
 
 	// System messages
 	SendSystemMessage(threadID uuid.UUID, text string, kind models.MessageKind) (*models.Message, error)
 }
 
 type chatService struct {
-	chatRepo         repository.ChatRepository
-	userRepo         repository.UserRepository
-	groupRepo        repository.GroupRepository
-	notificationRepo repository.NotificationRepository
-	bot              *telegram.Bot
+	chatRepo            repository.ChatRepository
+	userRepo            repository.UserRepository
+	groupRepo           repository.GroupRepository
+	teacherStudentRepo  repository.TeacherStudentRepository
+	mediaRepo           repository.MediaRepository
+	notificationService NotificationService
+	policy              policy.Policy
+	realtime            realtime.Publisher
 }
 
 func NewChatService(
 	chatRepo repository.ChatRepository,
 	userRepo repository.UserRepository,
 	groupRepo repository.GroupRepository,
-	notificationRepo repository.NotificationRepository,
-	bot *telegram.Bot,
+	teacherStudentRepo repository.TeacherStudentRepository,
+	mediaRepo repository.MediaRepository,
+	notificationService NotificationService,
+	pol policy.Policy,
+	publisher realtime.Publisher,
 ) ChatService {
 	return &chatService{
-		chatRepo:         chatRepo,
-		userRepo:         userRepo,
-		groupRepo:        groupRepo,
-		notificationRepo: notificationRepo,
-		bot:              bot,
+		chatRepo:            chatRepo,
+		userRepo:            userRepo,
+		groupRepo:           groupRepo,
+		teacherStudentRepo:  teacherStudentRepo,
+		mediaRepo:           mediaRepo,
+		notificationService: notificationService,
+		policy:              pol,
+		realtime:            publisher,
 	}
 }
 
+// GetOrCreateStudentTeacherThread возвращает личный чат ученика с его преподавателем.
+// Если teacherID не указан, а преподаватель у ученика один — берется он.
 func (s *chatService) GetOrCreateStudentTeacherThread(studentID, teacherID uuid.UUID) (*models.ChatThread, error) {
+	if teacherID == uuid.Nil {
+		teachers, err := s.teacherStudentRepo.ListTeachers(studentID)
+		if err != nil {
+			return nil, err
+		}
+		if len(teachers) != 1 {
+			return nil, ErrTeacherRequired
+		}
+		teacherID = teachers[0].ID
+	}
+
+	linked, err := s.teacherStudentRepo.IsLinked(teacherID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if !linked {
+		return nil, ErrStudentNotLinked
+	}
 	return s.chatRepo.GetOrCreateStudentTeacherThread(studentID, teacherID)
 }
 
-func (s *chatService) GetOrCreateGroupThread(groupID, teacherID uuid.UUID) (*models.ChatThread, error) {
-	return s.chatRepo.GetOrCreateGroupThread(groupID, teacherID)
+// GetOrCreateGroupThread возвращает чат группы; открыть его может преподаватель группы или ее участник
+func (s *chatService) GetOrCreateGroupThread(groupID, userID uuid.UUID) (*models.ChatThread, error) {
+	group, err := s.groupRepo.GetByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.policy.Can(user, policy.ActionChat, policy.Resource{TeacherID: group.TeacherID, GroupID: &group.ID}) {
+		return nil, ErrAccessDenied
+	}
+	return s.chatRepo.GetOrCreateGroupThread(groupID, group.TeacherID)
+}
+
+// CanAccessThread проверяет, может ли пользователь читать и писать в чат
+func (s *chatService) CanAccessThread(thread *models.ChatThread, userID uuid.UUID) bool {
+	return s.hasAccessToThread(thread, userID)
 }
 
 func (s *chatService) GetThread(id uuid.UUID) (*models.ChatThread, error) {
@@ -79,7 +151,8 @@ func (s *chatService) UpdateThread(thread *models.ChatThread) error {
 	return s.chatRepo.UpdateThread(thread)
 }
 
-func (s *chatService) SendMessage(threadID, authorID uuid.UUID, text *string, mediaIDs []uuid.UUID, kind models.MessageKind) (*models.Message, error) {
+// SendMessage отправляет сообщение в чат; replyToID — цитируемое сообщение того же чата
+func (s *chatService) SendMessage(threadID, authorID uuid.UUID, text *string, mediaIDs []uuid.UUID, replyToID *uuid.UUID, kind models.MessageKind) (*models.Message, error) {
 	// Проверяем, что пользователь имеет доступ к треду
 	thread, err := s.chatRepo.GetThread(threadID)
 	if err != nil {
@@ -88,7 +161,31 @@ func (s *chatService) SendMessage(threadID, authorID uuid.UUID, text *string, me
 
 	// Проверяем права доступа
 	if !s.hasAccessToThread(thread, authorID) {
-		return nil, errors.New("access denied to thread")
+		return nil, ErrAccessDenied
+	}
+
+	// Прикладывать можно только свои файлы
+	media := make([]models.Media, 0, len(mediaIDs))
+	for _, mediaID := range mediaIDs {
+		m, err := s.mediaRepo.GetByID(mediaID)
+		if err != nil {
+			return nil, err
+		}
+		if m.OwnerID != authorID {
+			return nil, ErrAccessDenied
+		}
+		media = append(media, *m)
+	}
+
+	var replyTo *models.Message
+	if replyToID != nil {
+		replyTo, err = s.chatRepo.GetMessage(*replyToID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if err != nil || replyTo.ThreadID != threadID {
+			return nil, ErrMessageNotInThread
+		}
 	}
 
 	// Создаем сообщение
@@ -98,19 +195,29 @@ func (s *chatService) SendMessage(threadID, authorID uuid.UUID, text *string, me
 		AuthorID:  authorID,
 		Text:      text,
 		Kind:      kind,
+		ReplyToID: replyToID,
 		CreatedAt: time.Now(),
+		Media:     media,
 	}
 
 	// Сохраняем сообщение
 	if err := s.chatRepo.CreateMessage(message); err != nil {
 		return nil, err
 	}
+	s.grantMediaToParticipants(thread, message)
+	if replyTo != nil {
+		message.ReplyTo = quotedMessage(replyTo)
+	}
 
 	// Обновляем время последнего сообщения в треде
 	thread.LastMessageAt = &message.CreatedAt
 	thread.UpdatedAt = time.Now()
 	s.chatRepo.UpdateThread(thread)
 
+	// Свое сообщение автор уже прочитал
+	s.advanceReadCursor(threadID, authorID, message)
+	s.realtime.Publish(s.threadRecipients(thread, uuid.Nil), realtime.EventMessageCreated, message)
+
 	// Отправляем уведомления другим участникам треда
 	s.notifyThreadParticipants(thread, message)
 
@@ -127,19 +234,201 @@ func (s *chatService) ListMessages(threadID uuid.UUID, limit int, before *time.T
 
 func (s *chatService) UpdateMessage(message *models.Message) error {
 	message.EditedAt = &[]time.Time{time.Now()}[0]
-	return s.chatRepo.UpdateMessage(message)
+	if err := s.chatRepo.UpdateMessage(message); err != nil {
+		return err
+	}
+	if thread, err := s.chatRepo.GetThread(message.ThreadID); err == nil {
+		s.realtime.Publish(s.threadRecipients(thread, uuid.Nil), realtime.EventMessageUpdated, message)
+	}
+	return nil
 }
 
 func (s *chatService) DeleteMessage(id uuid.UUID) error {
-	return s.chatRepo.DeleteMessage(id)
+	message, err := s.chatRepo.GetMessage(id)
+	if err != nil {
+		return err
+	}
+	if err := s.chatRepo.DeleteMessage(id); err != nil {
+		return err
+	}
+	if thread, err := s.chatRepo.GetThread(message.ThreadID); err == nil {
+		s.realtime.Publish(s.threadRecipients(thread, uuid.Nil), realtime.EventMessageDeleted, map[string]interface{}{
+			"id":        message.ID,
+			"thread_id": message.ThreadID,
+		})
+	}
+	return nil
+}
+
+// AddReaction ставит реакцию пользователя на сообщение
+func (s *chatService) AddReaction(messageID, userID uuid.UUID, emoji string) (*models.Message, error) {
+	if !models.IsReactionEmoji(emoji) {
+		return nil, ErrInvalidReaction
+	}
+	message, thread, err := s.accessibleMessage(messageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.chatRepo.AddReaction(&models.MessageReaction{
+		MessageID: message.ID,
+		UserID:    userID,
+		Emoji:     emoji,
+	}); err != nil {
+		return nil, err
+	}
+	return s.publishMessageUpdated(thread, messageID)
+}
+
+// RemoveReaction снимает реакцию пользователя; снять отсутствующую реакцию — не ошибка
+func (s *chatService) RemoveReaction(messageID, userID uuid.UUID, emoji string) (*models.Message, error) {
+	message, thread, err := s.accessibleMessage(messageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	removed, err := s.chatRepo.RemoveReaction(messageID, userID, emoji)
+	if err != nil {
+		return nil, err
+	}
+	if !removed {
+		return message, nil
+	}
+	return s.publishMessageUpdated(thread, messageID)
+}
+
+// PinMessage закрепляет сообщение в чате
+func (s *chatService) PinMessage(messageID, userID uuid.UUID) (*models.Message, error) {
+	message, thread, err := s.accessibleMessage(messageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.canPin(thread, userID) {
+		return nil, ErrAccessDenied
+	}
+	if message.PinnedAt != nil {
+		return message, nil
+	}
+	now := time.Now()
+	if err := s.chatRepo.SetPinned(messageID, &userID, &now); err != nil {
+		return nil, err
+	}
+	return s.publishMessageUpdated(thread, messageID)
+}
+
+// UnpinMessage открепляет сообщение
+func (s *chatService) UnpinMessage(messageID, userID uuid.UUID) (*models.Message, error) {
+	message, thread, err := s.accessibleMessage(messageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.canPin(thread, userID) {
+		return nil, ErrAccessDenied
+	}
+	if message.PinnedAt == nil {
+		return message, nil
+	}
+	if err := s.chatRepo.SetPinned(messageID, nil, nil); err != nil {
+		return nil, err
+	}
+	return s.publishMessageUpdated(thread, messageID)
+}
+
+// ListPinned возвращает закрепленные сообщения чата
+func (s *chatService) ListPinned(threadID, userID uuid.UUID) ([]*models.Message, error) {
+	thread, err := s.chatRepo.GetThread(threadID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.hasAccessToThread(thread, userID) {
+		return nil, ErrAccessDenied
+	}
+	return s.chatRepo.ListPinned(threadID)
+}
+
+// SendTyping сообщает остальным участникам чата, что пользователь печатает
+func (s *chatService) SendTyping(threadID, userID uuid.UUID) error {
+	thread, err := s.chatRepo.GetThread(threadID)
+	if err != nil {
+		return err
+	}
+	if !s.hasAccessToThread(thread, userID) {
+		return ErrAccessDenied
+	}
+	s.realtime.PublishEphemeral(s.threadRecipients(thread, userID), realtime.EventTyping, map[string]interface{}{
+		"thread_id": threadID,
+		"user_id":   userID,
+	})
+	return nil
 }
 
 func (s *chatService) GetUnreadCount(threadID, userID uuid.UUID) (int64, error) {
 	return s.chatRepo.GetUnreadCount(threadID, userID)
 }
 
-func (s *chatService) MarkAsRead(threadID, userID uuid.UUID) error {
-	return s.chatRepo.MarkAsRead(threadID, userID)
+func (s *chatService) GetUnreadCounts(threadIDs []uuid.UUID, userID uuid.UUID) (map[uuid.UUID]int64, error) {
+	return s.chatRepo.GetUnreadCounts(threadIDs, userID)
+}
+
+// MarkAsRead отмечает чат прочитанным до сообщения messageID, а без него — до последнего сообщения
+func (s *chatService) MarkAsRead(threadID, userID uuid.UUID, messageID *uuid.UUID) error {
+	thread, err := s.chatRepo.GetThread(threadID)
+	if err != nil {
+		return err
+	}
+	if !s.hasAccessToThread(thread, userID) {
+		return ErrAccessDenied
+	}
+
+	var message *models.Message
+	if messageID != nil {
+		message, err = s.chatRepo.GetMessage(*messageID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err != nil || message.ThreadID != threadID {
+			return ErrMessageNotInThread
+		}
+	} else {
+		message, err = s.chatRepo.GetLastMessage(threadID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil // В чате еще нет сообщений
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.chatRepo.MarkAsRead(&models.ChatReadCursor{
+		ThreadID:          threadID,
+		UserID:            userID,
+		LastReadMessageID: &message.ID,
+		LastReadAt:        message.CreatedAt,
+	})
+}
+
+// ListReadCursors возвращает, докуда прочитали чат остальные участники — для отметки «просмотрено»
+func (s *chatService) ListReadCursors(threadID, exceptUserID uuid.UUID) ([]models.ChatReadCursor, error) {
+	cursors, err := s.chatRepo.ListReadCursors(threadID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]models.ChatReadCursor, 0, len(cursors))
+	for _, cursor := range cursors {
+		if cursor.UserID != exceptUserID {
+			result = append(result, cursor)
+		}
+	}
+	return result, nil
+}
+
+func (s *chatService) advanceReadCursor(threadID, userID uuid.UUID, message *models.Message) {
+	if err := s.chatRepo.MarkAsRead(&models.ChatReadCursor{
+		ThreadID:          threadID,
+		UserID:            userID,
+		LastReadMessageID: &message.ID,
+		LastReadAt:        message.CreatedAt,
+	}); err != nil {
+		log.Printf("Failed to update read cursor for %s in thread %s: %v", userID, threadID, err)
+	}
 }
 
 func (s *chatService) SendSystemMessage(threadID uuid.UUID, text string, kind models.MessageKind) (*models.Message, error) {
@@ -165,6 +454,7 @@ func (s *chatService) SendSystemMessage(threadID uuid.UUID, text string, kind mo
 		thread.LastMessageAt = &message.CreatedAt
 		thread.UpdatedAt = time.Now()
 		s.chatRepo.UpdateThread(thread)
+		s.realtime.Publish(s.threadRecipients(thread, uuid.Nil), realtime.EventMessageCreated, message)
 	}
 
 	return message, nil
@@ -176,81 +466,130 @@ func (s *chatService) hasAccessToThread(thread *models.ChatThread, userID uuid.U
 	case models.ChatThreadTypeStudentTeacher:
 		return (thread.StudentID != nil && *thread.StudentID == userID) || thread.TeacherID == userID
 	case models.ChatThreadTypeGroup:
-		if thread.TeacherID == userID {
-			return true
-		}
-		if thread.GroupID != nil {
-			// Проверяем, является ли пользователь участником группы
-			isMember, err := s.groupRepo.IsMember(*thread.GroupID, userID)
-			return err == nil && isMember
+		// Преподаватель группы, ассистенты и ученики — по политике доступа
+		user, err := s.userRepo.GetByID(userID)
+		if err != nil {
+			return false
 		}
+		return s.policy.Can(user, policy.ActionChat, policy.Resource{TeacherID: thread.TeacherID, GroupID: thread.GroupID})
 	}
 	return false
 }
 
-// Helper method to notify thread participants
-func (s *chatService) notifyThreadParticipants(thread *models.ChatThread, message *models.Message) {
-	if s.bot == nil {
-		return
+// canPin — в личном чате закрепляют оба участника, в групповом только преподаватель группы
+func (s *chatService) canPin(thread *models.ChatThread, userID uuid.UUID) bool {
+	if thread.Type == models.ChatThreadTypeGroup {
+		return thread.TeacherID == userID
+	}
+	return s.hasAccessToThread(thread, userID)
+}
+
+// accessibleMessage загружает сообщение и проверяет доступ пользователя к его чату
+func (s *chatService) accessibleMessage(messageID, userID uuid.UUID) (*models.Message, *models.ChatThread, error) {
+	message, err := s.chatRepo.GetMessage(messageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	thread := message.Thread
+	if !s.hasAccessToThread(&thread, userID) {
+		return nil, nil, ErrAccessDenied
 	}
+	message.Thread = models.ChatThread{}
+	return message, &thread, nil
+}
+
+// publishMessageUpdated перечитывает сообщение и рассылает его участникам чата
+func (s *chatService) publishMessageUpdated(thread *models.ChatThread, messageID uuid.UUID) (*models.Message, error) {
+	message, err := s.chatRepo.GetMessage(messageID)
+	if err != nil {
+		return nil, err
+	}
+	message.Thread = models.ChatThread{}
+	s.realtime.Publish(s.threadRecipients(thread, uuid.Nil), realtime.EventMessageUpdated, message)
+	return message, nil
+}
+
+// quotedMessage — цитата для ответа: без вложенной цитаты, реакций и чата, как в ListMessages
+func quotedMessage(m *models.Message) *models.Message {
+	quoted := *m
+	quoted.Thread = models.ChatThread{}
+	quoted.Media = nil
+	quoted.ReplyTo = nil
+	quoted.Reactions = nil
+	return &quoted
+}
 
+// threadRecipients возвращает участников чата, кроме exceptID
+func (s *chatService) threadRecipients(thread *models.ChatThread, exceptID uuid.UUID) []uuid.UUID {
 	var recipientIDs []uuid.UUID
 
 	switch thread.Type {
 	case models.ChatThreadTypeStudentTeacher:
-		if thread.StudentID != nil && *thread.StudentID != message.AuthorID {
+		if thread.StudentID != nil && *thread.StudentID != exceptID {
 			recipientIDs = append(recipientIDs, *thread.StudentID)
 		}
-		if thread.TeacherID != message.AuthorID {
+		if thread.TeacherID != exceptID {
 			recipientIDs = append(recipientIDs, thread.TeacherID)
 		}
 	case models.ChatThreadTypeGroup:
+		if thread.TeacherID != exceptID {
+			recipientIDs = append(recipientIDs, thread.TeacherID)
+		}
 		if thread.GroupID != nil {
 			members, err := s.groupRepo.ListMembers(*thread.GroupID)
 			if err == nil {
 				for _, member := range members {
-					if member.UserID != message.AuthorID {
+					if member.UserID != exceptID {
 						recipientIDs = append(recipientIDs, member.UserID)
 					}
 				}
 			}
 		}
 	}
+	return recipientIDs
+}
 
-	// Отправляем уведомления
-	for _, recipientID := range recipientIDs {
-		user, err := s.userRepo.GetByID(recipientID)
-		if err == nil && user.TelegramID != 0 {
-			messageText := "[Медиафайл]"
-			if message.Text != nil {
-				messageText = *message.Text
+// grantMediaToParticipants открывает вложения сообщения остальным участникам чата
+func (s *chatService) grantMediaToParticipants(thread *models.ChatThread, message *models.Message) {
+	if len(message.Media) == 0 {
+		return
+	}
+	for _, recipientID := range s.threadRecipients(thread, message.AuthorID) {
+		for _, m := range message.Media {
+			if err := s.mediaRepo.GrantAccess(&models.MediaAccess{
+				MediaID:    m.ID,
+				UserID:     recipientID,
+				Permission: "read",
+			}); err != nil {
+				log.Printf("Failed to grant media %s to %s: %v", m.ID, recipientID, err)
 			}
-			s.bot.SendMessage(user.TelegramID, messageText)
 		}
-
-		// Создаем уведомление в системе
-		s.notificationRepo.Create(&models.Notification{
-			UserID:    recipientID,
-			Type:      models.NotificationTypeNewMessage,
-			Title:     "Новое сообщение",
-			Message:   s.getMessagePreview(message),
-			Payload:   `{"thread_id":"` + thread.ID.String() + `","message_id":"` + message.ID.String() + `"}`,
-			Channel:   models.NotificationChannelBot,
-			Status:    models.NotificationStatusPending,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		})
 	}
 }
 
-// Helper method to create message preview
-func (s *chatService) getMessagePreview(message *models.Message) string {
-	if message.Text != nil && len(*message.Text) > 0 {
-		text := *message.Text
-		if len(text) > 50 {
-			return text[:47] + "..."
+// Helper method to notify thread participants
+func (s *chatService) notifyThreadParticipants(thread *models.ChatThread, message *models.Message) {
+	recipientIDs := s.threadRecipients(thread, message.AuthorID)
+
+	title := "Новое сообщение"
+	if author, err := s.userRepo.GetByID(message.AuthorID); err == nil {
+		title = "Новое сообщение от " + strings.TrimSpace(author.FirstName+" "+author.LastName)
+	}
+	messageText := "[Медиафайл]"
+	if message.Text != nil && *message.Text != "" {
+		messageText = *message.Text
+	}
+
+	// Создаем уведомления; каналы доставки определяются настройками получателя
+	for _, recipientID := range recipientIDs {
+		if err := s.notificationService.CreateNotification(&models.Notification{
+			UserID:  recipientID,
+			Type:    models.NotificationTypeNewMessage,
+			Title:   title,
+			Message: messageText,
+			Payload: `{"thread_id":"` + thread.ID.String() + `","message_id":"` + message.ID.String() + `"}`,
+		}); err != nil {
+			log.Printf("Failed to create message notification for %s: %v", recipientID, err)
 		}
-		return text
 	}
-	return "[Медиафайл]"
 }
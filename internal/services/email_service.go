@@ -0,0 +1,168 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"edubot/internal/models"
+	"edubot/internal/repository"
+	"edubot/pkg/email"
+)
+
+var (
+	ErrEmailDisabled     = errors.New("email delivery is not configured")
+	ErrInvalidEmail      = errors.New("invalid email address")
+	ErrInvalidEmailToken = errors.New("invalid or expired verification link")
+)
+
+type EmailService interface {
+	// Подтверждение адреса
+	RequestVerification(userID uuid.UUID, address string) error
+	Verify(token string) (*models.User, error)
+
+	// Доставка уведомлений
+	Enabled() bool
+	SendNotification(user *models.User, notification *models.Notification) error
+}
+
+type emailService struct {
+	sender          email.Sender
+	userRepo        repository.UserRepository
+	baseURL         string
+	verificationTTL time.Duration
+}
+
+// NewEmailService создает сервис писем; sender может быть nil, если SMTP не настроен
+func NewEmailService(sender email.Sender, userRepo repository.UserRepository, baseURL string, verificationTTL time.Duration) EmailService {
+	return &emailService{
+		sender:          sender,
+		userRepo:        userRepo,
+		baseURL:         strings.TrimRight(baseURL, "/"),
+		verificationTTL: verificationTTL,
+	}
+}
+
+func (s *emailService) Enabled() bool {
+	return s.sender != nil
+}
+
+// RequestVerification сохраняет новый адрес как неподтвержденный и отправляет ссылку для подтверждения
+func (s *emailService) RequestVerification(userID uuid.UUID, address string) error {
+	if s.sender == nil {
+		return ErrEmailDisabled
+	}
+
+	parsed, err := mail.ParseAddress(strings.TrimSpace(address))
+	if err != nil {
+		return ErrInvalidEmail
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	user.Email = strings.ToLower(parsed.Address)
+	user.EmailVerified = false
+	user.EmailVerificationToken = hashToken(token)
+	user.EmailVerificationSent = &now
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	return s.render(user.Email, emailVerificationTemplate, emailData{
+		Name:    user.FirstName,
+		Title:   "Подтверждение адреса",
+		Lines:   []string{"Нажмите на кнопку ниже, чтобы получать уведомления на " + user.Email + "."},
+		LinkURL: s.baseURL + "/api/public/email/verify?token=" + token,
+	})
+}
+
+// Verify подтверждает адрес по токену из письма
+func (s *emailService) Verify(token string) (*models.User, error) {
+	if token == "" {
+		return nil, ErrInvalidEmailToken
+	}
+
+	user, err := s.userRepo.GetByEmailVerificationToken(hashToken(token))
+	if err != nil {
+		return nil, ErrInvalidEmailToken
+	}
+	if user.EmailVerificationSent == nil || time.Since(*user.EmailVerificationSent) > s.verificationTTL {
+		return nil, ErrInvalidEmailToken
+	}
+
+	user.EmailVerified = true
+	user.EmailVerificationToken = ""
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SendNotification отправляет уведомление письмом по шаблону его типа
+func (s *emailService) SendNotification(user *models.User, notification *models.Notification) error {
+	if s.sender == nil {
+		return ErrEmailDisabled
+	}
+	if user.Email == "" || !user.EmailVerified {
+		return &email.PermanentError{Err: errors.New("user has no verified email")}
+	}
+
+	tmpl, ok := emailTemplates[notification.Type]
+	if !ok {
+		tmpl = emailTemplates[models.NotificationTypeNewMessage]
+	}
+
+	return s.render(user.Email, tmpl, emailData{
+		Name:    user.FirstName,
+		Title:   notification.Title,
+		Lines:   strings.Split(notification.Message, "\n"),
+		LinkURL: s.baseURL + "/app",
+	})
+}
+
+func (s *emailService) render(to string, tmpl emailTemplate, data emailData) error {
+	var text, html bytes.Buffer
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return err
+	}
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return err
+	}
+
+	return s.sender.Send(&email.Message{
+		To:      to,
+		Subject: tmpl.subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+	})
+}
+
+// randomToken генерирует случайный токен для ссылок
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashToken хранит в БД только хэш токена
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	htmltemplate "html/template"
+	texttemplate "text/template"
+
+	"edubot/internal/models"
+)
+
+// emailData — данные для шаблонов писем
+type emailData struct {
+	Name    string
+	Title   string
+	Lines   []string
+	LinkURL string
+}
+
+// emailTemplate — тема и две версии письма (текст и HTML)
+type emailTemplate struct {
+	subject string
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// Общий макет; блоки "intro" и "action" задаются для каждого типа письма
+const emailTextLayout = `Здравствуйте{{if .Name}}, {{.Name}}{{end}}!
+
+{{template "intro" .}}
+
+{{.Title}}
+{{range .Lines}}{{.}}
+{{end}}
+{{template "action" .}}: {{.LinkURL}}
+
+—
+EduBot. Настроить уведомления можно в личном кабинете.
+`
+
+const emailHTMLLayout = `<!DOCTYPE html>
+<html lang="ru">
+<body style="margin:0;padding:24px;background:#ECF0F1;font-family:Arial,sans-serif;color:#2C3E50;">
+  <div style="max-width:560px;margin:0 auto;background:#fff;border-radius:8px;padding:24px;">
+    <p>Здравствуйте{{if .Name}}, {{.Name}}{{end}}!</p>
+    <p>{{template "intro" .}}</p>
+    <h2 style="font-size:18px;margin:16px 0 8px;">{{.Title}}</h2>
+    {{range .Lines}}<p style="margin:4px 0;">{{.}}</p>{{end}}
+    <p style="margin-top:24px;">
+      <a href="{{.LinkURL}}" style="background:#F39C12;color:#fff;padding:10px 18px;border-radius:4px;text-decoration:none;">{{template "action" .}}</a>
+    </p>
+    <p style="font-size:12px;color:#7f8c8d;margin-top:24px;">EduBot. Настроить уведомления можно в личном кабинете.</p>
+  </div>
+</body>
+</html>
+`
+
+var (
+	emailTextBase = texttemplate.Must(texttemplate.New("layout").Parse(emailTextLayout))
+	emailHTMLBase = htmltemplate.Must(htmltemplate.New("layout").Parse(emailHTMLLayout))
+)
+
+// newEmailTemplate создает шаблоны письма: blocks определяет "intro" и "action"
+func newEmailTemplate(subject, blocks string) emailTemplate {
+	text := texttemplate.Must(emailTextBase.Clone())
+	html := htmltemplate.Must(emailHTMLBase.Clone())
+	return emailTemplate{
+		subject: subject,
+		text:    texttemplate.Must(text.Parse(blocks)),
+		html:    htmltemplate.Must(html.Parse(blocks)),
+	}
+}
+
+var emailTemplates = map[models.NotificationType]emailTemplate{
+	models.NotificationTypeNewAssignment: newEmailTemplate("Новое задание",
+		`{{define "intro"}}Вам выдано новое задание.{{end}}{{define "action"}}Открыть задание{{end}}`),
+	models.NotificationTypeDeadlineReminder: newEmailTemplate("Скоро дедлайн",
+		`{{define "intro"}}Напоминаем о приближающемся сроке сдачи.{{end}}{{define "action"}}Перейти к заданию{{end}}`),
+	models.NotificationTypeOverdue: newEmailTemplate("Задание просрочено",
+		`{{define "intro"}}Срок сдачи задания истёк.{{end}}{{define "action"}}Сдать задание{{end}}`),
+	models.NotificationTypeGradeReceived: newEmailTemplate("Задание проверено",
+		`{{define "intro"}}Преподаватель проверил вашу работу.{{end}}{{define "action"}}Посмотреть оценку{{end}}`),
+	models.NotificationTypeNewMessage: newEmailTemplate("Новое сообщение",
+		`{{define "intro"}}Вам пришло новое сообщение в чате.{{end}}{{define "action"}}Открыть чат{{end}}`),
+	models.NotificationTypeGroupInvite: newEmailTemplate("Приглашение в группу",
+		`{{define "intro"}}Вас пригласили в учебную группу.{{end}}{{define "action"}}Открыть группу{{end}}`),
+}
+
+// emailVerificationTemplate — письмо для подтверждения адреса
+var emailVerificationTemplate = newEmailTemplate("Подтвердите email",
+	`{{define "intro"}}Вы указали этот адрес для уведомлений EduBot. Ссылка действует ограниченное время.{{end}}{{define "action"}}Подтвердить email{{end}}`)
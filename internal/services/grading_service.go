@@ -2,13 +2,15 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
 
 	"edubot/internal/models"
+	"edubot/internal/policy"
 	"edubot/internal/repository"
-	"edubot/pkg/telegram"
 )
 
 type GradingService interface {
@@ -19,7 +21,7 @@ type GradingService interface {
 	DeleteFeedback(id uuid.UUID) error
 
 	// Grading operations
-	GradeAssignment(assignmentTargetID, teacherID uuid.UUID, score *float64, text string, mediaIDs []uuid.UUID) (*models.Feedback, error)
+	GradeAssignment(assignmentTargetID uuid.UUID, actor Actor, score *float64, text string, mediaIDs []uuid.UUID) (*models.Feedback, error)
 	GetFeedbacksByAssignmentTarget(assignmentTargetID uuid.UUID) ([]*models.Feedback, error)
 	GetFeedbacksByTeacher(teacherID uuid.UUID) ([]*models.Feedback, error)
 	GetLatestFeedback(assignmentTargetID uuid.UUID) (*models.Feedback, error)
@@ -27,6 +29,10 @@ type GradingService interface {
 	// Teacher inbox operations
 	GetPendingGrading(teacherID uuid.UUID) ([]*models.AssignmentTarget, error)
 	GetGradedAssignments(teacherID uuid.UUID) ([]*models.AssignmentTarget, error)
+	GetTeacherTargets(teacherID uuid.UUID) ([]*models.AssignmentTarget, error)
+
+	// Assistant inbox operations
+	GetAssistantTargets(assistantID uuid.UUID, status models.AssignmentTargetStatus) ([]*models.AssignmentTarget, error)
 }
 
 type gradingService struct {
@@ -34,8 +40,10 @@ type gradingService struct {
 	assignmentTargetRepo repository.AssignmentTargetRepository
 	submissionRepo       repository.SubmissionRepository
 	userRepo             repository.UserRepository
-	notificationRepo     repository.NotificationRepository
-	bot                  *telegram.Bot
+	groupRepo            repository.GroupRepository
+	notificationService  NotificationService
+	policy               policy.Policy
+	audit                AuditService
 }
 
 func NewGradingService(
@@ -43,16 +51,20 @@ func NewGradingService(
 	assignmentTargetRepo repository.AssignmentTargetRepository,
 	submissionRepo repository.SubmissionRepository,
 	userRepo repository.UserRepository,
-	notificationRepo repository.NotificationRepository,
-	bot *telegram.Bot,
+	groupRepo repository.GroupRepository,
+	notificationService NotificationService,
+	pol policy.Policy,
+	audit AuditService,
 ) GradingService {
 	return &gradingService{
 		feedbackRepo:         feedbackRepo,
 		assignmentTargetRepo: assignmentTargetRepo,
 		submissionRepo:       submissionRepo,
 		userRepo:             userRepo,
-		notificationRepo:     notificationRepo,
-		bot:                  bot,
+		groupRepo:            groupRepo,
+		notificationService:  notificationService,
+		policy:               pol,
+		audit:                audit,
 	}
 }
 
@@ -79,7 +91,7 @@ func (s *gradingService) DeleteFeedback(id uuid.UUID) error {
 	return s.feedbackRepo.Delete(id)
 }
 
-func (s *gradingService) GradeAssignment(assignmentTargetID, teacherID uuid.UUID, score *float64, text string, mediaIDs []uuid.UUID) (*models.Feedback, error) {
+func (s *gradingService) GradeAssignment(assignmentTargetID uuid.UUID, actor Actor, score *float64, text string, mediaIDs []uuid.UUID) (*models.Feedback, error) {
 	// Получаем AssignmentTarget
 	target, err := s.assignmentTargetRepo.GetByID(assignmentTargetID)
 	if err != nil {
@@ -97,16 +109,19 @@ func (s *gradingService) GradeAssignment(assignmentTargetID, teacherID uuid.UUID
 		return nil, err
 	}
 
-	// Проверяем права учителя
-	if assignment.Assignment.TeacherID != teacherID {
-		return nil, errors.New("access denied: not assignment teacher")
+	// Проверяем права: преподаватель задания или ассистент группы
+	grader := actor.User
+	resource := policy.Resource{TeacherID: assignment.Assignment.TeacherID, GroupID: assignment.Assignment.GroupID}
+	if grader == nil || !s.policy.Can(grader, policy.ActionGrade, resource) {
+		return nil, ErrAccessDenied
 	}
+	before := map[string]interface{}{"status": target.Status, "score": target.Score}
 
 	// Создаем Feedback
 	feedback := &models.Feedback{
 		ID:                 uuid.New(),
 		AssignmentTargetID: assignmentTargetID,
-		TeacherID:          teacherID,
+		TeacherID:          grader.ID,
 		Text:               text,
 		Score:              score,
 		CreatedAt:          time.Now(),
@@ -128,6 +143,20 @@ func (s *gradingService) GradeAssignment(assignmentTargetID, teacherID uuid.UUID
 		return nil, err
 	}
 
+	s.audit.Record(actor, AuditEntry{
+		Action:     AuditActionGrade,
+		EntityType: AuditEntityAssignmentTarget,
+		EntityID:   target.ID,
+		OwnerID:    &assignment.Assignment.TeacherID,
+		Before:     before,
+		After: map[string]interface{}{
+			"status":      target.Status,
+			"score":       target.Score,
+			"feedback_id": feedback.ID,
+			"text":        text,
+		},
+	})
+
 	// Обновляем последний Submission с оценкой
 	submissions, err := s.submissionRepo.GetByAssignmentTarget(assignmentTargetID)
 	if err == nil && len(submissions) > 0 {
@@ -141,32 +170,27 @@ func (s *gradingService) GradeAssignment(assignmentTargetID, teacherID uuid.UUID
 		s.submissionRepo.Update(latestSubmission)
 	}
 
-	// Отправляем уведомление студенту
-	if s.bot != nil {
-		student, err := s.userRepo.GetByID(target.StudentID)
-		if err == nil && student.TelegramID != 0 {
-			s.bot.SendFeedbackNotification(
-				student.TelegramID,
-				assignment.Assignment.Title,
-				assignment.Assignment.Subject,
-				s.scoreToString(score),
-				text,
-			)
-		}
-	}
-
-	// Создаем уведомление в системе
-	s.notificationRepo.Create(&models.Notification{
-		UserID:    target.StudentID,
-		Type:      models.NotificationTypeGradeReceived,
-		Title:     "Получена оценка",
-		Message:   "Ваше задание оценено: " + assignment.Assignment.Title,
-		Payload:   `{"feedback_id":"` + feedback.ID.String() + `","assignment_target_id":"` + assignmentTargetID.String() + `"}`,
-		Channel:   models.NotificationChannelBot,
-		Status:    models.NotificationStatusPending,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	})
+	// Уведомляем студента по включенным им каналам
+	message := fmt.Sprintf("Задание: %s\nПредмет: %s\nОценка: %s",
+		assignment.Assignment.Title, assignment.Assignment.Subject, gradeLabel(s.scoreToString(score)))
+	if text != "" {
+		message += "\n\nКомментарий учителя:\n" + text
+	}
+	if err := s.notificationService.CreateNotification(&models.Notification{
+		UserID:  target.StudentID,
+		Type:    models.NotificationTypeGradeReceived,
+		Title:   "Ваше задание проверено",
+		Message: message,
+		Payload: `{"feedback_id":"` + feedback.ID.String() + `","assignment_target_id":"` + assignmentTargetID.String() + `"}`,
+	}); err != nil {
+		log.Printf("Failed to create grade notification: %v", err)
+	}
+	if err := s.notificationService.NotifyParents(target.StudentID, models.NotificationTypeGradeReceived,
+		"Новая оценка",
+		fmt.Sprintf("%s получил(а) оценку\n\n%s", studentDisplayName(&target.Student), message),
+		`{"feedback_id":"`+feedback.ID.String()+`","assignment_target_id":"`+assignmentTargetID.String()+`"}`); err != nil {
+		log.Printf("Failed to notify parents about grade: %v", err)
+	}
 
 	return feedback, nil
 }
@@ -185,12 +209,33 @@ func (s *gradingService) GetLatestFeedback(assignmentTargetID uuid.UUID) (*model
 
 func (s *gradingService) GetPendingGrading(teacherID uuid.UUID) ([]*models.AssignmentTarget, error) {
 	// Получаем все AssignmentTarget со статусом "submitted" для заданий этого учителя
-	return s.assignmentTargetRepo.ListByStatus(models.AssignmentTargetStatusSubmitted)
+	return s.assignmentTargetRepo.ListByTeacher(teacherID, models.AssignmentTargetStatusSubmitted)
 }
 
 func (s *gradingService) GetGradedAssignments(teacherID uuid.UUID) ([]*models.AssignmentTarget, error) {
 	// Получаем все AssignmentTarget со статусом "graded" для заданий этого учителя
-	return s.assignmentTargetRepo.ListByStatus(models.AssignmentTargetStatusGraded)
+	return s.assignmentTargetRepo.ListByTeacher(teacherID, models.AssignmentTargetStatusGraded)
+}
+
+func (s *gradingService) GetTeacherTargets(teacherID uuid.UUID) ([]*models.AssignmentTarget, error) {
+	// Все AssignmentTarget заданий этого учителя для его учеников
+	return s.assignmentTargetRepo.ListByTeacher(teacherID, "")
+}
+
+func (s *gradingService) GetAssistantTargets(assistantID uuid.UUID, status models.AssignmentTargetStatus) ([]*models.AssignmentTarget, error) {
+	// AssignmentTarget групповых заданий в группах, где пользователь — ассистент
+	groups, err := s.groupRepo.ListByMember(assistantID, models.GroupRoleAssistant)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return []*models.AssignmentTarget{}, nil
+	}
+	groupIDs := make([]uuid.UUID, 0, len(groups))
+	for _, g := range groups {
+		groupIDs = append(groupIDs, g.ID)
+	}
+	return s.assignmentTargetRepo.ListByGroups(groupIDs, status)
 }
 
 // Helper method to convert score to string
@@ -212,3 +257,11 @@ func (s *gradingService) scoreToString(score *float64) string {
 		return "needs_revision"
 	}
 }
+
+// gradeLabel переводит оценку в текст для уведомления
+func gradeLabel(grade string) string {
+	if grade == "needs_revision" {
+		return "на доработку"
+	}
+	return grade
+}
@@ -1,15 +1,19 @@
 package services
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 
 	"edubot/internal/models"
+	"edubot/internal/policy"
 	"edubot/internal/repository"
-	"edubot/pkg/telegram"
 )
 
+// ErrAccessDenied — политика доступа запретила действие
+var ErrAccessDenied = errors.New("access denied")
+
 type GroupService interface {
 	CreateGroup(teacherID uuid.UUID, name, subject string, grade, level int) (*models.Group, error)
 	GetGroup(id uuid.UUID) (*models.Group, error)
@@ -17,24 +21,25 @@ type GroupService interface {
 	DeleteGroup(id uuid.UUID) error
 	ListGroups(teacherID uuid.UUID) ([]*models.Group, error)
 	ListGroupsForStudent(studentID uuid.UUID) ([]*models.Group, error)
+	ListGroupsByMemberRole(userID uuid.UUID, role string) ([]*models.Group, error)
 
-	AddMember(groupID, userID uuid.UUID) error
-	RemoveMember(groupID, userID uuid.UUID) error
+	AddMember(actor *models.User, groupID, userID uuid.UUID, role string) error
+	RemoveMember(actor *models.User, groupID, userID uuid.UUID) error
 	ListMembers(groupID uuid.UUID) ([]*models.GroupMember, error)
 	IsMember(groupID, userID uuid.UUID) (bool, error)
 
-	AssignHomeworkToGroup(groupID uuid.UUID, title, description, subject string, grade, level int, due *time.Time) error
+	AssignHomeworkToGroup(actor *models.User, groupID uuid.UUID, title, description, subject string, grade, level int, due *time.Time) error
 }
 
 type groupService struct {
 	groups  repository.GroupRepository
 	users   repository.UserRepository
-	assigns repository.AssignmentRepository
-	bot     *telegram.Bot
+	assigns AssignmentService
+	policy  policy.Policy
 }
 
-func NewGroupService(groups repository.GroupRepository, users repository.UserRepository, assigns repository.AssignmentRepository, bot *telegram.Bot) GroupService {
-	return &groupService{groups: groups, users: users, assigns: assigns, bot: bot}
+func NewGroupService(groups repository.GroupRepository, users repository.UserRepository, assigns AssignmentService, pol policy.Policy) GroupService {
+	return &groupService{groups: groups, users: users, assigns: assigns, policy: pol}
 }
 
 func (s *groupService) CreateGroup(teacherID uuid.UUID, name, subject string, grade, level int) (*models.Group, error) {
@@ -99,15 +104,44 @@ func (s *groupService) ListGroupsForStudent(studentID uuid.UUID) ([]*models.Grou
 	return groups, nil
 }
 
-func (s *groupService) AddMember(groupID, userID uuid.UUID) error {
-	m := &models.GroupMember{GroupID: groupID, UserID: userID}
+func (s *groupService) ListGroupsByMemberRole(userID uuid.UUID, role string) ([]*models.Group, error) {
+	return s.groups.ListByMember(userID, role)
+}
+
+// AddMember добавляет участника; менять состав группы может только ее преподаватель
+func (s *groupService) AddMember(actor *models.User, groupID, userID uuid.UUID, role string) error {
+	if err := s.authorizeMembers(actor, groupID); err != nil {
+		return err
+	}
+	if role == "" {
+		role = models.GroupRoleStudent
+	}
+	if role != models.GroupRoleStudent && role != models.GroupRoleAssistant {
+		return errors.New("invalid member role")
+	}
+	m := &models.GroupMember{GroupID: groupID, UserID: userID, Role: role}
 	return s.groups.AddMember(m)
 }
 
-func (s *groupService) RemoveMember(groupID, userID uuid.UUID) error {
+// RemoveMember удаляет участника; менять состав группы может только ее преподаватель
+func (s *groupService) RemoveMember(actor *models.User, groupID, userID uuid.UUID) error {
+	if err := s.authorizeMembers(actor, groupID); err != nil {
+		return err
+	}
 	return s.groups.RemoveMember(groupID, userID)
 }
 
+func (s *groupService) authorizeMembers(actor *models.User, groupID uuid.UUID) error {
+	group, err := s.groups.GetByID(groupID)
+	if err != nil {
+		return err
+	}
+	if !s.policy.Can(actor, policy.ActionManageMembers, policy.Resource{TeacherID: group.TeacherID, GroupID: &group.ID}) {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
 func (s *groupService) ListMembers(groupID uuid.UUID) ([]*models.GroupMember, error) {
 	return s.groups.ListMembers(groupID)
 }
@@ -126,29 +160,22 @@ func (s *groupService) IsMember(groupID, userID uuid.UUID) (bool, error) {
 	return false, nil
 }
 
-func (s *groupService) AssignHomeworkToGroup(groupID uuid.UUID, title, description, subject string, grade, level int, due *time.Time) error {
+func (s *groupService) AssignHomeworkToGroup(actor *models.User, groupID uuid.UUID, title, description, subject string, grade, level int, due *time.Time) error {
 	// Получаем группу для проверки TeacherID
 	group, err := s.groups.GetByID(groupID)
 	if err != nil {
 		return err
 	}
-
-	// Используем новый AssignmentService для создания группового задания
-	assignmentService := NewAssignmentService(
-		s.assigns,
-		nil, // assignmentTargetRepo - нужно будет добавить
-		s.groups,
-		s.users,
-		nil, // notificationRepo - нужно будет добавить
-		s.bot,
-	)
+	if !s.policy.Can(actor, policy.ActionEditAssignment, policy.Resource{TeacherID: group.TeacherID, GroupID: &group.ID}) {
+		return ErrAccessDenied
+	}
 
 	dueDate := time.Now().Add(7 * 24 * time.Hour) // По умолчанию через неделю
 	if due != nil {
 		dueDate = *due
 	}
 
-	_, err = assignmentService.CreateGroupAssignment(
+	_, err = s.assigns.CreateGroupAssignment(
 		group.TeacherID,
 		groupID,
 		title,
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"edubot/internal/models"
+	"edubot/internal/repository"
+	"edubot/pkg/telegram"
+)
+
+// Без похожих символов (0/O, 1/I), чтобы код было легко продиктовать.
+// Подходит и для параметра start ссылки на бота (A-Z, 0-9, _ и -).
+const inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// personalInviteTTL — срок персонального приглашения, которое преподаватель отправляет ученику
+const personalInviteTTL = 7 * 24 * time.Hour
+
+var (
+	// ErrInvalidInvite — код не найден, истек, отозван или исчерпан
+	ErrInvalidInvite = errors.New("invalid or expired invite code")
+	// ErrInviteRoleConflict — код ученика может активировать только гость или ученик
+	ErrInviteRoleConflict = errors.New("only guests and students can redeem a student invite")
+	// ErrInviteNotFound — код не найден среди кодов преподавателя
+	ErrInviteNotFound = errors.New("invite not found")
+)
+
+// InviteParams — настройки нового кода приглашения
+type InviteParams struct {
+	GroupID   *uuid.UUID
+	StudentID *uuid.UUID // Персональный код для одного пользователя
+	Grade     int
+	Subjects  string
+	MaxUses   int
+	ExpiresAt *time.Time
+}
+
+// InviteStudentParams — кого преподаватель приглашает персонально; пользователь
+// ищется по ID, Telegram ID или username
+type InviteStudentParams struct {
+	UserID     *uuid.UUID
+	TelegramID *int64
+	Username   string
+	Grade      *int
+	Subjects   string
+}
+
+// NewStudentParams — ученик, которого преподаватель добавляет вручную
+type NewStudentParams struct {
+	FirstName  string
+	LastName   string
+	Phone      string
+	TelegramID int64 // 0 — неизвестен
+	Grade      int
+	Subjects   string
+}
+
+type InviteService interface {
+	CreateInvite(teacherID uuid.UUID, params InviteParams) (*models.Invite, error)
+	InviteStudent(teacherID uuid.UUID, params InviteStudentParams) (*models.User, *models.Invite, error)
+	CreateStudentInvite(teacherID uuid.UUID, params NewStudentParams) (*models.User, *models.Invite, error)
+	ListInvites(teacherID uuid.UUID) ([]*models.Invite, error)
+	RevokeInvite(teacherID, inviteID uuid.UUID) error
+	Redeem(user *models.User, code string) (*models.Invite, error)
+
+	// DeepLink — ссылка t.me/<bot>?start=<code>; пустая, если бот не настроен
+	DeepLink(code string) string
+}
+
+type inviteService struct {
+	inviteRepo         repository.InviteRepository
+	userRepo           repository.UserRepository
+	teacherStudentRepo repository.TeacherStudentRepository
+	groupRepo          repository.GroupRepository
+	roleChangeRepo     repository.RoleChangeRepository
+	telegramBot        *telegram.Bot
+	botUsername        string
+}
+
+func NewInviteService(
+	inviteRepo repository.InviteRepository,
+	userRepo repository.UserRepository,
+	teacherStudentRepo repository.TeacherStudentRepository,
+	groupRepo repository.GroupRepository,
+	roleChangeRepo repository.RoleChangeRepository,
+	telegramBot *telegram.Bot,
+) InviteService {
+	botUsername := ""
+	if telegramBot != nil {
+		botUsername = telegramBot.Username()
+	}
+	return &inviteService{
+		inviteRepo:         inviteRepo,
+		userRepo:           userRepo,
+		teacherStudentRepo: teacherStudentRepo,
+		groupRepo:          groupRepo,
+		roleChangeRepo:     roleChangeRepo,
+		telegramBot:        telegramBot,
+		botUsername:        botUsername,
+	}
+}
+
+// CreateInvite создает код приглашения; группа должна принадлежать преподавателю
+func (s *inviteService) CreateInvite(teacherID uuid.UUID, params InviteParams) (*models.Invite, error) {
+	if params.MaxUses < 0 {
+		return nil, fmt.Errorf("max uses must not be negative")
+	}
+	if params.ExpiresAt != nil && !params.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("expiry must be in the future")
+	}
+	if params.GroupID != nil {
+		group, err := s.groupRepo.GetByID(*params.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		if group.TeacherID != teacherID {
+			return nil, ErrAccessDenied
+		}
+	}
+
+	code, err := generateInviteCode("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invite code: %w", err)
+	}
+
+	now := time.Now()
+	invite := &models.Invite{
+		ID:        uuid.New(),
+		Code:      code,
+		TeacherID: teacherID,
+		GroupID:   params.GroupID,
+		StudentID: params.StudentID,
+		Grade:     params.Grade,
+		Subjects:  params.Subjects,
+		MaxUses:   params.MaxUses,
+		ExpiresAt: params.ExpiresAt,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.inviteRepo.Create(invite); err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+// InviteStudent отправляет пользователю персональный одноразовый код. Учеником преподавателя
+// он становится только после того, как сам активирует код.
+func (s *inviteService) InviteStudent(teacherID uuid.UUID, params InviteStudentParams) (*models.User, *models.Invite, error) {
+	var user *models.User
+	var err error
+	switch {
+	case params.UserID != nil:
+		user, err = s.userRepo.GetByID(*params.UserID)
+	case params.TelegramID != nil && *params.TelegramID != 0:
+		user, err = s.userRepo.GetByTelegramID(*params.TelegramID)
+	case params.Username != "":
+		user, err = s.userRepo.GetByUsername(params.Username)
+	default:
+		return nil, nil, fmt.Errorf("no identifier provided")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.Role != models.RoleGuest && user.Role != models.RoleStudent {
+		return nil, nil, ErrInviteRoleConflict
+	}
+
+	grade := 0
+	if params.Grade != nil {
+		grade = *params.Grade
+	}
+	expiresAt := time.Now().Add(personalInviteTTL)
+	invite, err := s.CreateInvite(teacherID, InviteParams{
+		StudentID: &user.ID,
+		Grade:     grade,
+		Subjects:  params.Subjects,
+		MaxUses:   1,
+		ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Приглашение ученику: ссылка на бота активирует код
+	if s.telegramBot != nil && user.TelegramID != 0 {
+		teacherName := "Преподаватель"
+		if teacher, err := s.userRepo.GetByID(teacherID); err == nil {
+			teacherName = strings.TrimSpace(teacher.FirstName + " " + teacher.LastName)
+		}
+		text := fmt.Sprintf("🎓 %s приглашает вас в ученики. Код приглашения: %s", teacherName, invite.Code)
+		if link := s.DeepLink(invite.Code); link != "" {
+			text = fmt.Sprintf("🎓 %s приглашает вас в ученики. Чтобы принять приглашение, откройте ссылку: %s", teacherName, link)
+		}
+		if err := s.telegramBot.SendMessage(user.TelegramID, text); err != nil {
+			log.Printf("Failed to send student invite to %d: %v", user.TelegramID, err)
+		}
+	}
+	return user, invite, nil
+}
+
+// CreateStudentInvite выдает код для ученика, которого еще нет в системе. С Telegram ID
+// заводится профиль гостя и код становится персональным; без него выдается одноразовый код,
+// который преподаватель передает ученику сам.
+func (s *inviteService) CreateStudentInvite(teacherID uuid.UUID, params NewStudentParams) (*models.User, *models.Invite, error) {
+	if params.TelegramID == 0 {
+		expiresAt := time.Now().Add(personalInviteTTL)
+		invite, err := s.CreateInvite(teacherID, InviteParams{
+			Grade:     params.Grade,
+			Subjects:  params.Subjects,
+			MaxUses:   1,
+			ExpiresAt: &expiresAt,
+		})
+		return nil, invite, err
+	}
+
+	user, err := s.userRepo.GetByTelegramID(params.TelegramID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user = &models.User{
+			TelegramID: params.TelegramID,
+			FirstName:  params.FirstName,
+			LastName:   params.LastName,
+			Phone:      params.Phone,
+			Role:       models.RoleGuest,
+		}
+		err = s.userRepo.Create(user)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	grade := params.Grade
+	return s.InviteStudent(teacherID, InviteStudentParams{UserID: &user.ID, Grade: &grade, Subjects: params.Subjects})
+}
+
+func (s *inviteService) ListInvites(teacherID uuid.UUID) ([]*models.Invite, error) {
+	return s.inviteRepo.ListByTeacher(teacherID)
+}
+
+func (s *inviteService) RevokeInvite(teacherID, inviteID uuid.UUID) error {
+	revoked, err := s.inviteRepo.Revoke(inviteID, teacherID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrInviteNotFound
+	}
+	return nil
+}
+
+// Redeem активирует код: пользователь становится учеником преподавателя, получает класс
+// и предметы из кода и попадает в группу. Повторная активация тем же пользователем
+// не расходует использование.
+func (s *inviteService) Redeem(user *models.User, code string) (*models.Invite, error) {
+	if user.Role != models.RoleGuest && user.Role != models.RoleStudent {
+		return nil, ErrInviteRoleConflict
+	}
+
+	invite, err := s.inviteRepo.GetByCode(code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidInvite
+		}
+		return nil, err
+	}
+	if !invite.Usable(time.Now()) {
+		return nil, ErrInvalidInvite
+	}
+	if invite.StudentID != nil && *invite.StudentID != user.ID {
+		return nil, ErrInvalidInvite
+	}
+
+	// Класс и предметы ученика другого преподавателя не меняем: они относятся и к его занятиям
+	keepProfile, err := s.linkedToOtherTeacher(user, invite.TeacherID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.inviteRepo.Redeem(invite.ID, user.ID); err != nil {
+		if errors.Is(err, repository.ErrInviteExhausted) {
+			return nil, ErrInvalidInvite
+		}
+		return nil, err
+	}
+
+	oldRole := user.Role
+	user.Role = models.RoleStudent
+	if invite.Grade != 0 && !keepProfile {
+		user.Grade = invite.Grade
+	}
+	if invite.Subjects != "" && !keepProfile {
+		user.Subjects = invite.Subjects
+	}
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	if oldRole != models.RoleStudent {
+		recordRoleChange(s.roleChangeRepo, user.ID, oldRole, models.RoleStudent, &invite.TeacherID, RoleChangeInvite)
+	}
+
+	if err := s.teacherStudentRepo.Link(invite.TeacherID, user.ID, invite.Subjects); err != nil {
+		return nil, fmt.Errorf("failed to link student: %w", err)
+	}
+
+	if invite.GroupID != nil {
+		role, err := s.groupRepo.GetMemberRole(*invite.GroupID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if role == "" {
+			member := &models.GroupMember{GroupID: *invite.GroupID, UserID: user.ID, Role: models.GroupRoleStudent}
+			if err := s.groupRepo.AddMember(member); err != nil {
+				return nil, fmt.Errorf("failed to add group member: %w", err)
+			}
+		}
+	}
+
+	return invite, nil
+}
+
+// linkedToOtherTeacher сообщает, занимается ли ученик уже у другого преподавателя
+func (s *inviteService) linkedToOtherTeacher(user *models.User, teacherID uuid.UUID) (bool, error) {
+	if user.Role != models.RoleStudent {
+		return false, nil
+	}
+	teachers, err := s.teacherStudentRepo.ListTeachers(user.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, teacher := range teachers {
+		if teacher.ID != teacherID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *inviteService) DeepLink(code string) string {
+	if s.botUsername == "" {
+		return ""
+	}
+	return "https://t.me/" + s.botUsername + "?start=" + url.QueryEscape(code)
+}
+
+// generateInviteCode генерирует код из 8 символов с префиксом
+func generateInviteCode(prefix string) (string, error) {
+	buf := make([]byte, 8)
+	max := big.NewInt(int64(len(inviteCodeAlphabet)))
+	for i := range buf {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		buf[i] = inviteCodeAlphabet[n.Int64()]
+	}
+	return prefix + string(buf), nil
+}
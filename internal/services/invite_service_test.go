@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"edubot/internal/models"
+	"edubot/internal/repository"
+	"edubot/pkg/database"
+)
+
+// inviteFixture — сервис кодов приглашения на временной SQLite
+type inviteFixture struct {
+	service            InviteService
+	userRepo           repository.UserRepository
+	teacherStudentRepo repository.TeacherStudentRepository
+	nextTelegramID     int64
+}
+
+func newInviteFixture(t *testing.T) *inviteFixture {
+	t.Helper()
+	t.Setenv("DATABASE_URL", "")
+	db, err := database.NewDatabase(filepath.Join(t.TempDir(), "edubot.db"))
+	if err != nil {
+		t.Fatalf("database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	f := &inviteFixture{
+		userRepo:           repository.NewUserRepository(db.DB),
+		teacherStudentRepo: repository.NewTeacherStudentRepository(db.DB),
+		nextTelegramID:     1000,
+	}
+	f.service = NewInviteService(
+		repository.NewInviteRepository(db.DB),
+		f.userRepo,
+		f.teacherStudentRepo,
+		repository.NewGroupRepository(db.DB),
+		repository.NewRoleChangeRepository(db.DB),
+		nil,
+	)
+	return f
+}
+
+func (f *inviteFixture) user(t *testing.T, role models.UserRole) *models.User {
+	t.Helper()
+	f.nextTelegramID++
+	user := &models.User{TelegramID: f.nextTelegramID, Role: role}
+	if err := f.userRepo.Create(user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+func (f *inviteFixture) linked(t *testing.T, teacher, student *models.User) bool {
+	t.Helper()
+	linked, err := f.teacherStudentRepo.IsLinked(teacher.ID, student.ID)
+	if err != nil {
+		t.Fatalf("IsLinked: %v", err)
+	}
+	return linked
+}
+
+func TestInviteStudentRequiresAcceptance(t *testing.T) {
+	f := newInviteFixture(t)
+	teacher := f.user(t, models.RoleTeacher)
+	guest := f.user(t, models.RoleGuest)
+	other := f.user(t, models.RoleGuest)
+
+	grade := 10
+	_, invite, err := f.service.InviteStudent(teacher.ID, InviteStudentParams{UserID: &guest.ID, Grade: &grade, Subjects: "math"})
+	if err != nil {
+		t.Fatalf("InviteStudent: %v", err)
+	}
+
+	// До активации пользователь остается гостем и не связан с преподавателем
+	stored, _ := f.userRepo.GetByID(guest.ID)
+	if stored.Role != models.RoleGuest || f.linked(t, teacher, guest) {
+		t.Fatalf("user claimed before accepting: role %s", stored.Role)
+	}
+
+	// Персональный код не подходит другому пользователю
+	if _, err := f.service.Redeem(other, invite.Code); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("other user: expected ErrInvalidInvite, got %v", err)
+	}
+
+	if _, err := f.service.Redeem(stored, invite.Code); err != nil {
+		t.Fatalf("Redeem: %v", err)
+	}
+	stored, _ = f.userRepo.GetByID(guest.ID)
+	if stored.Role != models.RoleStudent || stored.Grade != 10 || stored.Subjects != "math" {
+		t.Errorf("unexpected student after accepting: %+v", stored)
+	}
+	if !f.linked(t, teacher, guest) {
+		t.Error("student is not linked after accepting")
+	}
+}
+
+func TestInviteStudentRejectsOtherRoles(t *testing.T) {
+	f := newInviteFixture(t)
+	teacher := f.user(t, models.RoleTeacher)
+	for _, role := range []models.UserRole{models.RoleParent, models.RoleTeacher, models.RoleAdmin} {
+		user := f.user(t, role)
+		if _, _, err := f.service.InviteStudent(teacher.ID, InviteStudentParams{TelegramID: &user.TelegramID}); !errors.Is(err, ErrInviteRoleConflict) {
+			t.Errorf("%s: expected ErrInviteRoleConflict, got %v", role, err)
+		}
+	}
+	if _, _, err := f.service.InviteStudent(teacher.ID, InviteStudentParams{}); err == nil {
+		t.Error("expected error without identifier")
+	}
+}
+
+func TestRedeemKeepsProfileOfOtherTeachersStudent(t *testing.T) {
+	f := newInviteFixture(t)
+	first := f.user(t, models.RoleTeacher)
+	second := f.user(t, models.RoleTeacher)
+	student := f.user(t, models.RoleGuest)
+
+	grade := 9
+	_, invite, err := f.service.InviteStudent(first.ID, InviteStudentParams{UserID: &student.ID, Grade: &grade, Subjects: "physics"})
+	if err != nil {
+		t.Fatalf("InviteStudent: %v", err)
+	}
+	if _, err := f.service.Redeem(student, invite.Code); err != nil {
+		t.Fatalf("Redeem first: %v", err)
+	}
+
+	grade = 11
+	_, invite, err = f.service.InviteStudent(second.ID, InviteStudentParams{UserID: &student.ID, Grade: &grade, Subjects: "math"})
+	if err != nil {
+		t.Fatalf("InviteStudent second: %v", err)
+	}
+	if _, err := f.service.Redeem(student, invite.Code); err != nil {
+		t.Fatalf("Redeem second: %v", err)
+	}
+
+	stored, _ := f.userRepo.GetByID(student.ID)
+	if stored.Grade != 9 || stored.Subjects != "physics" {
+		t.Errorf("profile overwritten by second teacher: grade %d, subjects %q", stored.Grade, stored.Subjects)
+	}
+	if !f.linked(t, first, student) || !f.linked(t, second, student) {
+		t.Error("student must be linked to both teachers")
+	}
+}
+
+func TestCreateStudentInvite(t *testing.T) {
+	f := newInviteFixture(t)
+	teacher := f.user(t, models.RoleTeacher)
+
+	// Без Telegram ID — одноразовый код, пользователь не создается
+	user, invite, err := f.service.CreateStudentInvite(teacher.ID, NewStudentParams{FirstName: "Anna", Grade: 10})
+	if err != nil {
+		t.Fatalf("CreateStudentInvite: %v", err)
+	}
+	if user != nil || invite.MaxUses != 1 || invite.StudentID != nil || invite.ExpiresAt == nil {
+		t.Errorf("unexpected open invite: user %v, invite %+v", user, invite)
+	}
+
+	// С Telegram ID — профиль гостя и персональный код
+	user, invite, err = f.service.CreateStudentInvite(teacher.ID, NewStudentParams{FirstName: "Ivan", TelegramID: 555, Grade: 11, Subjects: "math"})
+	if err != nil {
+		t.Fatalf("CreateStudentInvite with telegram id: %v", err)
+	}
+	if user.Role != models.RoleGuest || user.FirstName != "Ivan" || f.linked(t, teacher, user) {
+		t.Errorf("user must stay an unlinked guest until accepting: %+v", user)
+	}
+	if invite.StudentID == nil || *invite.StudentID != user.ID || invite.Grade != 11 {
+		t.Errorf("expected personal invite for %s, got %+v", user.ID, invite)
+	}
+}
@@ -26,25 +26,29 @@ type MediaService interface {
 	DeleteMedia(id uuid.UUID, userID uuid.UUID) error
 	RecordView(mediaID, userID uuid.UUID, duration int) error
 	GetMediaViews(mediaID uuid.UUID, limit int) ([]*models.MediaView, error)
-	GrantMediaAccess(mediaID, userID uuid.UUID, permission string) error
-	RevokeMediaAccess(mediaID, userID uuid.UUID) error
+	GrantMediaAccess(mediaID, userID uuid.UUID, permission string, actor Actor) error
+	RevokeMediaAccess(mediaID, userID uuid.UUID, actor Actor) error
 	CheckMediaAccess(mediaID, userID uuid.UUID) (bool, error)
 }
 
 type mediaService struct {
-	mediaRepo      repository.MediaRepository
-	userRepo       repository.UserRepository
-	bot            *telegram.Bot
-	assignmentRepo repository.AssignmentRepository
+	mediaRepo          repository.MediaRepository
+	userRepo           repository.UserRepository
+	bot                *telegram.Bot
+	assignmentRepo     repository.AssignmentRepository
+	teacherStudentRepo repository.TeacherStudentRepository
+	audit              AuditService
 }
 
 // NewMediaService создает новый сервис медиафайлов
-func NewMediaService(mediaRepo repository.MediaRepository, userRepo repository.UserRepository, bot *telegram.Bot, assignmentRepo repository.AssignmentRepository) MediaService {
+func NewMediaService(mediaRepo repository.MediaRepository, userRepo repository.UserRepository, bot *telegram.Bot, assignmentRepo repository.AssignmentRepository, teacherStudentRepo repository.TeacherStudentRepository, audit AuditService) MediaService {
 	return &mediaService{
-		mediaRepo:      mediaRepo,
-		userRepo:       userRepo,
-		bot:            bot,
-		assignmentRepo: assignmentRepo,
+		mediaRepo:          mediaRepo,
+		userRepo:           userRepo,
+		bot:                bot,
+		assignmentRepo:     assignmentRepo,
+		teacherStudentRepo: teacherStudentRepo,
+		audit:              audit,
 	}
 }
 
@@ -207,7 +211,13 @@ func (s *mediaService) GetMediaViews(mediaID uuid.UUID, limit int) ([]*models.Me
 }
 
 // GrantMediaAccess предоставляет доступ к медиафайлу
-func (s *mediaService) GrantMediaAccess(mediaID, userID uuid.UUID, permission string) error {
+func (s *mediaService) GrantMediaAccess(mediaID, userID uuid.UUID, permission string, actor Actor) error {
+	media, err := s.mediaRepo.GetByID(mediaID)
+	if err != nil {
+		return fmt.Errorf("media not found: %w", err)
+	}
+	before := s.accessPermission(mediaID, userID)
+
 	access := &models.MediaAccess{
 		ID:         uuid.New(),
 		MediaID:    mediaID,
@@ -216,12 +226,56 @@ func (s *mediaService) GrantMediaAccess(mediaID, userID uuid.UUID, permission st
 		CreatedAt:  time.Now(),
 	}
 
-	return s.mediaRepo.GrantAccess(access)
+	if err := s.mediaRepo.GrantAccess(access); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:     AuditActionGrantAccess,
+		EntityType: AuditEntityMediaAccess,
+		EntityID:   mediaID,
+		OwnerID:    &media.OwnerID,
+		Before:     before,
+		After:      map[string]interface{}{"user_id": userID, "permission": permission},
+	})
+	return nil
 }
 
 // RevokeMediaAccess отзывает доступ к медиафайлу
-func (s *mediaService) RevokeMediaAccess(mediaID, userID uuid.UUID) error {
-	return s.mediaRepo.RevokeAccess(mediaID, userID)
+func (s *mediaService) RevokeMediaAccess(mediaID, userID uuid.UUID, actor Actor) error {
+	media, err := s.mediaRepo.GetByID(mediaID)
+	if err != nil {
+		return fmt.Errorf("media not found: %w", err)
+	}
+	before := s.accessPermission(mediaID, userID)
+
+	if err := s.mediaRepo.RevokeAccess(mediaID, userID); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:     AuditActionRevokeAccess,
+		EntityType: AuditEntityMediaAccess,
+		EntityID:   mediaID,
+		OwnerID:    &media.OwnerID,
+		Before:     before,
+		After:      map[string]interface{}{"user_id": userID},
+	})
+	return nil
+}
+
+// accessPermission возвращает текущее явное право пользователя на медиафайл для журнала
+func (s *mediaService) accessPermission(mediaID, userID uuid.UUID) map[string]interface{} {
+	list, err := s.mediaRepo.GetAccessList(mediaID)
+	if err != nil {
+		return nil
+	}
+	for _, access := range list {
+		if access.UserID == userID {
+			return map[string]interface{}{"user_id": userID, "permission": access.Permission}
+		}
+	}
+	return map[string]interface{}{"user_id": userID}
 }
 
 // CheckMediaAccess проверяет доступ к медиафайлу
@@ -296,8 +350,11 @@ func (s *mediaService) CheckMediaAccess(mediaID, userID uuid.UUID) (bool, error)
 			}
 			return sub.UserID == userID || a.TeacherID == userID, nil
 		}
-		// Без сущности — по роли (ученик/учитель) запрещено, кроме владельца (уже проверили)
-		return user.Role == models.RoleTeacher, nil
+		// Без сущности — только преподавателю этого ученика (владельца уже проверили)
+		if user.Role != models.RoleTeacher {
+			return false, nil
+		}
+		return s.teacherStudentRepo.IsLinked(userID, media.OwnerID)
 	}
 
 	// Приватные: доступ только по явному доступу в ACL
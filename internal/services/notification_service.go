@@ -1,13 +1,20 @@
 package services
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 
 	"edubot/internal/models"
+	"edubot/internal/realtime"
 	"edubot/internal/repository"
+	"edubot/pkg/email"
 	"edubot/pkg/telegram"
 )
 
@@ -25,46 +32,163 @@ type NotificationService interface {
 
 	// Batch operations
 	CreateForGroup(userIDs []uuid.UUID, notificationType models.NotificationType, title, message, payload string) error
+	NotifyParents(studentID uuid.UUID, notificationType models.NotificationType, title, message, payload string) error
 	SendPendingNotifications() error
 
+	// Dead letters
+	ListUndeliveredNotifications(teacherID uuid.UUID, status models.NotificationStatus) ([]*models.Notification, error)
+	RequeueNotification(teacherID, id uuid.UUID) error
+
+	// Preferences
+	GetPreferences(userID uuid.UUID) (*NotificationPreferences, error)
+	UpdatePreferences(userID uuid.UUID, update *NotificationPreferences) (*NotificationPreferences, error)
+
 	// Deadline management
 	ScheduleDeadlineReminders() error
 	ScheduleOverdueNotifications() error
 	CleanupOldNotifications() error
 }
 
+// NotificationPreferences — матрица «тип × канал» и тихие часы пользователя
+type NotificationPreferences struct {
+	Channels   map[models.NotificationType]map[models.NotificationChannel]bool `json:"channels"`
+	QuietHours *QuietHours                                                     `json:"quiet_hours,omitempty"`
+}
+
+// QuietHours — интервал, в который push-уведомления откладываются (в часовом поясе пользователя)
+type QuietHours struct {
+	Enabled bool   `json:"enabled"`
+	Start   string `json:"start"` // HH:MM
+	End     string `json:"end"`   // HH:MM
+}
+
+// RetryPolicy задает повторные попытки доставки с экспоненциальной задержкой
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Backoff возвращает задержку перед попыткой номер attempt+1
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
 type notificationService struct {
 	notificationRepo     repository.NotificationRepository
+	preferenceRepo       repository.NotificationPreferenceRepository
+	emailService         EmailService
+	retryPolicy          RetryPolicy
 	assignmentTargetRepo repository.AssignmentTargetRepository
 	assignmentRepo       repository.AssignmentRepository
 	userRepo             repository.UserRepository
+	parentRepo           repository.ParentRepository
+	teacherStudentRepo   repository.TeacherStudentRepository
 	bot                  *telegram.Bot
+	reminderOffsets      []time.Duration // По возрастанию
+	realtime             realtime.Publisher
+	telegramLinkRepo     repository.TelegramMessageLinkRepository
 }
 
 func NewNotificationService(
 	notificationRepo repository.NotificationRepository,
+	preferenceRepo repository.NotificationPreferenceRepository,
 	assignmentTargetRepo repository.AssignmentTargetRepository,
 	assignmentRepo repository.AssignmentRepository,
 	userRepo repository.UserRepository,
+	parentRepo repository.ParentRepository,
+	teacherStudentRepo repository.TeacherStudentRepository,
 	bot *telegram.Bot,
+	emailService EmailService,
+	retryPolicy RetryPolicy,
+	reminderOffsets []time.Duration,
+	publisher realtime.Publisher,
+	telegramLinkRepo repository.TelegramMessageLinkRepository,
 ) NotificationService {
+	offsets := append([]time.Duration(nil), reminderOffsets...)
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
 	return &notificationService{
 		notificationRepo:     notificationRepo,
+		preferenceRepo:       preferenceRepo,
 		assignmentTargetRepo: assignmentTargetRepo,
 		assignmentRepo:       assignmentRepo,
 		userRepo:             userRepo,
+		parentRepo:           parentRepo,
+		teacherStudentRepo:   teacherStudentRepo,
 		bot:                  bot,
+		emailService:         emailService,
+		retryPolicy:          retryPolicy,
+		reminderOffsets:      offsets,
+		realtime:             publisher,
+		telegramLinkRepo:     telegramLinkRepo,
 	}
 }
 
+// CreateNotification создает уведомление во всех каналах, которые пользователь включил для этого типа.
+// В notification после вызова остается первая созданная запись.
 func (s *notificationService) CreateNotification(notification *models.Notification) error {
-	if notification.ID == uuid.Nil {
-		notification.ID = uuid.New()
+	user, err := s.userRepo.GetByID(notification.UserID)
+	if err != nil {
+		return err
+	}
+
+	channels, err := s.enabledChannels(user.ID, notification.Type)
+	if err != nil {
+		return err
+	}
+	if user.BotUnreachable {
+		channels = replaceBotWithInApp(channels)
+	}
+
+	settings, err := s.preferenceRepo.GetSettings(user.ID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	quietUntil := quietHoursEnd(settings, user.Location(), now)
+
+	var first *models.Notification
+	for _, channel := range channels {
+		// Письма отправляем только на подтвержденный адрес
+		if channel == models.NotificationChannelEmail &&
+			(s.emailService == nil || !s.emailService.Enabled() || !user.EmailVerified) {
+			continue
+		}
+
+		n := *notification
+		n.ID = uuid.New()
+		n.Channel = channel
+		n.Status = models.NotificationStatusPending
+		n.NextAttemptAt = nil
+		n.CreatedAt = now
+		n.UpdatedAt = now
+		if channel.IsPush() && quietUntil != nil {
+			n.NextAttemptAt = quietUntil
+		}
+
+		if err := s.notificationRepo.Create(&n); err != nil {
+			return err
+		}
+		if n.Channel == models.NotificationChannelInApp {
+			s.realtime.Publish([]uuid.UUID{n.UserID}, realtime.EventNotificationCreated, &n)
+		}
+		if first == nil {
+			first = &n
+		}
 	}
-	notification.CreatedAt = time.Now()
-	notification.UpdatedAt = time.Now()
 
-	return s.notificationRepo.Create(notification)
+	if first != nil {
+		*notification = *first
+	}
+	return nil
 }
 
 func (s *notificationService) GetNotification(id uuid.UUID) (*models.Notification, error) {
@@ -79,8 +203,9 @@ func (s *notificationService) DeleteNotification(id uuid.UUID) error {
 	return s.notificationRepo.Delete(id)
 }
 
+// ListNotificationsByUser возвращает ленту уведомлений в приложении
 func (s *notificationService) ListNotificationsByUser(userID uuid.UUID) ([]*models.Notification, error) {
-	return s.notificationRepo.ListByUser(userID)
+	return s.notificationRepo.ListByUserAndChannel(userID, models.NotificationChannelInApp)
 }
 
 func (s *notificationService) MarkAsRead(notificationID uuid.UUID) error {
@@ -92,19 +217,63 @@ func (s *notificationService) MarkAsSent(notificationID uuid.UUID) error {
 }
 
 func (s *notificationService) CreateForGroup(userIDs []uuid.UUID, notificationType models.NotificationType, title, message, payload string) error {
-	return s.notificationRepo.CreateForGroup(userIDs, notificationType, title, message, payload)
+	for _, userID := range userIDs {
+		if err := s.CreateNotification(&models.Notification{
+			UserID:  userID,
+			Type:    notificationType,
+			Title:   title,
+			Message: message,
+			Payload: payload,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NotifyParents дублирует уведомление об ученике всем его родителям.
+// payload определяет событие: родитель, уже получивший уведомление с таким payload, пропускается.
+func (s *notificationService) NotifyParents(studentID uuid.UUID, notificationType models.NotificationType, title, message, payload string) error {
+	parentIDs, err := s.parentRepo.ListParentIDs(studentID)
+	if err != nil {
+		return err
+	}
+	for _, parentID := range parentIDs {
+		exists, err := s.notificationRepo.ExistsByPayload(parentID, notificationType, payload)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if err := s.CreateNotification(&models.Notification{
+			UserID:  parentID,
+			Type:    notificationType,
+			Title:   title,
+			Message: message,
+			Payload: payload,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (s *notificationService) SendPendingNotifications() error {
-	// Получаем все ожидающие уведомления
-	notifications, err := s.notificationRepo.ListByStatus(models.NotificationStatusPending)
+	// Получаем ожидающие уведомления, время отправки которых уже наступило
+	notifications, err := s.notificationRepo.ListDue(time.Now())
 	if err != nil {
 		return err
 	}
 
 	for _, notification := range notifications {
 		if err := s.sendNotification(notification); err != nil {
+			if telegram.IsUnreachable(err) {
+				s.fallbackToInApp(notification, err)
+				continue
+			}
 			log.Printf("Failed to send notification %s: %v", notification.ID, err)
+			s.recordDeliveryFailure(notification, err)
 			continue
 		}
 
@@ -115,10 +284,78 @@ func (s *notificationService) SendPendingNotifications() error {
 	return nil
 }
 
+// ListUndeliveredNotifications возвращает уведомления учеников преподавателя в статусе failed или bounced
+func (s *notificationService) ListUndeliveredNotifications(teacherID uuid.UUID, status models.NotificationStatus) ([]*models.Notification, error) {
+	if status != models.NotificationStatusFailed && status != models.NotificationStatusBounced {
+		return nil, fmt.Errorf("unsupported status: %s", status)
+	}
+	studentIDs, err := s.teacherStudentRepo.ListStudentIDs(teacherID)
+	if err != nil {
+		return nil, err
+	}
+	return s.notificationRepo.ListByStatus(status, studentIDs)
+}
+
+// RequeueNotification возвращает недоставленное уведомление ученика преподавателя в очередь отправки
+func (s *notificationService) RequeueNotification(teacherID, id uuid.UUID) error {
+	notification, err := s.notificationRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	linked, err := s.teacherStudentRepo.IsLinked(teacherID, notification.UserID)
+	if err != nil {
+		return err
+	}
+	if !linked {
+		return ErrAccessDenied
+	}
+	if notification.Status != models.NotificationStatusFailed && notification.Status != models.NotificationStatusBounced {
+		return errors.New("only failed or bounced notifications can be requeued")
+	}
+	return s.notificationRepo.Requeue(id)
+}
+
 func (s *notificationService) ScheduleDeadlineReminders() error {
-	// Получаем все активные задания (пока используем простую реализацию)
-	// В реальной системе здесь был бы метод GetActiveAssignments()
-	// Пока пропускаем эту функциональность
+	if len(s.reminderOffsets) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	maxOffset := s.reminderOffsets[len(s.reminderOffsets)-1]
+
+	// Несданные задания, у которых дедлайн попадает в окно самого раннего напоминания
+	targets, err := s.assignmentTargetRepo.ListPendingDueBetween(now, now.Add(maxOffset))
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets {
+		dueDate := target.Assignment.DueDate
+		timeLeft := dueDate.Sub(now)
+
+		// Берём ближайший наступивший порог: если задача запустилась поздно,
+		// более ранние напоминания уже неактуальны и не отправляются
+		var offset time.Duration
+		for _, o := range s.reminderOffsets {
+			if timeLeft <= o {
+				offset = o
+				break
+			}
+		}
+		if offset == 0 {
+			continue
+		}
+
+		// Задание выдано уже после порога — ученик и так получил уведомление о новом задании
+		if target.CreatedAt.After(dueDate.Add(-offset)) {
+			continue
+		}
+
+		if err := s.createDeadlineReminder(target, offset); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -130,18 +367,32 @@ func (s *notificationService) ScheduleOverdueNotifications() error {
 	}
 
 	for _, target := range overdueTargets {
-		// Создаем уведомление о просрочке
-		s.notificationRepo.Create(&models.Notification{
-			UserID:    target.StudentID,
-			Type:      models.NotificationTypeOverdue,
-			Title:     "Задание просрочено",
-			Message:   "Ваше задание просрочено: " + target.Assignment.Title,
-			Payload:   `{"assignment_target_id":"` + target.ID.String() + `"}`,
-			Channel:   models.NotificationChannelBot,
-			Status:    models.NotificationStatusPending,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		})
+		payload := `{"assignment_target_id":"` + target.ID.String() + `"}`
+
+		// Задача запускается периодически — уведомляем о каждой просрочке только один раз
+		exists, err := s.notificationRepo.ExistsByPayload(target.StudentID, models.NotificationTypeOverdue, payload)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if err := s.CreateNotification(&models.Notification{
+				UserID:  target.StudentID,
+				Type:    models.NotificationTypeOverdue,
+				Title:   "Задание просрочено",
+				Message: "Ваше задание просрочено: " + target.Assignment.Title,
+				Payload: payload,
+			}); err != nil {
+				return err
+			}
+		}
+
+		// Родители дедуплицируются отдельно: ученик мог отключить уведомления, и его записи нет
+		if err := s.NotifyParents(target.StudentID, models.NotificationTypeOverdue,
+			"Просрочено задание",
+			fmt.Sprintf("%s не сдал(а) задание в срок: %s", studentDisplayName(&target.Student), target.Assignment.Title),
+			payload); err != nil {
+			log.Printf("Failed to notify parents about overdue assignment %s: %v", target.ID, err)
+		}
 	}
 
 	return nil
@@ -153,6 +404,272 @@ func (s *notificationService) CleanupOldNotifications() error {
 	return s.notificationRepo.CleanupOld(cutoffDate)
 }
 
+func (s *notificationService) GetPreferences(userID uuid.UUID) (*NotificationPreferences, error) {
+	stored, err := s.preferenceRepo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.preferenceRepo.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs := &NotificationPreferences{
+		Channels: make(map[models.NotificationType]map[models.NotificationChannel]bool),
+		QuietHours: &QuietHours{
+			Enabled: settings.QuietHoursEnabled,
+			Start:   settings.QuietHoursStart,
+			End:     settings.QuietHoursEnd,
+		},
+	}
+	for _, t := range models.NotificationTypes {
+		prefs.Channels[t] = make(map[models.NotificationChannel]bool)
+		for _, c := range models.NotificationChannels {
+			prefs.Channels[t][c] = models.DefaultChannelEnabled(c)
+		}
+	}
+	for _, p := range stored {
+		if _, ok := prefs.Channels[p.Type]; ok {
+			prefs.Channels[p.Type][p.Channel] = p.Enabled
+		}
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences сохраняет переданные настройки; не указанные типы и каналы не меняются
+func (s *notificationService) UpdatePreferences(userID uuid.UUID, update *NotificationPreferences) (*NotificationPreferences, error) {
+	for t, channels := range update.Channels {
+		if !isKnownNotificationType(t) {
+			return nil, fmt.Errorf("unknown notification type: %s", t)
+		}
+		for c, enabled := range channels {
+			if !isKnownNotificationChannel(c) {
+				return nil, fmt.Errorf("unknown notification channel: %s", c)
+			}
+			if err := s.preferenceRepo.Upsert(&models.NotificationPreference{
+				UserID:  userID,
+				Type:    t,
+				Channel: c,
+				Enabled: enabled,
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if update.QuietHours != nil {
+		settings, err := s.preferenceRepo.GetSettings(userID)
+		if err != nil {
+			return nil, err
+		}
+		settings.QuietHoursEnabled = update.QuietHours.Enabled
+		if update.QuietHours.Start != "" {
+			settings.QuietHoursStart = update.QuietHours.Start
+		}
+		if update.QuietHours.End != "" {
+			settings.QuietHoursEnd = update.QuietHours.End
+		}
+		if _, err := parseClock(settings.QuietHoursStart); err != nil {
+			return nil, err
+		}
+		if _, err := parseClock(settings.QuietHoursEnd); err != nil {
+			return nil, err
+		}
+		settings.UpdatedAt = time.Now()
+		if settings.CreatedAt.IsZero() {
+			settings.CreatedAt = settings.UpdatedAt
+		}
+		if err := s.preferenceRepo.SaveSettings(settings); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetPreferences(userID)
+}
+
+// enabledChannels возвращает каналы, включенные пользователем для типа уведомлений
+func (s *notificationService) enabledChannels(userID uuid.UUID, notificationType models.NotificationType) ([]models.NotificationChannel, error) {
+	prefs, err := s.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byChannel, ok := prefs.Channels[notificationType]
+	var channels []models.NotificationChannel
+	for _, c := range models.NotificationChannels {
+		enabled := models.DefaultChannelEnabled(c)
+		if ok {
+			enabled = byChannel[c]
+		}
+		if enabled {
+			channels = append(channels, c)
+		}
+	}
+	return channels, nil
+}
+
+// quietHoursEnd возвращает момент окончания тихих часов, если сейчас они идут, иначе nil
+func quietHoursEnd(settings *models.NotificationSettings, loc *time.Location, now time.Time) *time.Time {
+	if !settings.QuietHoursEnabled {
+		return nil
+	}
+	start, err := parseClock(settings.QuietHoursStart)
+	if err != nil {
+		return nil
+	}
+	end, err := parseClock(settings.QuietHoursEnd)
+	if err != nil || start == end {
+		return nil
+	}
+
+	local := now.In(loc)
+	minutes := local.Hour()*60 + local.Minute()
+
+	var inQuiet bool
+	if start < end {
+		inQuiet = minutes >= start && minutes < end
+	} else {
+		// Интервал через полночь, например 22:00–08:00
+		inQuiet = minutes >= start || minutes < end
+	}
+	if !inQuiet {
+		return nil
+	}
+
+	until := time.Date(local.Year(), local.Month(), local.Day(), end/60, end%60, 0, 0, loc)
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return &until
+}
+
+// parseClock разбирает время "HH:MM" в минуты от начала суток
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, errors.New("time must be in HH:MM format")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// replaceBotWithInApp заменяет бот на уведомление в приложении, не дублируя его
+func replaceBotWithInApp(channels []models.NotificationChannel) []models.NotificationChannel {
+	result := make([]models.NotificationChannel, 0, len(channels))
+	hasInApp := false
+	for _, channel := range channels {
+		if channel == models.NotificationChannelInApp {
+			hasInApp = true
+		}
+	}
+	for _, channel := range channels {
+		if channel == models.NotificationChannelBot {
+			if hasInApp {
+				continue
+			}
+			channel = models.NotificationChannelInApp
+			hasInApp = true
+		}
+		result = append(result, channel)
+	}
+	return result
+}
+
+func isKnownNotificationType(t models.NotificationType) bool {
+	for _, known := range models.NotificationTypes {
+		if known == t {
+			return true
+		}
+	}
+	return false
+}
+
+func isKnownNotificationChannel(c models.NotificationChannel) bool {
+	for _, known := range models.NotificationChannels {
+		if known == c {
+			return true
+		}
+	}
+	return false
+}
+
+// recordDeliveryFailure планирует повтор с экспоненциальной задержкой.
+// После MaxAttempts попыток уведомление переходит в failed, отклоненные письма — сразу в bounced.
+func (s *notificationService) recordDeliveryFailure(notification *models.Notification, deliveryErr error) {
+	if !email.IsPermanent(deliveryErr) {
+		attempts := notification.Attempts + 1
+		var err error
+		if attempts >= s.retryPolicy.MaxAttempts {
+			log.Printf("Notification %s failed after %d attempts", notification.ID, attempts)
+			err = s.notificationRepo.MarkAsFailed(notification.ID, attempts, deliveryErr.Error())
+		} else {
+			next := time.Now().Add(s.retryPolicy.Backoff(attempts))
+			err = s.notificationRepo.ScheduleRetry(notification.ID, attempts, next, deliveryErr.Error())
+		}
+		if err != nil {
+			log.Printf("Failed to record notification error %s: %v", notification.ID, err)
+		}
+		return
+	}
+
+	if err := s.notificationRepo.MarkAsBounced(notification.ID, deliveryErr.Error()); err != nil {
+		log.Printf("Failed to mark notification %s as bounced: %v", notification.ID, err)
+	}
+
+	// Адрес не принимает письма — больше не используем его до повторного подтверждения
+	if notification.Channel == models.NotificationChannelEmail {
+		user, err := s.userRepo.GetByID(notification.UserID)
+		if err == nil && user.EmailVerified {
+			user.EmailVerified = false
+			if err := s.userRepo.Update(user); err != nil {
+				log.Printf("Failed to reset email verification for %s: %v", user.ID, err)
+			}
+		}
+	}
+}
+
+// fallbackToInApp отмечает пользователя недоступным через бота и переносит уведомление в приложение
+func (s *notificationService) fallbackToInApp(notification *models.Notification, deliveryErr error) {
+	log.Printf("User %s is unreachable via bot: %v", notification.UserID, deliveryErr)
+
+	reason := string(telegram.ErrorKindOther)
+	if apiErr, ok := telegram.AsAPIError(deliveryErr); ok {
+		reason = string(apiErr.Kind)
+	}
+	if err := s.userRepo.SetBotUnreachable(notification.UserID, reason); err != nil {
+		log.Printf("Failed to mark user %s as unreachable: %v", notification.UserID, err)
+	}
+
+	// Уведомление уже показано в приложении, если этот канал включен
+	channels, err := s.enabledChannels(notification.UserID, notification.Type)
+	if err == nil && containsChannel(channels, models.NotificationChannelInApp) {
+		if err := s.notificationRepo.Delete(notification.ID); err != nil {
+			log.Printf("Failed to delete notification %s: %v", notification.ID, err)
+		}
+		return
+	}
+
+	now := time.Now()
+	notification.Channel = models.NotificationChannelInApp
+	notification.Status = models.NotificationStatusSent
+	notification.NextAttemptAt = nil
+	notification.SentAt = &now
+	notification.LastError = deliveryErr.Error()
+	if err := s.notificationRepo.Update(notification); err != nil {
+		log.Printf("Failed to move notification %s to in-app: %v", notification.ID, err)
+		return
+	}
+	s.realtime.Publish([]uuid.UUID{notification.UserID}, realtime.EventNotificationCreated, notification)
+}
+
+func containsChannel(channels []models.NotificationChannel, channel models.NotificationChannel) bool {
+	for _, c := range channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
 // Helper method to send notification via appropriate channel
 func (s *notificationService) sendNotification(notification *models.Notification) error {
 	switch notification.Channel {
@@ -160,7 +677,10 @@ func (s *notificationService) sendNotification(notification *models.Notification
 		if s.bot != nil {
 			user, err := s.userRepo.GetByID(notification.UserID)
 			if err == nil && user.TelegramID != 0 {
-				message := notification.Title + "\n\n" + notification.Message
+				message := "<b>" + html.EscapeString(notification.Title) + "</b>\n\n" + html.EscapeString(notification.Message)
+				if notification.Type == models.NotificationTypeNewMessage {
+					return s.sendChatMessagePreview(user, notification, message)
+				}
 				return s.bot.SendMessage(user.TelegramID, message)
 			}
 		}
@@ -168,43 +688,80 @@ func (s *notificationService) sendNotification(notification *models.Notification
 		// In-app уведомления обрабатываются на фронтенде
 		return nil
 	case models.NotificationChannelEmail:
-		// Email уведомления - пока не реализованы
-		return nil
+		if s.emailService == nil {
+			return ErrEmailDisabled
+		}
+		user, err := s.userRepo.GetByID(notification.UserID)
+		if err != nil {
+			return err
+		}
+		return s.emailService.SendNotification(user, notification)
 	}
 
 	return nil
 }
 
-// Helper method to create deadline reminder
-func (s *notificationService) createDeadlineReminder(assignment *models.Assignment, timeRemaining string) {
-	// Получаем студентов, которым назначено задание
-	var studentIDs []uuid.UUID
+// sendChatMessagePreview отправляет превью сообщения чата и запоминает его,
+// чтобы ответ на превью в Telegram попал в тот же чат
+func (s *notificationService) sendChatMessagePreview(user *models.User, notification *models.Notification, message string) error {
+	var payload struct {
+		ThreadID  uuid.UUID  `json:"thread_id"`
+		MessageID *uuid.UUID `json:"message_id"`
+	}
+	if err := json.Unmarshal([]byte(notification.Payload), &payload); err != nil || payload.ThreadID == uuid.Nil {
+		return s.bot.SendMessage(user.TelegramID, message)
+	}
 
-	if assignment.GroupID != nil {
-		// Групповое задание - получаем участников группы
-		members, err := s.assignmentTargetRepo.ListByAssignment(assignment.ID)
-		if err == nil {
-			for _, target := range members {
-				studentIDs = append(studentIDs, target.StudentID)
-			}
-		}
-	} else if assignment.StudentID != nil {
-		// Индивидуальное задание
-		studentIDs = append(studentIDs, *assignment.StudentID)
-	}
-
-	// Создаем уведомления для всех студентов
-	for _, studentID := range studentIDs {
-		s.notificationRepo.Create(&models.Notification{
-			UserID:    studentID,
-			Type:      models.NotificationTypeDeadlineReminder,
-			Title:     "Напоминание о дедлайне",
-			Message:   "До сдачи задания осталось " + timeRemaining + ": " + assignment.Title,
-			Payload:   `{"assignment_id":"` + assignment.ID.String() + `"}`,
-			Channel:   models.NotificationChannelBot,
-			Status:    models.NotificationStatusPending,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		})
+	telegramMessageID, err := s.bot.SendMessageWithID(user.TelegramID, message+"\n\n<i>Ответьте на это сообщение, чтобы написать в чат.</i>")
+	if err != nil {
+		return err
+	}
+	if err := s.telegramLinkRepo.Create(&models.TelegramMessageLink{
+		ChatID:            user.TelegramID,
+		TelegramMessageID: telegramMessageID,
+		UserID:            user.ID,
+		ThreadID:          payload.ThreadID,
+		MessageID:         payload.MessageID,
+	}); err != nil {
+		log.Printf("Failed to save telegram message link for notification %s: %v", notification.ID, err)
+	}
+	return nil
+}
+
+// Helper method to create deadline reminder (once per target and offset)
+func (s *notificationService) createDeadlineReminder(target *models.AssignmentTarget, offset time.Duration) error {
+	payload := fmt.Sprintf(`{"assignment_target_id":"%s","offset":"%s"}`, target.ID, offset)
+
+	exists, err := s.notificationRepo.ExistsByPayload(target.StudentID, models.NotificationTypeDeadlineReminder, payload)
+	if err != nil || exists {
+		return err
+	}
+
+	message := fmt.Sprintf("До сдачи задания осталось %s: %s\nДедлайн: %s",
+		formatReminderOffset(offset),
+		target.Assignment.Title,
+		target.Student.FormatTime(target.Assignment.DueDate),
+	)
+
+	return s.CreateNotification(&models.Notification{
+		UserID:  target.StudentID,
+		Type:    models.NotificationTypeDeadlineReminder,
+		Title:   "Напоминание о дедлайне",
+		Message: message,
+		Payload: payload,
+	})
+}
+
+// formatReminderOffset возвращает "24 ч", "1 ч 30 мин" или "45 мин"
+func formatReminderOffset(offset time.Duration) string {
+	hours := int(offset / time.Hour)
+	minutes := int((offset % time.Hour) / time.Minute)
+	switch {
+	case hours > 0 && minutes > 0:
+		return fmt.Sprintf("%d ч %d мин", hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%d ч", hours)
+	default:
+		return fmt.Sprintf("%d мин", minutes)
 	}
 }
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"edubot/internal/models"
+	"edubot/internal/repository"
+	"edubot/pkg/database"
+)
+
+// nopPublisher — realtime-события в тестах никуда не отправляются
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(userIDs []uuid.UUID, eventType string, data interface{})          {}
+func (nopPublisher) PublishEphemeral(userIDs []uuid.UUID, eventType string, data interface{}) {}
+
+// notificationFixture — сервис уведомлений на временной SQLite без бота и почты
+type notificationFixture struct {
+	service            NotificationService
+	notificationRepo   repository.NotificationRepository
+	userRepo           repository.UserRepository
+	teacherStudentRepo repository.TeacherStudentRepository
+	parentRepo         repository.ParentRepository
+	assignmentRepo     repository.AssignmentRepository
+	targetRepo         repository.AssignmentTargetRepository
+	nextTelegramID     int64
+}
+
+func newNotificationFixture(t *testing.T) *notificationFixture {
+	t.Helper()
+	t.Setenv("DATABASE_URL", "")
+	db, err := database.NewDatabase(filepath.Join(t.TempDir(), "edubot.db"))
+	if err != nil {
+		t.Fatalf("database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	f := &notificationFixture{
+		notificationRepo:   repository.NewNotificationRepository(db.DB),
+		userRepo:           repository.NewUserRepository(db.DB),
+		teacherStudentRepo: repository.NewTeacherStudentRepository(db.DB),
+		parentRepo:         repository.NewParentRepository(db.DB),
+		assignmentRepo:     repository.NewAssignmentRepository(db.DB),
+		targetRepo:         repository.NewAssignmentTargetRepository(db.DB),
+		nextTelegramID:     1000,
+	}
+	f.service = NewNotificationService(
+		f.notificationRepo,
+		repository.NewNotificationPreferenceRepository(db.DB),
+		f.targetRepo,
+		f.assignmentRepo,
+		f.userRepo,
+		f.parentRepo,
+		f.teacherStudentRepo,
+		nil,
+		nil,
+		RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour},
+		nil,
+		nopPublisher{},
+		nil,
+	)
+	return f
+}
+
+func (f *notificationFixture) user(t *testing.T, role models.UserRole) *models.User {
+	t.Helper()
+	f.nextTelegramID++
+	user := &models.User{TelegramID: f.nextTelegramID, Role: role}
+	if err := f.userRepo.Create(user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+func (f *notificationFixture) student(t *testing.T, teacher *models.User) *models.User {
+	t.Helper()
+	student := f.user(t, models.RoleStudent)
+	if err := f.teacherStudentRepo.Link(teacher.ID, student.ID, ""); err != nil {
+		t.Fatalf("link student: %v", err)
+	}
+	return student
+}
+
+func (f *notificationFixture) failed(t *testing.T, userID uuid.UUID) *models.Notification {
+	t.Helper()
+	notification := &models.Notification{
+		UserID:  userID,
+		Type:    models.NotificationTypeNewMessage,
+		Channel: models.NotificationChannelBot,
+		Status:  models.NotificationStatusFailed,
+		Title:   "Новое сообщение",
+	}
+	if err := f.notificationRepo.Create(notification); err != nil {
+		t.Fatalf("create notification: %v", err)
+	}
+	return notification
+}
+
+func TestQuietHoursEnd(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	at := func(value string) time.Time {
+		parsed, err := time.ParseInLocation("2006-01-02 15:04", value, moscow)
+		if err != nil {
+			t.Fatalf("bad time %q: %v", value, err)
+		}
+		return parsed
+	}
+	settings := func(enabled bool, start, end string) *models.NotificationSettings {
+		return &models.NotificationSettings{QuietHoursEnabled: enabled, QuietHoursStart: start, QuietHoursEnd: end}
+	}
+
+	tests := []struct {
+		name     string
+		settings *models.NotificationSettings
+		now      time.Time
+		want     string // "" — тихие часы не действуют
+	}{
+		{"disabled", settings(false, "22:00", "08:00"), at("2024-03-10 23:00"), ""},
+		{"invalid start", settings(true, "25:00", "08:00"), at("2024-03-10 23:00"), ""},
+		{"empty interval", settings(true, "08:00", "08:00"), at("2024-03-10 08:00"), ""},
+		{"overnight before midnight", settings(true, "22:00", "08:00"), at("2024-03-10 23:00"), "2024-03-11 08:00"},
+		{"overnight after midnight", settings(true, "22:00", "08:00"), at("2024-03-11 02:30"), "2024-03-11 08:00"},
+		{"overnight start is quiet", settings(true, "22:00", "08:00"), at("2024-03-10 22:00"), "2024-03-11 08:00"},
+		{"overnight end is not quiet", settings(true, "22:00", "08:00"), at("2024-03-11 08:00"), ""},
+		{"overnight daytime", settings(true, "22:00", "08:00"), at("2024-03-10 12:00"), ""},
+		{"same day inside", settings(true, "13:00", "15:30"), at("2024-03-10 14:00"), "2024-03-10 15:30"},
+		{"same day outside", settings(true, "13:00", "15:30"), at("2024-03-10 16:00"), ""},
+		// Время сравнивается в часовом поясе пользователя: 20:00 UTC — 23:00 MSK
+		{"converts to user zone", settings(true, "22:00", "08:00"), time.Date(2024, 3, 10, 20, 0, 0, 0, time.UTC), "2024-03-11 08:00"},
+	}
+	for _, tt := range tests {
+		got := quietHoursEnd(tt.settings, moscow, tt.now)
+		switch {
+		case tt.want == "" && got != nil:
+			t.Errorf("%s: expected no quiet hours, got %s", tt.name, got)
+		case tt.want != "" && got == nil:
+			t.Errorf("%s: expected quiet hours until %s, got none", tt.name, tt.want)
+		case tt.want != "" && !got.Equal(at(tt.want)):
+			t.Errorf("%s: got %s, want %s", tt.name, got.In(moscow).Format("2006-01-02 15:04"), tt.want)
+		}
+	}
+}
+
+func TestUndeliveredNotificationsScopedToTeacher(t *testing.T) {
+	f := newNotificationFixture(t)
+	teacher := f.user(t, models.RoleTeacher)
+	other := f.user(t, models.RoleTeacher)
+	own := f.failed(t, f.student(t, teacher).ID)
+	foreign := f.failed(t, f.student(t, other).ID)
+
+	notifications, err := f.service.ListUndeliveredNotifications(teacher.ID, models.NotificationStatusFailed)
+	if err != nil {
+		t.Fatalf("ListUndeliveredNotifications: %v", err)
+	}
+	if len(notifications) != 1 || notifications[0].ID != own.ID {
+		t.Errorf("expected only the own student's notification, got %+v", notifications)
+	}
+
+	// Уведомление ученика другого преподавателя не переотправляется и остается failed
+	if err := f.service.RequeueNotification(teacher.ID, foreign.ID); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("requeue foreign: expected ErrAccessDenied, got %v", err)
+	}
+	if stored, _ := f.notificationRepo.GetByID(foreign.ID); stored.Status != models.NotificationStatusFailed {
+		t.Errorf("foreign notification status = %s, want failed", stored.Status)
+	}
+
+	if err := f.service.RequeueNotification(teacher.ID, own.ID); err != nil {
+		t.Fatalf("requeue own: %v", err)
+	}
+	if stored, _ := f.notificationRepo.GetByID(own.ID); stored.Status != models.NotificationStatusPending {
+		t.Errorf("own notification status = %s, want pending", stored.Status)
+	}
+}
+
+func TestOverdueNotifiesParentsOfMutedStudentOnce(t *testing.T) {
+	f := newNotificationFixture(t)
+	teacher := f.user(t, models.RoleTeacher)
+	student := f.student(t, teacher)
+	parent := f.user(t, models.RoleParent)
+	if err := f.parentRepo.Link(parent.ID, student.ID); err != nil {
+		t.Fatalf("link parent: %v", err)
+	}
+
+	// Ученик отключил уведомления о просрочке во всех каналах
+	muted := map[models.NotificationChannel]bool{}
+	for _, channel := range models.NotificationChannels {
+		muted[channel] = false
+	}
+	if _, err := f.service.UpdatePreferences(student.ID, &NotificationPreferences{
+		Channels: map[models.NotificationType]map[models.NotificationChannel]bool{models.NotificationTypeOverdue: muted},
+	}); err != nil {
+		t.Fatalf("UpdatePreferences: %v", err)
+	}
+
+	assignment := &models.Assignment{ID: uuid.New(), Title: "Кинематика", Subject: "physics", Grade: 10, Level: 1, TeacherID: teacher.ID, DueDate: time.Now().Add(-time.Hour)}
+	if err := f.assignmentRepo.Create(assignment); err != nil {
+		t.Fatalf("create assignment: %v", err)
+	}
+	if err := f.targetRepo.Create(&models.AssignmentTarget{AssignmentID: assignment.ID, StudentID: student.ID, Status: models.AssignmentTargetStatusOverdue}); err != nil {
+		t.Fatalf("create target: %v", err)
+	}
+
+	var parentCount int
+	for run := 1; run <= 3; run++ {
+		if err := f.service.ScheduleOverdueNotifications(); err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+		studentNotifications, _ := f.notificationRepo.ListByUser(student.ID)
+		if len(studentNotifications) != 0 {
+			t.Errorf("run %d: muted student got %d notifications", run, len(studentNotifications))
+		}
+		parentNotifications, _ := f.notificationRepo.ListByUser(parent.ID)
+		if run == 1 {
+			parentCount = len(parentNotifications)
+			if parentCount == 0 {
+				t.Fatal("parent was not notified")
+			}
+		} else if len(parentNotifications) != parentCount {
+			t.Errorf("run %d: parent has %d notifications, want %d", run, len(parentNotifications), parentCount)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"edubot/internal/models"
+	"edubot/internal/repository"
+)
+
+// parentInviteTTL — срок действия кода приглашения родителя
+const parentInviteTTL = 7 * 24 * time.Hour
+
+// ParentInvitePrefix отличает коды родителей от кодов учеников (например, в ссылке на бота)
+const ParentInvitePrefix = "P-"
+
+var (
+	// ErrInvalidParentInvite — код не найден, истек или уже использован
+	ErrInvalidParentInvite = errors.New("invalid or expired parent invite code")
+	// ErrParentRoleConflict — ученик или преподаватель не может стать родителем
+	ErrParentRoleConflict = errors.New("only guests and parents can accept a parent invite")
+	// ErrChildNotLinked — ученик не является ребенком этого родителя
+	ErrChildNotLinked = errors.New("student is not linked to this parent")
+)
+
+type ParentService interface {
+	// Коды приглашения
+	CreateInvite(teacherID, studentID uuid.UUID) (*models.ParentInvite, error)
+	AcceptInvite(user *models.User, code string) (*models.User, error)
+
+	// Просмотр успеваемости (только чтение)
+	ListChildren(parentID uuid.UUID) ([]models.User, error)
+	GetChildAssignments(parentID, studentID uuid.UUID) ([]*models.AssignmentTarget, error)
+	GetChildProgress(parentID, studentID uuid.UUID) (*ChildProgress, error)
+}
+
+// ChildProgress — сводка успеваемости ребенка для родителя
+type ChildProgress struct {
+	Total        int     `json:"total"`
+	Pending      int     `json:"pending"`
+	Submitted    int     `json:"submitted"`
+	Graded       int     `json:"graded"`
+	Overdue      int     `json:"overdue"`
+	AverageScore float64 `json:"average_score"`
+}
+
+type parentService struct {
+	parentRepo           repository.ParentRepository
+	teacherStudentRepo   repository.TeacherStudentRepository
+	assignmentTargetRepo repository.AssignmentTargetRepository
+	userRepo             repository.UserRepository
+	roleChangeRepo       repository.RoleChangeRepository
+}
+
+func NewParentService(
+	parentRepo repository.ParentRepository,
+	teacherStudentRepo repository.TeacherStudentRepository,
+	assignmentTargetRepo repository.AssignmentTargetRepository,
+	userRepo repository.UserRepository,
+	roleChangeRepo repository.RoleChangeRepository,
+) ParentService {
+	return &parentService{
+		parentRepo:           parentRepo,
+		teacherStudentRepo:   teacherStudentRepo,
+		assignmentTargetRepo: assignmentTargetRepo,
+		userRepo:             userRepo,
+		roleChangeRepo:       roleChangeRepo,
+	}
+}
+
+// CreateInvite выдает код приглашения для родителя ученика преподавателя
+func (s *parentService) CreateInvite(teacherID, studentID uuid.UUID) (*models.ParentInvite, error) {
+	linked, err := s.teacherStudentRepo.IsLinked(teacherID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if !linked {
+		return nil, ErrStudentNotLinked
+	}
+
+	code, err := generateInviteCode(ParentInvitePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invite code: %w", err)
+	}
+
+	now := time.Now()
+	invite := &models.ParentInvite{
+		ID:        uuid.New(),
+		Code:      code,
+		StudentID: studentID,
+		CreatedBy: teacherID,
+		ExpiresAt: now.Add(parentInviteTTL),
+		CreatedAt: now,
+	}
+	if err := s.parentRepo.CreateInvite(invite); err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+// AcceptInvite связывает пользователя с ребенком по коду и делает его родителем
+func (s *parentService) AcceptInvite(user *models.User, code string) (*models.User, error) {
+	if user.Role != models.RoleGuest && user.Role != models.RoleParent {
+		return nil, ErrParentRoleConflict
+	}
+
+	invite, err := s.parentRepo.GetInviteByCode(code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidParentInvite
+		}
+		return nil, err
+	}
+	if !invite.Usable(time.Now()) {
+		return nil, ErrInvalidParentInvite
+	}
+
+	used, err := s.parentRepo.UseInvite(invite.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, ErrInvalidParentInvite
+	}
+
+	if err := s.parentRepo.Link(user.ID, invite.StudentID); err != nil {
+		return nil, fmt.Errorf("failed to link child: %w", err)
+	}
+	if user.Role != models.RoleParent {
+		oldRole := user.Role
+		user.Role = models.RoleParent
+		if err := s.userRepo.Update(user); err != nil {
+			return nil, fmt.Errorf("failed to update user: %w", err)
+		}
+		recordRoleChange(s.roleChangeRepo, user.ID, oldRole, models.RoleParent, &invite.CreatedBy, RoleChangeParent)
+	}
+	return &invite.Student, nil
+}
+
+func (s *parentService) ListChildren(parentID uuid.UUID) ([]models.User, error) {
+	return s.parentRepo.ListChildren(parentID)
+}
+
+// GetChildAssignments возвращает задания ребенка со статусами, оценками и отзывами преподавателя
+func (s *parentService) GetChildAssignments(parentID, studentID uuid.UUID) ([]*models.AssignmentTarget, error) {
+	if err := s.checkChild(parentID, studentID); err != nil {
+		return nil, err
+	}
+	return s.assignmentTargetRepo.ListByStudentWithFeedback(studentID)
+}
+
+// GetChildProgress считает сводку по заданиям ребенка
+func (s *parentService) GetChildProgress(parentID, studentID uuid.UUID) (*ChildProgress, error) {
+	if err := s.checkChild(parentID, studentID); err != nil {
+		return nil, err
+	}
+	targets, err := s.assignmentTargetRepo.ListByStudent(studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &ChildProgress{Total: len(targets)}
+	var totalScore float64
+	var scoreCount int
+	for _, target := range targets {
+		switch target.Status {
+		case models.AssignmentTargetStatusPending:
+			progress.Pending++
+		case models.AssignmentTargetStatusSubmitted:
+			progress.Submitted++
+		case models.AssignmentTargetStatusGraded:
+			progress.Graded++
+			if target.Score != nil {
+				totalScore += *target.Score
+				scoreCount++
+			}
+		case models.AssignmentTargetStatusOverdue:
+			progress.Overdue++
+		}
+	}
+	if scoreCount > 0 {
+		progress.AverageScore = totalScore / float64(scoreCount)
+	}
+	return progress, nil
+}
+
+func (s *parentService) checkChild(parentID, studentID uuid.UUID) error {
+	linked, err := s.parentRepo.IsLinked(parentID, studentID)
+	if err != nil {
+		return err
+	}
+	if !linked {
+		return ErrChildNotLinked
+	}
+	return nil
+}
+
+// studentDisplayName — имя ученика для уведомлений родителям
+func studentDisplayName(student *models.User) string {
+	if name := strings.TrimSpace(student.FirstName + " " + student.LastName); name != "" {
+		return name
+	}
+	return "Ученик"
+}
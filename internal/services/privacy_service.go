@@ -0,0 +1,339 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+
+	"edubot/internal/models"
+	"edubot/internal/repository"
+	"edubot/pkg/storage"
+	"edubot/pkg/telegram"
+)
+
+// ErrErasureNotAllowed — данные преподавателей и администраторов так не удаляются: от них зависят чужие данные
+var ErrErasureNotAllowed = errors.New("erasure is allowed only for students, parents and guests")
+
+// Сущность и действие журнала изменений для удаления данных пользователя
+const (
+	AuditEntityUser   = "user"
+	AuditActionErase  = "erase"
+	AuditActionExport = "export"
+	exportFormatVer   = 1
+	exportFileTimeout = 60 * time.Second
+)
+
+// ExportManifest — оглавление архива выгрузки (manifest.json)
+type ExportManifest struct {
+	FormatVersion int                 `json:"format_version"`
+	UserID        uuid.UUID           `json:"user_id"`
+	GeneratedAt   time.Time           `json:"generated_at"`
+	GeneratedBy   uuid.UUID           `json:"generated_by"`
+	Records       map[string]int      `json:"records"` // Файл JSON → число записей
+	Files         []string            `json:"files"`   // Выгруженные файлы вложений и медиа
+	MissingFiles  []ExportMissingFile `json:"missing_files,omitempty"`
+}
+
+// ExportMissingFile — файл, который не удалось положить в архив
+type ExportMissingFile struct {
+	ID     uuid.UUID `json:"id"`
+	Reason string    `json:"reason"`
+}
+
+// ErasureReport — отчет об удалении данных пользователя
+type ErasureReport struct {
+	UserID       uuid.UUID        `json:"user_id"`
+	ErasedAt     time.Time        `json:"erased_at"`
+	ErasedBy     *uuid.UUID       `json:"erased_by,omitempty"`
+	Deleted      map[string]int64 `json:"deleted"`    // Удалено строк по таблицам
+	Anonymized   []string         `json:"anonymized"` // Обезличенные поля профиля
+	FilesDeleted int              `json:"files_deleted"`
+	FileErrors   []string         `json:"file_errors,omitempty"`
+	Notes        []string         `json:"notes"`
+}
+
+// PrivacyService выгружает и удаляет данные пользователя по запросу семьи
+type PrivacyService interface {
+	Export(actor Actor, userID uuid.UUID, w io.Writer) error
+	Erase(actor Actor, userID uuid.UUID) (*ErasureReport, error)
+}
+
+type privacyService struct {
+	userDataRepo       repository.UserDataRepository
+	userRepo           repository.UserRepository
+	teacherStudentRepo repository.TeacherStudentRepository
+	fileStorage        *storage.Storage
+	bot                *telegram.Bot
+	audit              AuditService
+	httpClient         *http.Client
+}
+
+func NewPrivacyService(
+	userDataRepo repository.UserDataRepository,
+	userRepo repository.UserRepository,
+	teacherStudentRepo repository.TeacherStudentRepository,
+	fileStorage *storage.Storage,
+	bot *telegram.Bot,
+	audit AuditService,
+) PrivacyService {
+	return &privacyService{
+		userDataRepo:       userDataRepo,
+		userRepo:           userRepo,
+		teacherStudentRepo: teacherStudentRepo,
+		fileStorage:        fileStorage,
+		bot:                bot,
+		audit:              audit,
+		httpClient:         &http.Client{Timeout: exportFileTimeout},
+	}
+}
+
+// canManage: администратор — любой пользователь, преподаватель — только свои ученики
+func (s *privacyService) canManage(requester *models.User, userID uuid.UUID) (bool, error) {
+	if requester == nil {
+		return false, nil
+	}
+	switch requester.Role {
+	case models.RoleAdmin:
+		return true, nil
+	case models.RoleTeacher:
+		return s.teacherStudentRepo.IsLinked(requester.ID, userID)
+	}
+	return false, nil
+}
+
+// Export пишет ZIP-архив со всеми данными пользователя: JSON по разделам,
+// файлы вложений и медиа, manifest.json с оглавлением. Выгрузка записывается в журнал.
+func (s *privacyService) Export(actor Actor, userID uuid.UUID, w io.Writer) error {
+	requester := actor.User
+	allowed, err := s.canManage(requester, userID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrAccessDenied
+	}
+
+	data, err := s.userDataRepo.Collect(userID)
+	if err != nil {
+		return err
+	}
+
+	manifest := ExportManifest{
+		FormatVersion: exportFormatVer,
+		UserID:        userID,
+		GeneratedAt:   time.Now(),
+		GeneratedBy:   requester.ID,
+		Records:       make(map[string]int),
+		Files:         []string{},
+	}
+
+	archive := zip.NewWriter(w)
+	sections := []struct {
+		name    string
+		records interface{}
+		count   int
+	}{
+		{"profile.json", data.User, 1},
+		{"assignment_targets.json", data.AssignmentTargets, len(data.AssignmentTargets)},
+		{"submissions.json", data.Submissions, len(data.Submissions)},
+		{"attachments.json", data.Attachments, len(data.Attachments)},
+		{"feedback.json", data.Feedback, len(data.Feedback)},
+		{"messages.json", data.Messages, len(data.Messages)},
+		{"media.json", data.Media, len(data.Media)},
+		{"notifications.json", data.Notifications, len(data.Notifications)},
+		{"drafts.json", data.Drafts, len(data.Drafts)},
+		{"teachers.json", data.TeacherLinks, len(data.TeacherLinks)},
+		{"parents.json", data.ParentLinks, len(data.ParentLinks)},
+		{"groups.json", data.GroupMemberships, len(data.GroupMemberships)},
+		{"trial_requests.json", data.TrialRequests, len(data.TrialRequests)},
+	}
+	for _, section := range sections {
+		if err := writeZipJSON(archive, section.name, section.records); err != nil {
+			return err
+		}
+		manifest.Records[section.name] = section.count
+	}
+
+	// Вложения лежат на диске сервера
+	for _, attachment := range data.Attachments {
+		name := fmt.Sprintf("files/attachments/%s_%s", attachment.ID, filepath.Base(attachment.OriginalName))
+		if err := copyFileToZip(archive, name, attachment.FilePath); err != nil {
+			manifest.MissingFiles = append(manifest.MissingFiles, ExportMissingFile{ID: attachment.ID, Reason: err.Error()})
+			continue
+		}
+		manifest.Files = append(manifest.Files, name)
+	}
+
+	// Медиа хранятся в Telegram и скачиваются через бота
+	for _, media := range data.Media {
+		name := fmt.Sprintf("files/media/%s%s", media.ID, mediaExtension(&media))
+		if err := s.copyTelegramFileToZip(archive, name, media.TelegramFileID); err != nil {
+			manifest.MissingFiles = append(manifest.MissingFiles, ExportMissingFile{ID: media.ID, Reason: err.Error()})
+			continue
+		}
+		manifest.Files = append(manifest.Files, name)
+	}
+
+	if err := writeZipJSON(archive, "manifest.json", manifest); err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:     AuditActionExport,
+		EntityType: AuditEntityUser,
+		EntityID:   userID,
+		After:      manifest.Records,
+	})
+	return nil
+}
+
+// Erase безвозвратно удаляет данные пользователя и обезличивает профиль.
+// Профиль не удаляется: на его ID ссылаются задания и журнал изменений.
+func (s *privacyService) Erase(actor Actor, userID uuid.UUID) (*ErasureReport, error) {
+	if actor.User == nil || actor.User.Role != models.RoleAdmin {
+		return nil, ErrAccessDenied
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == models.RoleTeacher || user.Role == models.RoleAdmin {
+		return nil, ErrErasureNotAllowed
+	}
+
+	anonymize := map[string]interface{}{
+		// Отрицательный ID не совпадет с настоящим Telegram ID, и тот же человек сможет войти заново
+		"telegram_id":              -int64(binary.BigEndian.Uint64(userID[:8]) >> 1),
+		"username":                 "",
+		"first_name":               "Удаленный пользователь",
+		"last_name":                "",
+		"phone":                    "",
+		"grade":                    0,
+		"subjects":                 "",
+		"invite_code":              nil,
+		"email":                    "",
+		"email_verified":           false,
+		"email_verification_token": "",
+		"email_verification_sent":  nil,
+		"role":                     models.RoleGuest,
+		"deleted_at":               time.Now(),
+	}
+	result, err := s.userDataRepo.Erase(userID, anonymize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase user data: %w", err)
+	}
+
+	report := &ErasureReport{
+		UserID:   userID,
+		ErasedAt: time.Now(),
+		ErasedBy: actor.UserID(),
+		Deleted:  result.Deleted,
+		Anonymized: []string{
+			"telegram_id", "username", "first_name", "last_name", "phone",
+			"grade", "subjects", "invite_code", "email", "role",
+		},
+		Notes: []string{
+			"Files stored in Telegram cannot be deleted by the bot; their file IDs were removed",
+			"Audit log entries keep only the anonymized user ID",
+			"Trial requests were matched by Telegram ID, phone and Telegram username; requests left with other contacts were not found",
+		},
+	}
+	for _, path := range result.FilePaths {
+		if err := s.fileStorage.DeleteFile(path); err != nil {
+			report.FileErrors = append(report.FileErrors, err.Error())
+			continue
+		}
+		report.FilesDeleted++
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:     AuditActionErase,
+		EntityType: AuditEntityUser,
+		EntityID:   userID,
+		Before:     map[string]interface{}{"role": user.Role},
+		After:      report.Deleted,
+	})
+	return report, nil
+}
+
+func (s *privacyService) copyTelegramFileToZip(archive *zip.Writer, name, fileID string) error {
+	if s.bot == nil {
+		return errors.New("telegram bot is disabled")
+	}
+	url, err := s.bot.GetFilePath(fileID)
+	if err != nil {
+		return err
+	}
+	resp, err := s.httpClient.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download file: status %d", resp.StatusCode)
+	}
+
+	dst, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, resp.Body)
+	return err
+}
+
+func writeZipJSON(archive *zip.Writer, name string, value interface{}) error {
+	dst, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(dst)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func copyFileToZip(archive *zip.Writer, name, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return errors.New("file not found on server")
+	}
+	defer src.Close()
+
+	dst, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// mediaExtension подбирает расширение файла по MIME-типу медиа
+func mediaExtension(media *models.Media) string {
+	switch media.MimeType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "application/pdf":
+		return ".pdf"
+	case "video/mp4":
+		return ".mp4"
+	case "audio/mpeg":
+		return ".mp3"
+	case "audio/ogg":
+		return ".ogg"
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"edubot/internal/models"
+	"edubot/internal/repository"
+	"edubot/pkg/database"
+	"edubot/pkg/storage"
+)
+
+// privacyFixture — сервис выгрузки и удаления данных на временной SQLite
+type privacyFixture struct {
+	service   PrivacyService
+	audit     AuditService
+	userRepo  repository.UserRepository
+	trialRepo *repository.TrialRequestRepository
+}
+
+func newPrivacyFixture(t *testing.T) *privacyFixture {
+	t.Helper()
+	t.Setenv("DATABASE_URL", "")
+	dir := t.TempDir()
+	db, err := database.NewDatabase(filepath.Join(dir, "edubot.db"))
+	if err != nil {
+		t.Fatalf("database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	fileStorage, err := storage.NewStorage(filepath.Join(dir, "uploads"), 1<<20, 1<<20)
+	if err != nil {
+		t.Fatalf("storage: %v", err)
+	}
+
+	f := &privacyFixture{
+		audit:     NewAuditService(repository.NewAuditLogRepository(db.DB)),
+		userRepo:  repository.NewUserRepository(db.DB),
+		trialRepo: repository.NewTrialRequestRepository(db.DB),
+	}
+	f.service = NewPrivacyService(
+		repository.NewUserDataRepository(db.DB),
+		f.userRepo,
+		repository.NewTeacherStudentRepository(db.DB),
+		fileStorage,
+		nil,
+		f.audit,
+	)
+	return f
+}
+
+// seedTrialRequests создает заявки ученика (по Telegram ID, телефону и username) и одну чужую
+func (f *privacyFixture) seedTrialRequests(t *testing.T) (*models.User, *models.TrialRequest) {
+	t.Helper()
+	student := &models.User{TelegramID: 4242, Username: "Anna_K", Phone: "+7 900 000-00-00", Role: models.RoleStudent}
+	if err := f.userRepo.Create(student); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	requests := []*models.TrialRequest{
+		{ContactType: "telegram", ContactValue: "unrelated", TelegramID: 4242},
+		{ContactType: "phone", ContactValue: " +7 900 000-00-00 "},
+		{ContactType: "telegram", ContactValue: "@anna_k"},
+		{ContactType: "phone", ContactValue: "+7 911 111-11-11"},
+	}
+	for _, request := range requests {
+		request.Name, request.Subject, request.Grade, request.Level = "Anna", "math", 10, 1
+		if err := f.trialRepo.Create(request); err != nil {
+			t.Fatalf("create trial request: %v", err)
+		}
+	}
+	return student, requests[3]
+}
+
+func TestPrivacyExportRecordsAudit(t *testing.T) {
+	f := newPrivacyFixture(t)
+	student, _ := f.seedTrialRequests(t)
+	admin := &models.User{ID: uuid.New(), Role: models.RoleAdmin}
+
+	var buf bytes.Buffer
+	if err := f.service.Export(Actor{User: admin, IP: "192.0.2.1"}, student.ID, &buf); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("read archive: %v", err)
+	}
+	var manifest ExportManifest
+	for _, file := range archive.File {
+		if file.Name != "manifest.json" {
+			continue
+		}
+		r, err := file.Open()
+		if err != nil {
+			t.Fatalf("open manifest: %v", err)
+		}
+		if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+			t.Fatalf("decode manifest: %v", err)
+		}
+		r.Close()
+	}
+	if manifest.Records["trial_requests.json"] != 3 {
+		t.Errorf("trial_requests.json records = %d, want 3", manifest.Records["trial_requests.json"])
+	}
+
+	logs, _, err := f.audit.List(repository.AuditLogFilter{EntityType: AuditEntityUser, EntityID: &student.ID, Limit: 10})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(logs) != 1 || logs[0].Action != AuditActionExport || logs[0].ActorID == nil || *logs[0].ActorID != admin.ID || logs[0].IP != "192.0.2.1" {
+		t.Errorf("expected one export entry by the admin, got %+v", logs)
+	}
+
+	// Отказ в доступе не пишется в журнал как выгрузка
+	guest := &models.User{ID: uuid.New(), Role: models.RoleGuest}
+	if err := f.service.Export(Actor{User: guest}, student.ID, &bytes.Buffer{}); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("guest export: expected ErrAccessDenied, got %v", err)
+	}
+	if _, total, _ := f.audit.List(repository.AuditLogFilter{EntityType: AuditEntityUser, EntityID: &student.ID, Limit: 10}); total != 1 {
+		t.Errorf("audit entries after denied export = %d, want 1", total)
+	}
+}
+
+func TestPrivacyEraseDeletesTrialRequests(t *testing.T) {
+	f := newPrivacyFixture(t)
+	student, other := f.seedTrialRequests(t)
+	admin := &models.User{ID: uuid.New(), Role: models.RoleAdmin}
+
+	report, err := f.service.Erase(Actor{User: admin}, student.ID)
+	if err != nil {
+		t.Fatalf("Erase: %v", err)
+	}
+	if report.Deleted["trial_requests"] != 3 {
+		t.Errorf("deleted trial requests = %d, want 3", report.Deleted["trial_requests"])
+	}
+
+	remaining, err := f.trialRepo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].ID != other.ID {
+		t.Errorf("expected only the unrelated request to remain, got %+v", remaining)
+	}
+}
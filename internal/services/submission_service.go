@@ -2,13 +2,15 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
 	"edubot/internal/models"
 	"edubot/internal/repository"
-	"edubot/pkg/telegram"
 )
 
 type SubmissionService interface {
@@ -38,8 +40,7 @@ type submissionService struct {
 	assignmentTargetRepo repository.AssignmentTargetRepository
 	draftRepo            repository.DraftRepository
 	userRepo             repository.UserRepository
-	notificationRepo     repository.NotificationRepository
-	bot                  *telegram.Bot
+	notificationService  NotificationService
 }
 
 func NewSubmissionService(
@@ -47,16 +48,14 @@ func NewSubmissionService(
 	assignmentTargetRepo repository.AssignmentTargetRepository,
 	draftRepo repository.DraftRepository,
 	userRepo repository.UserRepository,
-	notificationRepo repository.NotificationRepository,
-	bot *telegram.Bot,
+	notificationService NotificationService,
 ) SubmissionService {
 	return &submissionService{
 		submissionRepo:       submissionRepo,
 		assignmentTargetRepo: assignmentTargetRepo,
 		draftRepo:            draftRepo,
 		userRepo:             userRepo,
-		notificationRepo:     notificationRepo,
-		bot:                  bot,
+		notificationService:  notificationService,
 	}
 }
 
@@ -144,31 +143,20 @@ func (s *submissionService) SubmitAssignment(assignmentTargetID, studentID uuid.
 	// Удаляем черновик, если он был
 	s.draftRepo.DeleteByAssignmentTarget(assignmentTargetID)
 
-	// Отправляем уведомление учителю
-	if s.bot != nil {
-		teacher, err := s.userRepo.GetByID(assignment.Assignment.TeacherID)
-		if err == nil && teacher.TelegramID != 0 {
-			s.bot.SendAssignmentCompletedNotification(
-				teacher.TelegramID,
-				assignment.Assignment.Title,
-				assignment.Assignment.Subject,
-				assignment.Assignment.DueDate.Format("2006-01-02 15:04"),
-			)
-		}
+	// Уведомляем учителя по включенным им каналам
+	studentName := "Ученик"
+	if student, err := s.userRepo.GetByID(studentID); err == nil {
+		studentName = strings.TrimSpace(student.FirstName + " " + student.LastName)
+	}
+	if err := s.notificationService.CreateNotification(&models.Notification{
+		UserID:  assignment.Assignment.TeacherID,
+		Type:    models.NotificationTypeNewAssignment,
+		Title:   "Новая отправка задания",
+		Message: fmt.Sprintf("%s отправил задание: %s\nПредмет: %s", studentName, assignment.Assignment.Title, assignment.Assignment.Subject),
+		Payload: `{"submission_id":"` + submission.ID.String() + `","assignment_target_id":"` + assignmentTargetID.String() + `"}`,
+	}); err != nil {
+		log.Printf("Failed to create submission notification: %v", err)
 	}
-
-	// Создаем уведомление в системе
-	s.notificationRepo.Create(&models.Notification{
-		UserID:    assignment.Assignment.TeacherID,
-		Type:      models.NotificationTypeNewAssignment,
-		Title:     "Новая отправка задания",
-		Message:   "Студент отправил задание: " + assignment.Assignment.Title,
-		Payload:   `{"submission_id":"` + submission.ID.String() + `","assignment_target_id":"` + assignmentTargetID.String() + `"}`,
-		Channel:   models.NotificationChannelBot,
-		Status:    models.NotificationStatusPending,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	})
 
 	return submission, nil
 }
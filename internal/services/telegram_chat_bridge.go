@@ -0,0 +1,91 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"edubot/internal/models"
+	"edubot/internal/repository"
+	"edubot/pkg/telegram"
+)
+
+// TelegramChatBridge публикует ответы на уведомления бота в чаты приложения,
+// чтобы ученик мог переписываться с преподавателем, не открывая приложение
+type TelegramChatBridge struct {
+	linkRepo     repository.TelegramMessageLinkRepository
+	userRepo     repository.UserRepository
+	chatService  ChatService
+	mediaService MediaService
+}
+
+func NewTelegramChatBridge(
+	linkRepo repository.TelegramMessageLinkRepository,
+	userRepo repository.UserRepository,
+	chatService ChatService,
+	mediaService MediaService,
+) *TelegramChatBridge {
+	return &TelegramChatBridge{
+		linkRepo:     linkRepo,
+		userRepo:     userRepo,
+		chatService:  chatService,
+		mediaService: mediaService,
+	}
+}
+
+// HandleReply — обработчик для telegram.Bot.SetOnChatReply. Ответы на другие
+// сообщения бота или на чужие превью не обрабатываются (handled = false).
+func (b *TelegramChatBridge) HandleReply(reply telegram.ChatReply) (string, bool) {
+	link, err := b.linkRepo.GetByTelegramMessage(reply.ChatID, reply.ReplyToMessageID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Failed to find telegram message link %d/%d: %v", reply.ChatID, reply.ReplyToMessageID, err)
+		}
+		return "", false
+	}
+	user, err := b.userRepo.GetByTelegramID(reply.FromID)
+	if err != nil || user.ID != link.UserID {
+		return "", false
+	}
+
+	text := strings.TrimSpace(reply.Text)
+	if text == "" && len(reply.Files) == 0 {
+		return "Можно отправить текст, фото или документ.", true
+	}
+
+	// Файлы остаются в Telegram, в приложении сохраняются их file_id
+	mediaIDs := make([]uuid.UUID, 0, len(reply.Files))
+	for _, file := range reply.Files {
+		media, err := b.mediaService.CreateMediaFromTelegram(
+			file.FileID, file.UniqueID, reply.ChatID, reply.MessageID,
+			models.MediaType(file.Kind), file.MimeType, file.Size, file.FileName,
+			user.ID, models.MediaScopePrivate, "", nil,
+		)
+		if err != nil {
+			log.Printf("Failed to save telegram file from %d: %v", reply.FromID, err)
+			return "Не удалось сохранить файл, попробуйте еще раз.", true
+		}
+		mediaIDs = append(mediaIDs, media.ID)
+	}
+
+	var messageText *string
+	if text != "" {
+		messageText = &text
+	}
+	// Ответ на превью цитирует исходное сообщение чата, если оно еще не удалено
+	_, err = b.chatService.SendMessage(link.ThreadID, user.ID, messageText, mediaIDs, link.MessageID, models.MessageKindMessage)
+	if errors.Is(err, ErrMessageNotInThread) {
+		_, err = b.chatService.SendMessage(link.ThreadID, user.ID, messageText, mediaIDs, nil, models.MessageKindMessage)
+	}
+	if err != nil {
+		if errors.Is(err, ErrAccessDenied) || errors.Is(err, gorm.ErrRecordNotFound) {
+			return "Этот чат вам больше недоступен.", true
+		}
+		log.Printf("Failed to post telegram reply to thread %s: %v", link.ThreadID, err)
+		return "Не удалось отправить сообщение, попробуйте позже.", true
+	}
+	return "✅ Отправлено в чат", true
+}
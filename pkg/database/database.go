@@ -73,8 +73,24 @@ func (d *Database) Migrate() error {
 		&models.ChatThread{},
 		&models.Message{},
 		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.NotificationSettings{},
 		&models.Draft{},
 		&models.HomepageMedia{},
+		&models.JobRun{},
+		&models.JobLock{},
+		&models.Session{},
+		&models.TeacherStudent{},
+		&models.ParentStudent{},
+		&models.ParentInvite{},
+		&models.RoleChange{},
+		&models.Invite{},
+		&models.InviteRedemption{},
+		&models.AuditLog{},
+		&models.AccessToken{},
+		&models.ChatReadCursor{},
+		&models.TelegramMessageLink{},
+		&models.MessageReaction{},
 	)
 }
 
@@ -0,0 +1,223 @@
+package email
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// smtpSink — минимальный SMTP-сервер для тестов: принимает письма и
+// отвечает rcptReply (например, "550 no such user") на RCPT TO
+type smtpSink struct {
+	listener  net.Listener
+	rcptReply string
+
+	mu       sync.Mutex
+	from     string
+	rcpts    []string
+	messages []string
+}
+
+func newSMTPSink(t *testing.T, rcptReply string) *smtpSink {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	sink := &smtpSink{listener: listener, rcptReply: rcptReply}
+	go sink.serve()
+	t.Cleanup(func() { listener.Close() })
+	return sink
+}
+
+func (s *smtpSink) config() Config {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return Config{Host: "127.0.0.1", Port: addr.Port, From: "EduBot <bot@edubot.test>"}
+}
+
+func (s *smtpSink) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpSink) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 sink ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSpace(line)
+		upper := strings.ToUpper(cmd)
+		switch {
+		case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+			reply("250 sink")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			s.mu.Lock()
+			s.from = cmd[len("MAIL FROM:"):]
+			s.mu.Unlock()
+			reply("250 ok")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			if s.rcptReply != "" {
+				reply(s.rcptReply)
+				continue
+			}
+			s.mu.Lock()
+			s.rcpts = append(s.rcpts, cmd[len("RCPT TO:"):])
+			s.mu.Unlock()
+			reply("250 ok")
+		case upper == "DATA":
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 queued")
+		case upper == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestSMTPSenderDeliversMultipartMessage(t *testing.T) {
+	sink := newSMTPSink(t, "")
+	sender, err := NewSMTPSender(sink.config())
+	if err != nil {
+		t.Fatalf("NewSMTPSender: %v", err)
+	}
+
+	err = sender.Send(&Message{
+		To:      "Ученик <student@example.com>",
+		Subject: "Новое задание",
+		Text:    "Проверьте задание по алгебре",
+		HTML:    "<p>Проверьте задание по <b>алгебре</b></p>",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if sink.from != "<bot@edubot.test>" {
+		t.Errorf("MAIL FROM = %q", sink.from)
+	}
+	if len(sink.rcpts) != 1 || sink.rcpts[0] != "<student@example.com>" {
+		t.Errorf("RCPT TO = %v", sink.rcpts)
+	}
+	if len(sink.messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(sink.messages))
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(sink.messages[0]))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Новое задание" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q (%v)", msg.Header.Get("Content-Type"), err)
+	}
+
+	parts := map[string]string{}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatalf("decode part: %v", err)
+		}
+		parts[strings.SplitN(part.Header.Get("Content-Type"), ";", 2)[0]] = string(body)
+	}
+	if parts["text/plain"] != "Проверьте задание по алгебре" {
+		t.Errorf("text part = %q", parts["text/plain"])
+	}
+	if parts["text/html"] != "<p>Проверьте задание по <b>алгебре</b></p>" {
+		t.Errorf("html part = %q", parts["text/html"])
+	}
+}
+
+func TestSMTPSenderClassifiesRejections(t *testing.T) {
+	tests := []struct {
+		reply     string
+		permanent bool
+	}{
+		{"550 no such user", true},
+		{"552 mailbox full", true},
+		{"450 try again later", false},
+	}
+	for _, tt := range tests {
+		sink := newSMTPSink(t, tt.reply)
+		sender, err := NewSMTPSender(sink.config())
+		if err != nil {
+			t.Fatalf("NewSMTPSender: %v", err)
+		}
+		err = sender.Send(&Message{To: "student@example.com", Subject: "s", Text: "t"})
+		if err == nil {
+			t.Fatalf("%s: expected error", tt.reply)
+		}
+		if IsPermanent(err) != tt.permanent {
+			t.Errorf("%s: IsPermanent = %v, want %v (%v)", tt.reply, IsPermanent(err), tt.permanent, err)
+		}
+	}
+}
+
+func TestSMTPSenderRejectsInvalidRecipient(t *testing.T) {
+	// Порт не слушается: до соединения дело доходить не должно
+	sender, err := NewSMTPSender(Config{Host: "127.0.0.1", Port: 1, From: "bot@edubot.test"})
+	if err != nil {
+		t.Fatalf("NewSMTPSender: %v", err)
+	}
+	if err := sender.Send(&Message{To: "not an address", Subject: "s", Text: "t"}); !IsPermanent(err) {
+		t.Errorf("expected permanent error, got %v", err)
+	}
+}
+
+func TestNewSMTPSenderValidatesConfig(t *testing.T) {
+	if _, err := NewSMTPSender(Config{From: "bot@edubot.test"}); err == nil {
+		t.Error("expected error without host")
+	}
+	if _, err := NewSMTPSender(Config{Host: "localhost", Port: 25, From: "bot"}); err == nil {
+		t.Error("expected error for invalid from address")
+	}
+	if _, err := NewSMTPSender(Config{Host: "localhost", Port: 25, From: "EduBot <bot@edubot.test>"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Store хранит счетчики запросов. Память процесса подходит для одного экземпляра,
+// при нескольких экземплярах нужна общая реализация (например, Redis).
+type Store interface {
+	// Increment увеличивает счетчик ключа в текущем окне и возвращает его значение и время сброса
+	Increment(key string, window time.Duration) (count int, resetAt time.Time, err error)
+	// Count возвращает счетчик ключа в текущем окне, не увеличивая его (0 — окна нет)
+	Count(key string) (count int, resetAt time.Time, err error)
+}
+
+// Rule — не больше Limit запросов за Window. Limit 0 отключает ограничение.
+type Rule struct {
+	Limit  int
+	Window time.Duration
+}
+
+// ParseRule разбирает правило вида "30/1m"; "0" или пустая строка отключают ограничение
+func ParseRule(value string) (Rule, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return Rule{}, nil
+	}
+	limitPart, windowPart, found := strings.Cut(value, "/")
+	if !found {
+		return Rule{}, fmt.Errorf("rate limit %q: expected <limit>/<window>", value)
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(limitPart))
+	if err != nil || limit < 0 {
+		return Rule{}, fmt.Errorf("rate limit %q: invalid limit", value)
+	}
+	window, err := time.ParseDuration(strings.TrimSpace(windowPart))
+	if err != nil || window <= 0 {
+		return Rule{}, fmt.Errorf("rate limit %q: invalid window", value)
+	}
+	return Rule{Limit: limit, Window: window}, nil
+}
+
+// Enabled сообщает, что правило что-то ограничивает
+func (r Rule) Enabled() bool {
+	return r.Limit > 0 && r.Window > 0
+}
+
+// Limiter применяет правило к ключам (IP, Telegram ID) в общем хранилище
+type Limiter struct {
+	store  Store
+	rule   Rule
+	prefix string
+}
+
+// NewLimiter создает ограничитель; prefix разделяет счетчики разных ограничителей в одном хранилище
+func NewLimiter(store Store, prefix string, rule Rule) *Limiter {
+	return &Limiter{store: store, rule: rule, prefix: prefix}
+}
+
+// Allow учитывает запрос и сообщает, укладывается ли он в лимит.
+// При отказе возвращает, через сколько можно повторить.
+// Ошибка хранилища не блокирует запросы: лучше пропустить лишнее, чем закрыть вход всем.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil || !l.rule.Enabled() {
+		return true, 0
+	}
+	count, resetAt, err := l.store.Increment(l.prefix+":"+key, l.rule.Window)
+	if err != nil {
+		return true, 0
+	}
+	if count > l.rule.Limit {
+		return false, time.Until(resetAt)
+	}
+	return true, 0
+}
+
+// Blocked сообщает, исчерпан ли лимит ключа, не учитывая сам вызов. Вместе с Allow позволяет
+// считать только неудачные попытки: Blocked — перед попыткой, Allow — после неудачи.
+func (l *Limiter) Blocked(key string) (bool, time.Duration) {
+	if l == nil || !l.rule.Enabled() {
+		return false, 0
+	}
+	count, resetAt, err := l.store.Count(l.prefix + ":" + key)
+	if err != nil {
+		return false, 0
+	}
+	if count >= l.rule.Limit {
+		return true, time.Until(resetAt)
+	}
+	return false, 0
+}
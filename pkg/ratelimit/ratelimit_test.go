@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// failingStore — хранилище, которое всегда возвращает ошибку
+type failingStore struct{}
+
+func (failingStore) Increment(key string, window time.Duration) (int, time.Time, error) {
+	return 0, time.Time{}, errors.New("store is down")
+}
+
+func (failingStore) Count(key string) (int, time.Time, error) {
+	return 0, time.Time{}, errors.New("store is down")
+}
+
+func TestLimiterWindow(t *testing.T) {
+	window := 100 * time.Millisecond
+	limiter := NewLimiter(NewMemoryStore(), "test", Rule{Limit: 2, Window: window})
+
+	for i := 0; i < 2; i++ {
+		if allowed, _ := limiter.Allow("a"); !allowed {
+			t.Fatalf("request %d rejected within limit", i+1)
+		}
+	}
+	allowed, retryAfter := limiter.Allow("a")
+	if allowed {
+		t.Fatal("request over limit allowed")
+	}
+	if retryAfter <= 0 || retryAfter > window {
+		t.Errorf("retryAfter = %s, want (0, %s]", retryAfter, window)
+	}
+
+	// Счетчики ключей независимы
+	if allowed, _ := limiter.Allow("b"); !allowed {
+		t.Error("other key rejected")
+	}
+
+	// Окно фиксированное: после сброса лимит снова доступен
+	time.Sleep(window + 20*time.Millisecond)
+	if allowed, _ := limiter.Allow("a"); !allowed {
+		t.Error("request rejected after window reset")
+	}
+}
+
+func TestLimiterPrefixesShareStore(t *testing.T) {
+	store := NewMemoryStore()
+	first := NewLimiter(store, "first", Rule{Limit: 1, Window: time.Minute})
+	second := NewLimiter(store, "second", Rule{Limit: 1, Window: time.Minute})
+
+	first.Allow("key")
+	if allowed, _ := first.Allow("key"); allowed {
+		t.Error("first limiter: second request allowed")
+	}
+	if allowed, _ := second.Allow("key"); !allowed {
+		t.Error("second limiter must not see counters of the first")
+	}
+}
+
+func TestLimiterBlocked(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(), "fail", Rule{Limit: 2, Window: time.Minute})
+
+	// Проверка не расходует лимит
+	for i := 0; i < 5; i++ {
+		if blocked, _ := limiter.Blocked("ip"); blocked {
+			t.Fatal("blocked before any failures")
+		}
+	}
+	limiter.Allow("ip")
+	if blocked, _ := limiter.Blocked("ip"); blocked {
+		t.Error("blocked after one failure of two")
+	}
+	limiter.Allow("ip")
+	blocked, retryAfter := limiter.Blocked("ip")
+	if !blocked || retryAfter <= 0 {
+		t.Errorf("expected blocked after two failures, got %v %s", blocked, retryAfter)
+	}
+}
+
+func TestLimiterDisabledOrFailing(t *testing.T) {
+	limiters := map[string]*Limiter{
+		"nil":           nil,
+		"zero rule":     NewLimiter(NewMemoryStore(), "off", Rule{}),
+		"failing store": NewLimiter(failingStore{}, "down", Rule{Limit: 1, Window: time.Minute}),
+	}
+	for name, limiter := range limiters {
+		for i := 0; i < 3; i++ {
+			if allowed, _ := limiter.Allow("key"); !allowed {
+				t.Errorf("%s: request %d rejected", name, i+1)
+			}
+		}
+		if blocked, _ := limiter.Blocked("key"); blocked {
+			t.Errorf("%s: key blocked", name)
+		}
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Rule
+	}{
+		{"", Rule{}},
+		{"0", Rule{}},
+		{"30/1m", Rule{Limit: 30, Window: time.Minute}},
+		{" 5 / 1h ", Rule{Limit: 5, Window: time.Hour}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRule(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseRule(%q) = %+v, %v; want %+v", tt.value, got, err, tt.want)
+		}
+	}
+
+	for _, value := range []string{"30", "x/1m", "-1/1m", "5/0s", "5/soon"} {
+		if _, err := ParseRule(value); err == nil {
+			t.Errorf("ParseRule(%q) expected error", value)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package telegram
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidAuthHash = errors.New("invalid telegram auth signature")
+	ErrAuthExpired     = errors.New("telegram auth data expired")
+	ErrNoBotToken      = errors.New("bot token is required to validate auth data")
+)
+
+// authClockSkew — допустимое расхождение часов, если auth_date немного в будущем
+const authClockSkew = time.Minute
+
+// WebAppUser — пользователь из initData Mini App
+type WebAppUser struct {
+	ID        int64  `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Username  string `json:"username"`
+	PhotoURL  string `json:"photo_url"`
+}
+
+// WebAppInitData — разобранная строка Telegram.WebApp.initData
+type WebAppInitData struct {
+	User       WebAppUser
+	AuthDate   time.Time
+	QueryID    string
+	StartParam string
+}
+
+// ParseWebAppInitData разбирает initData без проверки подписи
+func ParseWebAppInitData(initData string) (*WebAppInitData, error) {
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid init data: %w", err)
+	}
+
+	data := &WebAppInitData{
+		QueryID:    values.Get("query_id"),
+		StartParam: values.Get("start_param"),
+	}
+	if err := json.Unmarshal([]byte(values.Get("user")), &data.User); err != nil || data.User.ID == 0 {
+		return nil, errors.New("init data has no user")
+	}
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil {
+		return nil, errors.New("init data has no auth_date")
+	}
+	data.AuthDate = time.Unix(authDate, 0)
+	return data, nil
+}
+
+// ValidateWebAppInitData проверяет подпись initData Mini App:
+// secret = HMAC-SHA256("WebAppData", botToken), hash = HMAC-SHA256(secret, data_check_string)
+func ValidateWebAppInitData(initData, botToken string, maxAge time.Duration) (*WebAppInitData, error) {
+	if botToken == "" {
+		return nil, ErrNoBotToken
+	}
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid init data: %w", err)
+	}
+
+	fields := make(map[string]string, len(values))
+	for key := range values {
+		fields[key] = values.Get(key)
+	}
+	if !checkHash(fields, hmacSHA256([]byte("WebAppData"), []byte(botToken))) {
+		return nil, ErrInvalidAuthHash
+	}
+
+	data, err := ParseWebAppInitData(initData)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAuthDate(data.AuthDate, maxAge); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ValidateLoginWidget проверяет данные Telegram Login Widget:
+// secret = SHA256(botToken), hash = HMAC-SHA256(secret, data_check_string).
+// fields — все полученные поля виджета, включая hash и auth_date.
+func ValidateLoginWidget(fields map[string]string, botToken string, maxAge time.Duration) error {
+	if botToken == "" {
+		return ErrNoBotToken
+	}
+	secret := sha256.Sum256([]byte(botToken))
+	if !checkHash(fields, secret[:]) {
+		return ErrInvalidAuthHash
+	}
+
+	authDate, err := strconv.ParseInt(fields["auth_date"], 10, 64)
+	if err != nil {
+		return errors.New("auth data has no auth_date")
+	}
+	return checkAuthDate(time.Unix(authDate, 0), maxAge)
+}
+
+// checkHash сравнивает поле hash с подписью data_check_string:
+// все поля, кроме hash, в виде key=value, отсортированные по ключу и разделенные \n
+func checkHash(fields map[string]string, secret []byte) bool {
+	hash := fields["hash"]
+	if hash == "" {
+		return false
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		if key != "hash" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+fields[key])
+	}
+
+	expected := hex.EncodeToString(hmacSHA256(secret, []byte(strings.Join(pairs, "\n"))))
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(hash)))
+}
+
+func checkAuthDate(authDate time.Time, maxAge time.Duration) error {
+	age := time.Since(authDate)
+	if age < -authClockSkew || (maxAge > 0 && age > maxAge) {
+		return ErrAuthExpired
+	}
+	return nil
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,141 @@
+package telegram
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Векторы подписаны независимо (Python hmac/hashlib) по алгоритмам из документации Telegram
+const (
+	testBotToken = "123456789:AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsaw"
+
+	testInitData = "query_id=AAHdF6IQAAAAAN0XohDhrOrc" +
+		"&user=%7B%22id%22%3A279058397%2C%22first_name%22%3A%22Vladislav%22%2C%22last_name%22%3A%22Kibenko%22%2C%22username%22%3A%22vdkfrost%22%2C%22language_code%22%3A%22ru%22%2C%22is_premium%22%3Atrue%7D" +
+		"&auth_date=1700000000" +
+		"&hash=4d01a9830a7a5bbafe066c9c69bc81878beddfb000521ff9b338d20e4b89486c"
+
+	testWidgetHash = "e2f0a2f7b85584ee4714530fee809f9d8d06e353ba5ea8ce6716dee749c88916"
+)
+
+func testWidgetFields() map[string]string {
+	return map[string]string{
+		"id":         "279058397",
+		"first_name": "Vladislav",
+		"username":   "vdkfrost",
+		"photo_url":  "https://t.me/i/userpic/320/x.jpg",
+		"auth_date":  "1700000000",
+		"hash":       testWidgetHash,
+	}
+}
+
+// signInitData подписывает initData с заданным auth_date — для проверок срока действия
+func signInitData(t *testing.T, authDate time.Time) string {
+	t.Helper()
+	values := url.Values{}
+	values.Set("user", `{"id":42,"first_name":"Anna"}`)
+	values.Set("auth_date", strconv.FormatInt(authDate.Unix(), 10))
+	fields := map[string]string{"user": values.Get("user"), "auth_date": values.Get("auth_date")}
+	keys := []string{"auth_date", "user"}
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+fields[key])
+	}
+	secret := hmacSHA256([]byte("WebAppData"), []byte(testBotToken))
+	values.Set("hash", hex.EncodeToString(hmacSHA256(secret, []byte(strings.Join(pairs, "\n")))))
+	return values.Encode()
+}
+
+func TestValidateWebAppInitData(t *testing.T) {
+	data, err := ValidateWebAppInitData(testInitData, testBotToken, 0)
+	if err != nil {
+		t.Fatalf("valid init data rejected: %v", err)
+	}
+	if data.User.ID != 279058397 || data.User.Username != "vdkfrost" || data.User.LastName != "Kibenko" {
+		t.Errorf("unexpected user: %+v", data.User)
+	}
+	if data.QueryID != "AAHdF6IQAAAAAN0XohDhrOrc" || data.AuthDate.Unix() != 1700000000 {
+		t.Errorf("unexpected data: %+v", data)
+	}
+}
+
+func TestValidateWebAppInitDataRejectsTampering(t *testing.T) {
+	tests := map[string]string{
+		"changed user":  strings.Replace(testInitData, "279058397", "279058398", 1),
+		"changed date":  strings.Replace(testInitData, "auth_date=1700000000", "auth_date=1700000001", 1),
+		"extra field":   testInitData + "&start_param=admin",
+		"no hash":       testInitData[:strings.Index(testInitData, "&hash=")],
+		"uppercase key": strings.Replace(testInitData, "query_id", "Query_id", 1),
+	}
+	for name, initData := range tests {
+		if _, err := ValidateWebAppInitData(initData, testBotToken, 0); !errors.Is(err, ErrInvalidAuthHash) {
+			t.Errorf("%s: expected ErrInvalidAuthHash, got %v", name, err)
+		}
+	}
+
+	if _, err := ValidateWebAppInitData(testInitData, "987654:other", 0); !errors.Is(err, ErrInvalidAuthHash) {
+		t.Errorf("other bot token: expected ErrInvalidAuthHash, got %v", err)
+	}
+	if _, err := ValidateWebAppInitData(testInitData, "", 0); !errors.Is(err, ErrNoBotToken) {
+		t.Errorf("empty bot token: expected ErrNoBotToken, got %v", err)
+	}
+}
+
+func TestValidateWebAppInitDataAge(t *testing.T) {
+	if _, err := ValidateWebAppInitData(signInitData(t, time.Now().Add(-time.Minute)), testBotToken, time.Hour); err != nil {
+		t.Errorf("fresh init data rejected: %v", err)
+	}
+	if _, err := ValidateWebAppInitData(signInitData(t, time.Now().Add(-2*time.Hour)), testBotToken, time.Hour); !errors.Is(err, ErrAuthExpired) {
+		t.Errorf("old init data: expected ErrAuthExpired, got %v", err)
+	}
+	if _, err := ValidateWebAppInitData(signInitData(t, time.Now().Add(time.Hour)), testBotToken, time.Hour); !errors.Is(err, ErrAuthExpired) {
+		t.Errorf("future init data: expected ErrAuthExpired, got %v", err)
+	}
+	// Тестовый вектор подписан давно: с ограничением возраста он устарел
+	if _, err := ValidateWebAppInitData(testInitData, testBotToken, time.Hour); !errors.Is(err, ErrAuthExpired) {
+		t.Errorf("vector with max age: expected ErrAuthExpired, got %v", err)
+	}
+}
+
+func TestValidateLoginWidget(t *testing.T) {
+	if err := ValidateLoginWidget(testWidgetFields(), testBotToken, 0); err != nil {
+		t.Fatalf("valid widget data rejected: %v", err)
+	}
+
+	upper := testWidgetFields()
+	upper["hash"] = strings.ToUpper(testWidgetHash)
+	if err := ValidateLoginWidget(upper, testBotToken, 0); err != nil {
+		t.Errorf("uppercase hash rejected: %v", err)
+	}
+
+	tampered := testWidgetFields()
+	tampered["id"] = "1"
+	if err := ValidateLoginWidget(tampered, testBotToken, 0); !errors.Is(err, ErrInvalidAuthHash) {
+		t.Errorf("tampered id: expected ErrInvalidAuthHash, got %v", err)
+	}
+
+	// Поле, которого не было при подписи, ломает подпись
+	extra := testWidgetFields()
+	extra["last_name"] = "Kibenko"
+	if err := ValidateLoginWidget(extra, testBotToken, 0); !errors.Is(err, ErrInvalidAuthHash) {
+		t.Errorf("extra field: expected ErrInvalidAuthHash, got %v", err)
+	}
+
+	// Ключ виджета — SHA256(token), а не HMAC("WebAppData", token)
+	secret := sha256.Sum256([]byte(testBotToken))
+	if checkHash(testWidgetFields(), hmacSHA256([]byte("WebAppData"), []byte(testBotToken))) || !checkHash(testWidgetFields(), secret[:]) {
+		t.Error("widget must be signed with SHA256(bot token)")
+	}
+
+	if err := ValidateLoginWidget(testWidgetFields(), testBotToken, time.Hour); !errors.Is(err, ErrAuthExpired) {
+		t.Errorf("old widget data: expected ErrAuthExpired, got %v", err)
+	}
+	if err := ValidateLoginWidget(testWidgetFields(), "", 0); !errors.Is(err, ErrNoBotToken) {
+		t.Errorf("empty bot token: expected ErrNoBotToken, got %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"strconv"
 	"strings"
+	"sync"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -14,17 +15,26 @@ type Bot struct {
 	api           *tgbotapi.BotAPI
 	token         string
 	webhook       string
+	webhookSecret string
 	assignStudent func(teacherTelegramID int64, telegramID *int64, username string, grade *int, subjects string) error
 	getUserRole   func(telegramID int64) string
 	listGroups    func(teacherTelegramID int64) ([]struct {
 		ID   string
 		Name string
 	}, error)
+	onStart        func(telegramID int64)
+	onStartCode    func(from *tgbotapi.User, code string) string
+	onChatReply    func(reply ChatReply) (string, bool)
+	queue          *SendQueue
+	updates        *updateDeduper
+
+	mu             sync.Mutex
 	systemMessages map[int64]int // ID последнего системного сообщения для каждого чата
 }
 
-// NewBot создает новый экземпляр бота
-func NewBot(token, webhook string) (*Bot, error) {
+// NewBot создает новый экземпляр бота.
+// Пустой webhookSecret выводится из токена, чтобы секрет был стабилен между перезапусками.
+func NewBot(token, webhook, webhookSecret string, queueConfig QueueConfig) (*Bot, error) {
 	bot, err := tgbotapi.NewBotAPI(token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bot: %w", err)
@@ -32,14 +42,51 @@ func NewBot(token, webhook string) (*Bot, error) {
 
 	bot.Debug = false // Включаем в режиме разработки
 
+	if webhookSecret == "" {
+		webhookSecret = DeriveWebhookSecret(token)
+	}
+
 	return &Bot{
 		api:             bot,
 		token:           token,
 		webhook:         webhook,
+		webhookSecret:   webhookSecret,
 		systemMessages:  make(map[int64]int),
+		queue:           NewSendQueue(queueConfig, bot.Send),
+		updates:         newUpdateDeduper(updateDedupeSize),
 	}, nil
 }
 
+// send отправляет сообщение через очередь с ограничением скорости
+func (b *Bot) send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	return b.queue.Send(chatIDOf(c), c)
+}
+
+// QueueStats возвращает метрики очереди исходящих сообщений
+func (b *Bot) QueueStats() QueueStats {
+	return b.queue.Stats()
+}
+
+// Stop останавливает очередь отправки
+func (b *Bot) Stop() {
+	b.queue.Stop()
+}
+
+// chatIDOf возвращает чат получателя для лимита на чат (0 — только общий лимит)
+func chatIDOf(c tgbotapi.Chattable) int64 {
+	switch m := c.(type) {
+	case tgbotapi.MessageConfig:
+		return m.ChatID
+	case tgbotapi.PhotoConfig:
+		return m.ChatID
+	case tgbotapi.DocumentConfig:
+		return m.ChatID
+	case tgbotapi.EditMessageTextConfig:
+		return m.ChatID
+	}
+	return 0
+}
+
 // SetAssignStudent callback to backend
 func (b *Bot) SetAssignStudent(cb func(teacherTelegramID int64, telegramID *int64, username string, grade *int, subjects string) error) {
 	b.assignStudent = cb
@@ -47,7 +94,11 @@ func (b *Bot) SetAssignStudent(cb func(teacherTelegramID int64, telegramID *int6
 
 // deletePreviousSystemMessage удаляет предыдущее системное сообщение
 func (b *Bot) deletePreviousSystemMessage(chatID int64) {
-	if messageID, exists := b.systemMessages[chatID]; exists && messageID > 0 {
+	b.mu.Lock()
+	messageID, exists := b.systemMessages[chatID]
+	b.mu.Unlock()
+
+	if exists && messageID > 0 {
 		deleteMsg := tgbotapi.NewDeleteMessage(chatID, messageID)
 		_, err := b.api.Request(deleteMsg)
 		if err != nil {
@@ -56,6 +107,13 @@ func (b *Bot) deletePreviousSystemMessage(chatID int64) {
 	}
 }
 
+// setSystemMessage запоминает ID последнего системного сообщения в чате
+func (b *Bot) setSystemMessage(chatID int64, messageID int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.systemMessages[chatID] = messageID
+}
+
 // sendSystemMessage отправляет системное сообщение с автоудалением предыдущего
 func (b *Bot) sendSystemMessage(chatID int64, text string, parseMode string) error {
 	// Удаляем предыдущее системное сообщение
@@ -66,13 +124,13 @@ func (b *Bot) sendSystemMessage(chatID int64, text string, parseMode string) err
 		msg.ParseMode = parseMode
 	}
 	
-	sentMsg, err := b.api.Send(msg)
+	sentMsg, err := b.send(msg)
 	if err != nil {
 		return err
 	}
 	
 	// Сохраняем ID нового системного сообщения
-	b.systemMessages[chatID] = sentMsg.MessageID
+	b.setSystemMessage(chatID, sentMsg.MessageID)
 	return nil
 }
 
@@ -87,19 +145,41 @@ func (b *Bot) SetListTeacherGroups(cb func(teacherTelegramID int64) ([]struct {
 	b.listGroups = cb
 }
 
-// SetWebhook устанавливает webhook для бота
+// SetOnStart callback: пользователь нажал /start и снова доступен для сообщений бота
+func (b *Bot) SetOnStart(cb func(telegramID int64)) { b.onStart = cb }
+
+// SetOnStartCode callback: пользователь пришел по ссылке t.me/<bot>?start=<code>.
+// Возвращает текст ответа пользователю.
+func (b *Bot) SetOnStartCode(cb func(from *tgbotapi.User, code string) string) { b.onStartCode = cb }
+
+// Username возвращает username бота (для ссылок t.me/<bot>)
+func (b *Bot) Username() string { return b.api.Self.UserName }
+
+// SetWebhook устанавливает webhook для бота с secret_token:
+// Telegram передает его в заголовке X-Telegram-Bot-Api-Secret-Token
 func (b *Bot) SetWebhook() error {
 	webhookConfig, err := tgbotapi.NewWebhook(b.webhook)
 	if err != nil {
 		return fmt.Errorf("failed to create webhook config: %w", err)
 	}
-	_, err = b.api.Request(webhookConfig)
+
+	// WebhookConfig в tgbotapi v5 не поддерживает secret_token, поэтому собираем параметры сами
+	params := tgbotapi.Params{
+		"url":          webhookConfig.URL.String(),
+		"secret_token": b.webhookSecret,
+	}
+	_, err = b.api.MakeRequest("setWebhook", params)
 	if err != nil {
 		return fmt.Errorf("failed to set webhook: %w", err)
 	}
 	return nil
 }
 
+// WebhookSecret возвращает секрет, которым Telegram подписывает запросы к webhook
+func (b *Bot) WebhookSecret() string {
+	return b.webhookSecret
+}
+
 // SetCommands устанавливает команды бота
 func (b *Bot) SetCommands() error {
 	commands := []tgbotapi.BotCommand{
@@ -162,7 +242,7 @@ func (b *Bot) SendWelcomeToNewUser(chatID int64, firstName string) error {
 	)
 	msg.ReplyMarkup = keyboard
 
-	_, err := b.api.Send(msg)
+	_, err := b.send(msg)
 	if err != nil {
 		return fmt.Errorf("failed to send welcome message: %w", err)
 	}
@@ -171,14 +251,8 @@ func (b *Bot) SendWelcomeToNewUser(chatID int64, firstName string) error {
 
 // SendMessage отправляет сообщение пользователю
 func (b *Bot) SendMessage(chatID int64, text string) error {
-	msg := tgbotapi.NewMessage(chatID, text)
-	msg.ParseMode = "HTML"
-
-	_, err := b.api.Send(msg)
-	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
-	}
-	return nil
+	_, err := b.SendMessageWithID(chatID, text)
+	return err
 }
 
 // SendNotification отправляет уведомление преподавателю о новой заявке
@@ -225,7 +299,7 @@ func (b *Bot) SendTrialRequestNotification(teacherID int64, requestData map[stri
 	msg := tgbotapi.NewMessage(teacherID, text)
 	msg.ParseMode = "HTML"
 	
-	_, err := b.api.Send(msg)
+	_, err := b.send(msg)
 	if err != nil {
 		log.Printf("Failed to send trial request notification: %v", err)
 	} else {
@@ -287,55 +361,89 @@ func (b *Bot) GetUpdates() (tgbotapi.UpdatesChannel, error) {
 	return updates, nil
 }
 
-// ProcessUpdate обрабатывает входящее обновление
-func (b *Bot) ProcessUpdate(update map[string]interface{}) {
+// ProcessUpdate обрабатывает входящее обновление.
+// Повторная доставка того же update_id (ретраи Telegram) игнорируется.
+func (b *Bot) ProcessUpdate(update tgbotapi.Update) {
+	if !b.updates.firstSeen(update.UpdateID) {
+		log.Printf("Skipping duplicate update %d", update.UpdateID)
+		return
+	}
+
 	// Проверяем, есть ли callback query (нажатие на inline-кнопку)
-	if callbackQuery, ok := update["callback_query"].(map[string]interface{}); ok {
-		b.processCallbackQuery(callbackQuery)
+	if update.CallbackQuery != nil {
+		b.processCallbackQuery(update.CallbackQuery)
 		return
 	}
 
 	// Обрабатываем обычные сообщения
-	message, ok := update["message"].(map[string]interface{})
-	if !ok {
+	message := update.Message
+	if message == nil || message.From == nil {
 		return
 	}
 
-	text, _ := message["text"].(string)
-	from, _ := message["from"].(map[string]interface{})
-	chat, _ := message["chat"].(map[string]interface{})
+	text := message.Text
+	userID := message.From.ID
+	chatID := message.Chat.ID
 
-	userID, _ := from["id"].(float64)
-	chatID, _ := chat["id"].(float64)
+	log.Printf("Received message: %s from user %d", text, userID)
 
-	log.Printf("Received message: %s from user %d", text, int64(userID))
+	// Ссылка t.me/<bot>?start=<code> приходит как "/start <code>"
+	if code, ok := strings.CutPrefix(text, "/start "); ok && strings.TrimSpace(code) != "" {
+		b.handleStartCode(message.From, chatID, strings.TrimSpace(code))
+		return
+	}
+
+	// Ответ на уведомление о сообщении уходит в чат приложения
+	if b.handleChatReply(message) {
+		return
+	}
 
 	// Обработка команд бота
 	switch text {
 	case "/start":
+		if b.onStart != nil {
+			b.onStart(userID)
+		}
 		role := "guest"
 		if b.getUserRole != nil {
-			role = b.getUserRole(int64(userID))
+			role = b.getUserRole(userID)
 		}
-		b.sendMainMenu(int64(chatID), role)
+		b.sendMainMenu(chatID, role)
 	case "/help":
-		b.sendHelpMessage(int64(chatID))
+		b.sendHelpMessage(chatID)
 	case "/app":
-		b.sendAppLink(int64(chatID))
+		b.sendAppLink(chatID)
 	case "/info":
-		b.sendTeacherInfo(int64(chatID))
+		b.sendTeacherInfo(chatID)
 	default:
 		if strings.HasPrefix(text, "/add_student") {
-			b.handleAddStudent(int64(userID), text)
+			b.handleAddStudent(userID, text)
 			return
 		}
 		// Проверяем, есть ли медиафайлы в сообщении
 		if b.hasMediaFiles(message) {
 			b.handleMediaMessage(message)
 		} else {
-			b.SendMessage(int64(chatID), "Используйте команду /start для начала работы с ботом.")
+			b.SendMessage(chatID, "Используйте команду /start для начала работы с ботом.")
+		}
+	}
+}
+
+// handleStartCode активирует код из ссылки и показывает меню с учетом новой роли
+func (b *Bot) handleStartCode(from *tgbotapi.User, chatID int64, code string) {
+	if b.onStart != nil {
+		b.onStart(from.ID)
+	}
+	if b.onStartCode != nil {
+		if reply := b.onStartCode(from, code); reply != "" {
+			b.SendMessage(chatID, reply)
 		}
 	}
+	role := "guest"
+	if b.getUserRole != nil {
+		role = b.getUserRole(from.ID)
+	}
+	b.sendMainMenu(chatID, role)
 }
 
 func (b *Bot) handleAddStudent(teacherTelegramID int64, text string) {
@@ -367,10 +475,10 @@ func (b *Bot) handleAddStudent(teacherTelegramID int64, text string) {
 		subjects = strings.Join(parts[3:], " ")
 	}
 	if err := b.assignStudent(teacherTelegramID, tgID, uname, grade, subjects); err != nil {
-		b.SendMessage(teacherTelegramID, fmt.Sprintf("Не удалось назначить ученика: %v", err))
+		b.SendMessage(teacherTelegramID, fmt.Sprintf("Не удалось пригласить ученика: %v", err))
 		return
 	}
-	b.SendMessage(teacherTelegramID, "✅ Ученик назначен")
+	b.SendMessage(teacherTelegramID, "✅ Приглашение отправлено. Ученик появится в списке, когда примет его")
 }
 
 // sendMainMenu показывает главное меню по роли
@@ -407,34 +515,34 @@ func (b *Bot) sendMainMenu(chatID int64, role string) {
 	
 	// Удаляем предыдущее системное сообщение и отправляем новое
 	b.deletePreviousSystemMessage(chatID)
-	sentMsg, err := b.api.Send(msg)
+	sentMsg, err := b.send(msg)
 	if err == nil {
-		b.systemMessages[chatID] = sentMsg.MessageID
+		b.setSystemMessage(chatID, sentMsg.MessageID)
 	}
 }
 
 // processCallbackQuery обрабатывает инлайн-кнопки
-func (b *Bot) processCallbackQuery(cb map[string]interface{}) {
-	data, _ := cb["data"].(string)
-	from, _ := cb["from"].(map[string]interface{})
-	userID, _ := from["id"].(float64)
-	message, _ := cb["message"].(map[string]interface{})
-	chat, _ := message["chat"].(map[string]interface{})
-	chatID, _ := chat["id"].(float64)
+func (b *Bot) processCallbackQuery(cb *tgbotapi.CallbackQuery) {
+	if cb.From == nil || cb.Message == nil {
+		return
+	}
+	data := cb.Data
+	userID := cb.From.ID
+	chatID := cb.Message.Chat.ID
 
 	switch data {
 	case "help":
-		b.sendHelpMessage(int64(chatID))
+		b.sendHelpMessage(chatID)
 	case "show_groups":
-		b.renderGroupsList(int64(chatID), int64(userID))
+		b.renderGroupsList(chatID, userID)
 	case "student_submit_mode":
-		b.enterStudentSubmitMode(int64(chatID), int64(userID))
+		b.enterStudentSubmitMode(chatID, userID)
 	case "teacher_feedback_mode":
-		b.enterTeacherFeedbackMode(int64(chatID), int64(userID))
+		b.enterTeacherFeedbackMode(chatID, userID)
 	case "exit_submit_mode":
-		b.exitStudentSubmitMode(int64(chatID), int64(userID))
+		b.exitStudentSubmitMode(chatID, userID)
 	case "exit_feedback_mode":
-		b.exitTeacherFeedbackMode(int64(chatID), int64(userID))
+		b.exitTeacherFeedbackMode(chatID, userID)
 	default:
 		// no-op
 	}
@@ -465,34 +573,26 @@ func (b *Bot) renderGroupsList(chatID, teacherTelegramID int64) {
 	kb := tgbotapi.NewInlineKeyboardMarkup(rows...)
 	msg := tgbotapi.NewMessage(chatID, "Ваши группы:")
 	msg.ReplyMarkup = kb
-	_, _ = b.api.Send(msg)
+	_, _ = b.send(msg)
 }
 
 // hasMediaFiles проверяет, содержит ли сообщение медиафайлы
-func (b *Bot) hasMediaFiles(message map[string]interface{}) bool {
+func (b *Bot) hasMediaFiles(message *tgbotapi.Message) bool {
 	// Проверяем различные типы медиафайлов
-	_, hasPhoto := message["photo"]
-	_, hasVideo := message["video"]
-	_, hasAudio := message["audio"]
-	_, hasDocument := message["document"]
-	_, hasVoice := message["voice"]
-
-	return hasPhoto || hasVideo || hasAudio || hasDocument || hasVoice
+	return len(message.Photo) > 0 ||
+		message.Video != nil ||
+		message.Audio != nil ||
+		message.Document != nil ||
+		message.Voice != nil
 }
 
 // handleMediaMessage обрабатывает сообщения с медиафайлами
-func (b *Bot) handleMediaMessage(message map[string]interface{}) {
-	from, _ := message["from"].(map[string]interface{})
-	chat, _ := message["chat"].(map[string]interface{})
-
-	userID, _ := from["id"].(float64)
-	chatID, _ := chat["id"].(float64)
-
-	log.Printf("Received media message from user %d", int64(userID))
+func (b *Bot) handleMediaMessage(message *tgbotapi.Message) {
+	log.Printf("Received media message from user %d", message.From.ID)
 
 	// Здесь нужно будет интегрировать с MediaService
 	// Пока просто отправляем подтверждение
-	b.SendMessage(int64(chatID), "📎 Медиафайл получен! Спасибо за отправку.")
+	b.SendMessage(message.Chat.ID, "📎 Медиафайл получен! Спасибо за отправку.")
 }
 
 // duplicate callback handler removed (используется новая версия выше)
@@ -528,11 +628,11 @@ func (b *Bot) sendWelcomeMessage(chatID int64) error {
 
 	// Удаляем предыдущее системное сообщение и отправляем новое
 	b.deletePreviousSystemMessage(chatID)
-	sentMsg, err := b.api.Send(msg)
+	sentMsg, err := b.send(msg)
 	if err != nil {
 		return fmt.Errorf("failed to send welcome message: %w", err)
 	}
-	b.systemMessages[chatID] = sentMsg.MessageID
+	b.setSystemMessage(chatID, sentMsg.MessageID)
 	return nil
 }
 
@@ -585,11 +685,11 @@ func (b *Bot) sendHelpMessage(chatID int64) error {
 
 	// Удаляем предыдущее системное сообщение и отправляем новое
 	b.deletePreviousSystemMessage(chatID)
-	sentMsg, err := b.api.Send(msg)
+	sentMsg, err := b.send(msg)
 	if err != nil {
 		return fmt.Errorf("failed to send help message: %w", err)
 	}
-	b.systemMessages[chatID] = sentMsg.MessageID
+	b.setSystemMessage(chatID, sentMsg.MessageID)
 	return nil
 }
 
@@ -618,7 +718,7 @@ func (b *Bot) sendAppLink(chatID int64) error {
 	)
 	msg.ReplyMarkup = keyboard
 
-	_, err := b.api.Send(msg)
+	_, err := b.send(msg)
 	if err != nil {
 		return fmt.Errorf("failed to send app link: %w", err)
 	}
@@ -663,7 +763,7 @@ Telegram: @pugach3
 	)
 	msg.ReplyMarkup = keyboard
 
-	_, err := b.api.Send(msg)
+	_, err := b.send(msg)
 	if err != nil {
 		return fmt.Errorf("failed to send teacher info: %w", err)
 	}
@@ -693,7 +793,7 @@ func (b *Bot) SendAssignmentCompletedNotification(chatID int64, title, subject,
 	)
 	msg.ReplyMarkup = keyboard
 
-	_, err := b.api.Send(msg)
+	_, err := b.send(msg)
 	if err != nil {
 		return fmt.Errorf("failed to send assignment completed notification: %w", err)
 	}
@@ -723,7 +823,7 @@ func (b *Bot) SendCommentNotification(chatID int64, content, title, subject stri
 	)
 	msg.ReplyMarkup = keyboard
 
-	_, err := b.api.Send(msg)
+	_, err := b.send(msg)
 	if err != nil {
 		return fmt.Errorf("failed to send comment notification: %w", err)
 	}
@@ -817,7 +917,7 @@ func (b *Bot) SendMediaUploadInstructions(chatID int64, mediaType string) error
 	msg.ParseMode = "HTML"
 	msg.ReplyMarkup = keyboard
 
-	_, err := b.api.Send(msg)
+	_, err := b.send(msg)
 	if err != nil {
 		return fmt.Errorf("failed to send media upload instructions: %w", err)
 	}
@@ -861,7 +961,7 @@ func (b *Bot) SendMediaUploadSuccess(chatID int64, mediaType, fileName string) e
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = "HTML"
 
-	_, err := b.api.Send(msg)
+	_, err := b.send(msg)
 	if err != nil {
 		return fmt.Errorf("failed to send media upload success: %w", err)
 	}
@@ -879,7 +979,7 @@ func (b *Bot) SendMediaUploadError(chatID int64, errorMsg string) error {
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = "HTML"
 
-	_, err := b.api.Send(msg)
+	_, err := b.send(msg)
 	if err != nil {
 		return fmt.Errorf("failed to send media upload error: %w", err)
 	}
@@ -1023,7 +1123,7 @@ func (b *Bot) enterStudentSubmitMode(chatID, userID int64) {
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = "HTML"
 	msg.ReplyMarkup = keyboard
-	_, _ = b.api.Send(msg)
+	_, _ = b.send(msg)
 }
 
 // exitStudentSubmitMode выходит из режима сдачи ДЗ
@@ -1066,7 +1166,7 @@ func (b *Bot) enterTeacherFeedbackMode(chatID, userID int64) {
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = "HTML"
 	msg.ReplyMarkup = keyboard
-	_, _ = b.api.Send(msg)
+	_, _ = b.send(msg)
 }
 
 // exitTeacherFeedbackMode выходит из режима записи фидбэка
@@ -1128,7 +1228,7 @@ func (b *Bot) SendAssignmentDeepLink(chatID int64, assignmentID string, assignme
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = "HTML"
 	msg.ReplyMarkup = keyboard
-	_, err := b.api.Send(msg)
+	_, err := b.send(msg)
 	return err
 }
 
@@ -1155,7 +1255,7 @@ func (b *Bot) SendProgressDeepLink(chatID int64, subject string) error {
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = "HTML"
 	msg.ReplyMarkup = keyboard
-	_, err := b.api.Send(msg)
+	_, err := b.send(msg)
 	return err
 }
 
@@ -1182,6 +1282,6 @@ func (b *Bot) SendChatDeepLink(chatID int64, teacherName string) error {
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = "HTML"
 	msg.ReplyMarkup = keyboard
-	_, err := b.api.Send(msg)
+	_, err := b.send(msg)
 	return err
 }
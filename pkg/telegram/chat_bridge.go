@@ -0,0 +1,112 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// IncomingFile — файл из входящего сообщения Telegram
+type IncomingFile struct {
+	FileID   string
+	UniqueID string
+	Kind     string // "image", "video", "audio", "document" — совпадает с models.MediaType
+	MimeType string
+	FileName string
+	Size     int64
+}
+
+// ChatReply — ответ пользователя на сообщение бота (reply)
+type ChatReply struct {
+	ChatID           int64
+	FromID           int64
+	MessageID        int
+	ReplyToMessageID int
+	Text             string // Текст или подпись к файлу
+	Files            []IncomingFile
+}
+
+// SetOnChatReply задает обработчик ответов на сообщения бота.
+// handled = false — ответ не относится к чатам, сообщение обрабатывается как обычно.
+func (b *Bot) SetOnChatReply(cb func(reply ChatReply) (text string, handled bool)) {
+	b.onChatReply = cb
+}
+
+// SendMessageWithID отправляет сообщение и возвращает его ID в Telegram
+func (b *Bot) SendMessageWithID(chatID int64, text string) (int, error) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "HTML"
+
+	sent, err := b.send(msg)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send message: %w", classifyError(err))
+	}
+	return sent.MessageID, nil
+}
+
+// handleChatReply передает ответ на сообщение бота обработчику; true — ответ обработан
+func (b *Bot) handleChatReply(message *tgbotapi.Message) bool {
+	if b.onChatReply == nil || message.ReplyToMessage == nil || strings.HasPrefix(message.Text, "/") {
+		return false
+	}
+
+	reply := ChatReply{
+		ChatID:           message.Chat.ID,
+		FromID:           message.From.ID,
+		MessageID:        message.MessageID,
+		ReplyToMessageID: message.ReplyToMessage.MessageID,
+		Text:             message.Text,
+		Files:            incomingFiles(message),
+	}
+	if reply.Text == "" {
+		reply.Text = message.Caption
+	}
+
+	text, handled := b.onChatReply(reply)
+	if handled && text != "" {
+		b.SendMessage(message.Chat.ID, text)
+	}
+	return handled
+}
+
+// incomingFiles собирает файлы сообщения; у фото берется самый большой размер
+func incomingFiles(message *tgbotapi.Message) []IncomingFile {
+	var files []IncomingFile
+	if n := len(message.Photo); n > 0 {
+		photo := message.Photo[n-1]
+		files = append(files, IncomingFile{
+			FileID: photo.FileID, UniqueID: photo.FileUniqueID, Kind: "image",
+			MimeType: "image/jpeg", Size: int64(photo.FileSize),
+		})
+	}
+	if d := message.Document; d != nil {
+		kind := "document"
+		if strings.HasPrefix(d.MimeType, "image/") {
+			kind = "image"
+		}
+		files = append(files, IncomingFile{
+			FileID: d.FileID, UniqueID: d.FileUniqueID, Kind: kind,
+			MimeType: d.MimeType, FileName: d.FileName, Size: int64(d.FileSize),
+		})
+	}
+	if v := message.Video; v != nil {
+		files = append(files, IncomingFile{
+			FileID: v.FileID, UniqueID: v.FileUniqueID, Kind: "video",
+			MimeType: v.MimeType, FileName: v.FileName, Size: int64(v.FileSize),
+		})
+	}
+	if a := message.Audio; a != nil {
+		files = append(files, IncomingFile{
+			FileID: a.FileID, UniqueID: a.FileUniqueID, Kind: "audio",
+			MimeType: a.MimeType, FileName: a.FileName, Size: int64(a.FileSize),
+		})
+	}
+	if v := message.Voice; v != nil {
+		files = append(files, IncomingFile{
+			FileID: v.FileID, UniqueID: v.FileUniqueID, Kind: "audio",
+			MimeType: v.MimeType, Size: int64(v.FileSize),
+		})
+	}
+	return files
+}
@@ -0,0 +1,89 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ErrorKind — категория ошибки Telegram Bot API
+type ErrorKind string
+
+const (
+	ErrorKindBlocked      ErrorKind = "blocked"        // Пользователь заблокировал бота или удалил аккаунт
+	ErrorKindChatNotFound ErrorKind = "chat_not_found" // Пользователь ни разу не писал боту
+	ErrorKindRateLimited  ErrorKind = "rate_limited"   // Превышен лимит запросов, см. RetryAfter
+	ErrorKindOther        ErrorKind = "other"
+)
+
+// APIError — классифицированная ошибка Telegram Bot API
+type APIError struct {
+	Kind       ErrorKind
+	Code       int
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("telegram %s (%d): %v", e.Kind, e.Code, e.Err)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Unreachable сообщает, что писать пользователю в бот бесполезно, пока он сам не нажмет /start
+func (e *APIError) Unreachable() bool {
+	return e.Kind == ErrorKindBlocked || e.Kind == ErrorKindChatNotFound
+}
+
+// AsAPIError извлекает APIError из цепочки ошибок
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// IsUnreachable сообщает, что пользователь заблокировал бота или чат с ним не найден
+func IsUnreachable(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.Unreachable()
+}
+
+// classifyError оборачивает ошибку Bot API в APIError; прочие ошибки (сеть и т.п.) возвращает как есть
+func classifyError(err error) error {
+	if _, ok := AsAPIError(err); ok || err == nil {
+		return err
+	}
+
+	var tgErr *tgbotapi.Error
+	if !errors.As(err, &tgErr) {
+		var tgErrValue tgbotapi.Error
+		if !errors.As(err, &tgErrValue) {
+			return err
+		}
+		tgErr = &tgErrValue
+	}
+
+	apiErr := &APIError{Kind: ErrorKindOther, Code: tgErr.Code, Err: err}
+	message := strings.ToLower(tgErr.Message)
+
+	switch {
+	case tgErr.Code == http.StatusTooManyRequests || tgErr.RetryAfter > 0:
+		apiErr.Kind = ErrorKindRateLimited
+		apiErr.RetryAfter = time.Duration(tgErr.RetryAfter) * time.Second
+	case tgErr.Code == http.StatusForbidden:
+		// "bot was blocked by the user", "user is deactivated", "bot can't initiate conversation with a user"
+		apiErr.Kind = ErrorKindBlocked
+	case tgErr.Code == http.StatusBadRequest && strings.Contains(message, "chat not found"):
+		apiErr.Kind = ErrorKindChatNotFound
+	}
+
+	return apiErr
+}
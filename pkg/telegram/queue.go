@@ -0,0 +1,354 @@
+package telegram
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ErrQueueClosed возвращается при отправке после остановки очереди
+var ErrQueueClosed = errors.New("telegram send queue is closed")
+
+// QueueConfig содержит ограничения исходящих сообщений.
+// Telegram допускает около 30 сообщений в секунду на бота и 1 сообщение в секунду в один чат.
+type QueueConfig struct {
+	GlobalRate  float64 // Сообщений в секунду на бота
+	PerChatRate float64 // Сообщений в секунду в один чат
+	Workers     int     // Одновременных запросов к Bot API
+	QueueSize   int     // Емкость очереди; при заполнении Send ждет
+	MaxRetries  int     // Повторов после ответа 429
+}
+
+// DefaultQueueConfig возвращает лимиты по умолчанию
+func DefaultQueueConfig() QueueConfig {
+	return QueueConfig{
+		GlobalRate:  30,
+		PerChatRate: 1,
+		Workers:     4,
+		QueueSize:   1000,
+		MaxRetries:  3,
+	}
+}
+
+// QueueStats — метрики очереди отправки
+type QueueStats struct {
+	Depth       int64 `json:"depth"`        // Ожидают отправки
+	MaxDepth    int64 `json:"max_depth"`    // Наибольшая глубина с момента запуска
+	InFlight    int64 `json:"in_flight"`    // Отправляются сейчас
+	Sent        int64 `json:"sent"`         // Отправлено успешно
+	Failed      int64 `json:"failed"`       // Завершились ошибкой
+	RateLimited int64 `json:"rate_limited"` // Получено ответов 429
+}
+
+type sendResult struct {
+	message tgbotapi.Message
+	err     error
+}
+
+type sendJob struct {
+	chatID int64
+	msg    tgbotapi.Chattable
+	result chan sendResult
+}
+
+// SendQueue отправляет сообщения через ограниченное число воркеров
+// с лимитами token bucket на бота и на каждый чат
+type SendQueue struct {
+	cfg  QueueConfig
+	send func(tgbotapi.Chattable) (tgbotapi.Message, error)
+
+	jobs     chan *sendJob
+	stop     chan struct{}
+	drained  chan struct{} // Закрывается, когда Stop завершил оставшиеся в очереди сообщения
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+
+	mu     sync.Mutex
+	global *tokenBucket
+	chats  map[int64]*tokenBucket
+
+	depth       int64
+	maxDepth    int64
+	inFlight    int64
+	sent        int64
+	failed      int64
+	rateLimited int64
+}
+
+// NewSendQueue создает очередь и запускает воркеры
+func NewSendQueue(cfg QueueConfig, send func(tgbotapi.Chattable) (tgbotapi.Message, error)) *SendQueue {
+	defaults := DefaultQueueConfig()
+	if cfg.GlobalRate <= 0 {
+		cfg.GlobalRate = defaults.GlobalRate
+	}
+	if cfg.PerChatRate <= 0 {
+		cfg.PerChatRate = defaults.PerChatRate
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaults.Workers
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaults.QueueSize
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+
+	q := &SendQueue{
+		cfg:     cfg,
+		send:    send,
+		jobs:    make(chan *sendJob, cfg.QueueSize),
+		stop:    make(chan struct{}),
+		drained: make(chan struct{}),
+		global:  newTokenBucket(cfg.GlobalRate, cfg.GlobalRate),
+		chats:   make(map[int64]*tokenBucket),
+	}
+
+	for i := 0; i < cfg.Workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+	return q
+}
+
+// Send ставит сообщение в очередь и ждет результата отправки
+func (q *SendQueue) Send(chatID int64, msg tgbotapi.Chattable) (tgbotapi.Message, error) {
+	select {
+	case <-q.stop:
+		return tgbotapi.Message{}, ErrQueueClosed
+	default:
+	}
+
+	job := &sendJob{chatID: chatID, msg: msg, result: make(chan sendResult, 1)}
+
+	depth := atomic.AddInt64(&q.depth, 1)
+	for {
+		max := atomic.LoadInt64(&q.maxDepth)
+		if depth <= max || atomic.CompareAndSwapInt64(&q.maxDepth, max, depth) {
+			break
+		}
+	}
+
+	select {
+	case q.jobs <- job:
+	case <-q.stop:
+		atomic.AddInt64(&q.depth, -1)
+		return tgbotapi.Message{}, ErrQueueClosed
+	}
+
+	return q.awaitResult(job)
+}
+
+// awaitResult ждет результата отправки. Сообщение, попавшее в очередь уже после того,
+// как Stop ее опустошил, никто не обработает — оно завершается ErrQueueClosed.
+func (q *SendQueue) awaitResult(job *sendJob) (tgbotapi.Message, error) {
+	select {
+	case res := <-job.result:
+		return res.message, res.err
+	case <-q.drained:
+		select {
+		case res := <-job.result:
+			return res.message, res.err
+		default:
+			atomic.AddInt64(&q.depth, -1)
+			return tgbotapi.Message{}, ErrQueueClosed
+		}
+	}
+}
+
+// Stats возвращает текущие метрики очереди
+func (q *SendQueue) Stats() QueueStats {
+	return QueueStats{
+		Depth:       atomic.LoadInt64(&q.depth),
+		MaxDepth:    atomic.LoadInt64(&q.maxDepth),
+		InFlight:    atomic.LoadInt64(&q.inFlight),
+		Sent:        atomic.LoadInt64(&q.sent),
+		Failed:      atomic.LoadInt64(&q.failed),
+		RateLimited: atomic.LoadInt64(&q.rateLimited),
+	}
+}
+
+// Stop останавливает воркеры; сообщения, оставшиеся в очереди, завершаются ErrQueueClosed
+func (q *SendQueue) Stop() {
+	q.stopOnce.Do(func() {
+		close(q.stop)
+		q.wg.Wait()
+		defer close(q.drained)
+		for {
+			select {
+			case job := <-q.jobs:
+				atomic.AddInt64(&q.depth, -1)
+				job.result <- sendResult{err: ErrQueueClosed}
+			default:
+				return
+			}
+		}
+	})
+}
+
+func (q *SendQueue) worker() {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.stop:
+			return
+		case job := <-q.jobs:
+			atomic.AddInt64(&q.depth, -1)
+			atomic.AddInt64(&q.inFlight, 1)
+			message, err := q.process(job)
+			atomic.AddInt64(&q.inFlight, -1)
+			if err != nil {
+				atomic.AddInt64(&q.failed, 1)
+			} else {
+				atomic.AddInt64(&q.sent, 1)
+			}
+			job.result <- sendResult{message: message, err: err}
+		}
+	}
+}
+
+// process ждет разрешения лимитеров и отправляет сообщение, повторяя после 429
+func (q *SendQueue) process(job *sendJob) (tgbotapi.Message, error) {
+	for attempt := 0; ; attempt++ {
+		// Сначала ждем очереди чата, затем общий лимит — так токен бота
+		// не расходуется, пока сообщение стоит в очереди своего чата
+		if err := q.wait(q.reserveChat(job.chatID)); err != nil {
+			return tgbotapi.Message{}, err
+		}
+		if err := q.wait(q.reserveGlobal()); err != nil {
+			return tgbotapi.Message{}, err
+		}
+
+		message, err := q.send(job.msg)
+		if err == nil {
+			return message, nil
+		}
+
+		classified := classifyError(err)
+		apiErr, ok := AsAPIError(classified)
+		if !ok || apiErr.Kind != ErrorKindRateLimited {
+			return message, classified
+		}
+
+		atomic.AddInt64(&q.rateLimited, 1)
+		retryAfter := apiErr.RetryAfter
+		if retryAfter <= 0 {
+			retryAfter = time.Second
+		}
+		q.pause(job.chatID, retryAfter)
+
+		if attempt >= q.cfg.MaxRetries {
+			return message, classified
+		}
+		log.Printf("Telegram rate limit for chat %d, retrying in %s", job.chatID, retryAfter)
+	}
+}
+
+func (q *SendQueue) wait(d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-q.stop:
+		return ErrQueueClosed
+	}
+}
+
+func (q *SendQueue) reserveGlobal() time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.global.reserve(time.Now())
+}
+
+func (q *SendQueue) reserveChat(chatID int64) time.Duration {
+	if chatID == 0 {
+		return 0
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	bucket, ok := q.chats[chatID]
+	if !ok {
+		q.pruneChats(now)
+		bucket = newTokenBucket(q.cfg.PerChatRate, 1)
+		q.chats[chatID] = bucket
+	}
+	return bucket.reserve(now)
+}
+
+// pause приостанавливает отправку в чат и для всего бота на время retry_after
+func (q *SendQueue) pause(chatID int64, d time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	until := time.Now().Add(d)
+	q.global.pause(until)
+	if bucket, ok := q.chats[chatID]; ok {
+		bucket.pause(until)
+	}
+}
+
+// pruneChats удаляет лимитеры чатов, которые успели полностью восстановиться
+func (q *SendQueue) pruneChats(now time.Time) {
+	if len(q.chats) < 1024 {
+		return
+	}
+	for chatID, bucket := range q.chats {
+		if bucket.idle(now) {
+			delete(q.chats, chatID)
+		}
+	}
+}
+
+// tokenBucket — лимитер с резервированием (GCRA): каждый вызов reserve занимает
+// следующий свободный слот и возвращает, сколько ждать до него
+type tokenBucket struct {
+	interval  time.Duration // Время восстановления одного токена
+	tolerance time.Duration // Запас на burst
+	tat       time.Time     // Теоретическое время прибытия следующего сообщения
+}
+
+func newTokenBucket(rate, burst float64) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	interval := time.Duration(float64(time.Second) / rate)
+	return &tokenBucket{
+		interval:  interval,
+		tolerance: time.Duration((burst - 1) * float64(interval)),
+	}
+}
+
+// reserve занимает слот и возвращает, сколько нужно подождать перед отправкой
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	if b.tat.Before(now) {
+		b.tat = now
+	}
+	sendAt := b.tat.Add(-b.tolerance)
+	b.tat = b.tat.Add(b.interval)
+	if sendAt.Before(now) {
+		return 0
+	}
+	return sendAt.Sub(now)
+}
+
+// pause сдвигает ближайший слот не раньше until; burst после паузы не накапливается
+func (b *tokenBucket) pause(until time.Time) {
+	if next := until.Add(b.tolerance); next.After(b.tat) {
+		b.tat = next
+	}
+}
+
+func (b *tokenBucket) idle(now time.Time) bool {
+	return !b.tat.After(now)
+}
@@ -0,0 +1,53 @@
+package telegram
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func newTestSendQueue() *SendQueue {
+	return NewSendQueue(QueueConfig{GlobalRate: 1000, PerChatRate: 1000, Workers: 1, QueueSize: 4}, func(tgbotapi.Chattable) (tgbotapi.Message, error) {
+		return tgbotapi.Message{MessageID: 1}, nil
+	})
+}
+
+func TestSendQueueSendAfterStop(t *testing.T) {
+	q := newTestSendQueue()
+	if _, err := q.Send(1, tgbotapi.NewMessage(1, "hi")); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	q.Stop()
+	if _, err := q.Send(1, tgbotapi.NewMessage(1, "hi")); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Send after Stop: expected ErrQueueClosed, got %v", err)
+	}
+}
+
+func TestSendQueueJobEnqueuedAfterDrain(t *testing.T) {
+	q := newTestSendQueue()
+	q.Stop()
+
+	// Send прошел проверку остановки до Stop, а в очередь попал уже после ее опустошения
+	job := &sendJob{chatID: 1, msg: tgbotapi.NewMessage(1, "hi"), result: make(chan sendResult, 1)}
+	q.depth++
+	q.jobs <- job
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := q.awaitResult(job)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrQueueClosed) {
+			t.Errorf("expected ErrQueueClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("sender blocked after Stop")
+	}
+	if depth := q.Stats().Depth; depth != 0 {
+		t.Errorf("depth = %d, want 0", depth)
+	}
+}
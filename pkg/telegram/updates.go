@@ -0,0 +1,76 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Режимы получения обновлений
+const (
+	ModeWebhook = "webhook"
+	ModePolling = "polling"
+)
+
+// updateDedupeSize — сколько последних update_id помнить
+const updateDedupeSize = 1000
+
+// updateDeduper помнит последние update_id, чтобы не обрабатывать ретраи Telegram повторно
+type updateDeduper struct {
+	mu    sync.Mutex
+	seen  map[int]struct{}
+	order []int
+	size  int
+}
+
+func newUpdateDeduper(size int) *updateDeduper {
+	return &updateDeduper{
+		seen:  make(map[int]struct{}, size),
+		order: make([]int, 0, size),
+		size:  size,
+	}
+}
+
+// firstSeen отмечает update_id и сообщает, встретился ли он впервые
+func (d *updateDeduper) firstSeen(updateID int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.seen[updateID]; ok {
+		return false
+	}
+	if len(d.order) >= d.size {
+		delete(d.seen, d.order[0])
+		d.order = d.order[1:]
+	}
+	d.seen[updateID] = struct{}{}
+	d.order = append(d.order, updateID)
+	return true
+}
+
+// StartPolling удаляет webhook и получает обновления через long polling (для локальной разработки)
+func (b *Bot) StartPolling() error {
+	if _, err := b.api.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	updates, err := b.GetUpdates()
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for update := range updates {
+			b.ProcessUpdate(update)
+		}
+		log.Printf("Telegram polling stopped")
+	}()
+	return nil
+}
+
+// StopPolling прекращает получение обновлений
+func (b *Bot) StopPolling() {
+	b.api.StopReceivingUpdates()
+}
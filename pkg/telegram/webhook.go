@@ -0,0 +1,25 @@
+package telegram
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+)
+
+// WebhookSecretHeader — заголовок, в котором Telegram передает secret_token
+const WebhookSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// DeriveWebhookSecret выводит secret_token из токена бота.
+// Telegram допускает 1-256 символов A-Z, a-z, 0-9, _ и -, hex подходит.
+func DeriveWebhookSecret(botToken string) string {
+	sum := sha256.Sum256([]byte("edubot-webhook:" + botToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyWebhookSecret сравнивает секрет из заголовка с ожидаемым за постоянное время
+func VerifyWebhookSecret(expected, got string) bool {
+	if expected == "" || got == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(got)) == 1
+}
@@ -39,16 +39,8 @@ function initializeTelegramWebApp() {
         // Жёсткая авто-авторизация в WebApp: если есть initDataUnsafe.user — авторизуемся без вопросов
         const tgUser = window.Telegram.WebApp.initDataUnsafe && window.Telegram.WebApp.initDataUnsafe.user;
         if (tgUser) {
-            const telegramData = {
-                id: tgUser.id,
-                first_name: tgUser.first_name || '',
-                last_name: tgUser.last_name || '',
-                username: tgUser.username || '',
-                photo_url: tgUser.photo_url || '',
-                auth_date: Math.floor(Date.now() / 1000),
-                hash: ''
-            };
-            authenticateWithTelegram(telegramData)
+            // Сервер проверяет подпись initData, поэтому отправляем строку целиком
+            authenticateWithTelegram({ init_data: window.Telegram.WebApp.initData })
                 .then((result) => {
                     // Остаёмся на текущей странице, просто обновляем UI
                     updateUIForUser(result.user);
@@ -261,7 +253,8 @@ async function handleTrialSubmission(e) {
         level: parseInt(rawData.level),
         contact_type: contactType,
         contact_value: contactValue,
-        comment: rawData.comment || ''
+        comment: rawData.comment || '',
+        website: rawData.website || ''
     };
     
     // Валидация данных
@@ -651,11 +644,8 @@ function handleInviteLinkPostAuth() {
     const url = new URL(window.location.href);
     const invite = url.searchParams.get('invite');
     if (invite) {
-        api.post('/api/public/register-student', { inviteCode: invite })
-            .then((result) => {
-                if (result && result.token) {
-                    localStorage.setItem('authToken', result.token);
-                }
+        api.post('/api/invites/redeem', { code: invite })
+            .then(() => {
                 showSuccess('Добро пожаловать! Аккаунт ученика привязан.');
                 setTimeout(() => window.location.href = '/student-dashboard', 1200);
             })
@@ -680,6 +670,7 @@ function prefillInviteFromURL() {
 
 // Выход из системы
 function logout() {
+    if (typeof revokeCurrentSession === 'function') revokeCurrentSession();
     authToken = null;
     currentUser = null;
     localStorage.removeItem('authToken');
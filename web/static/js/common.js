@@ -40,11 +40,96 @@ document.addEventListener('click', function(event) {
 // Унифицированная функция выхода
 function logout() {
     if (confirm('Вы уверены, что хотите выйти?')) {
+        revokeCurrentSession();
         localStorage.removeItem('authToken');
         window.location.href = '/app';
     }
 }
 
+// Завершение текущей сессии на сервере (refresh-токен перестает действовать)
+function revokeCurrentSession() {
+    const token = localStorage.getItem('authToken');
+    if (!token) return;
+    fetch('/api/auth/logout', {
+        method: 'POST',
+        headers: { 'Authorization': 'Bearer ' + token },
+        credentials: 'include',
+        keepalive: true
+    }).catch(() => {});
+}
+
+// Access-токен живет недолго: при ответе 401 от API обновляем его по refresh-cookie
+// и повторяем запрос, а незадолго до истечения обновляем заранее
+(function () {
+    const originalFetch = window.fetch.bind(window);
+    let refreshPromise = null;
+
+    function refreshAccessToken() {
+        if (!refreshPromise) {
+            refreshPromise = originalFetch('/api/public/auth/refresh', { method: 'POST', credentials: 'include' })
+                .then(resp => resp.ok ? resp.json() : null)
+                .then(data => {
+                    if (data && data.token) {
+                        localStorage.setItem('authToken', data.token);
+                        scheduleRefresh(data.token);
+                        return data.token;
+                    }
+                    return null;
+                })
+                .catch(() => null)
+                .finally(() => { refreshPromise = null; });
+        }
+        return refreshPromise;
+    }
+
+    function tokenExpiresAt(token) {
+        try {
+            const payload = JSON.parse(atob(token.split('.')[1].replace(/-/g, '+').replace(/_/g, '/')));
+            return payload.exp ? payload.exp * 1000 : 0;
+        } catch (e) {
+            return 0;
+        }
+    }
+
+    let refreshTimer = null;
+    function scheduleRefresh(token) {
+        const expiresAt = tokenExpiresAt(token);
+        if (!expiresAt) return;
+        clearTimeout(refreshTimer);
+        refreshTimer = setTimeout(refreshAccessToken, Math.max(expiresAt - Date.now() - 60000, 0));
+    }
+
+    function isAPIRequest(url) {
+        const path = url.startsWith(location.origin) ? url.slice(location.origin.length) : url;
+        return path.startsWith('/api/') && !path.startsWith('/api/public/auth/');
+    }
+
+    window.fetch = async function (input, init) {
+        const url = typeof input === 'string' ? input : input.url;
+        const response = await originalFetch(input, init);
+        if (response.status !== 401 || !isAPIRequest(url)) {
+            return response;
+        }
+
+        const token = await refreshAccessToken();
+        if (!token) {
+            return response;
+        }
+
+        // Повторяем запрос с новым токеном
+        const headers = new Headers((init && init.headers) || (input instanceof Request ? input.headers : undefined));
+        headers.set('Authorization', 'Bearer ' + token);
+        return originalFetch(input, Object.assign({}, init, { headers, credentials: 'include' }));
+    };
+
+    window.refreshAccessToken = refreshAccessToken;
+
+    const currentToken = localStorage.getItem('authToken');
+    if (currentToken) {
+        scheduleRefresh(currentToken);
+    }
+})();
+
 // Унифицированные функции уведомлений
 function showSuccess(message) {
     // Создаем уведомление если нет контейнера